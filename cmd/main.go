@@ -1,12 +1,12 @@
 package main
 
 import (
-	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
+	"github.com/spf13/cobra"
 	"github.com/zakirkun/neon/internal/cli"
 	"github.com/zakirkun/neon/internal/config"
 	"github.com/zakirkun/neon/internal/docker"
@@ -20,41 +20,47 @@ var (
 )
 
 func main() {
-	// Add version flag
-	showVersion := flag.Bool("version", false, "Show version information")
-	// Parse all flags
-	flag.Parse()
+	cli.SetVersion(fmt.Sprintf("%s (%s)", version, commit))
+	cli.SetStartup(startup)
 
-	if *showVersion {
-		fmt.Printf("Neon v%s (%s)\n", version, commit)
-		return
+	if err := cli.Execute(); err != nil {
+		log.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
+}
 
+// startup dijalankan sebelum setiap command, setelah flag global --config
+// dan --context diparse.
+func startup(cmd *cobra.Command) error {
 	// Initialize logger
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		log.Fatalf("Failed to get home directory: %v", err)
+		return fmt.Errorf("failed to get home directory: %v", err)
 	}
 
 	logDir := filepath.Join(homeDir, ".neon", "logs")
 	if err := logger.Init(logDir); err != nil {
-		log.Fatalf("Failed to initialize logger: %v", err)
+		return fmt.Errorf("failed to initialize logger: %v", err)
 	}
 
 	// Initialize config
 	if err := config.Load(); err != nil {
 		if !os.IsNotExist(err) {
 			logger.Error(err, "Failed to load configuration")
-		} else {
-			logger.Warn("No config file found, using defaults")
+			return fmt.Errorf("invalid configuration\n%v", err)
 		}
+		logger.Warn("No config file found, using defaults")
+	}
+
+	if !cli.NeedsDocker(cmd) {
+		return nil
 	}
 
 	// Initialize Docker client
 	client, err := docker.NewClient()
 	if err != nil {
 		logger.Error(err, "Failed to initialize Docker client")
-		os.Exit(1)
+		return err
 	}
 
 	// Check Docker and Swarm status
@@ -62,19 +68,22 @@ func main() {
 
 	if err := checker.CheckDockerStatus(); err != nil {
 		logger.Error(err, "Docker health check failed")
-		fmt.Println("Error: Docker daemon is not running")
-		os.Exit(1)
+		if name := config.ContextName(); name != "" {
+			return fmt.Errorf("Docker daemon for context %s is not reachable", name)
+		}
+		return fmt.Errorf("Docker daemon is not running")
+	}
+
+	if !cli.NeedsSwarm(cmd) {
+		return nil
 	}
 
 	if err := checker.CheckSwarmStatus(); err != nil {
 		logger.Error(err, "Swarm health check failed")
 		fmt.Println("Warning: Docker Swarm mode is not enabled")
 		fmt.Println("Some features may not be available")
-		fmt.Println("To enable Swarm mode, run: docker swarm init")
+		fmt.Println("To enable Swarm mode, run: neon swarm init")
 	}
 
-	if err := cli.Execute(); err != nil {
-		log.Printf("Error: %v\n", err)
-		os.Exit(1)
-	}
+	return nil
 }
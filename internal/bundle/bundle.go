@@ -0,0 +1,197 @@
+// Package bundle mengelola paket deploy yang dapat dipakai ulang (mirip
+// chart Helm) untuk Docker Swarm. Sebuah bundle adalah direktori berisi:
+//
+//	bundle.yaml          nama, versi, dan deskripsi bundle
+//	values.yaml          nilai default
+//	values.schema.yaml   (opsional) skema values
+//	templates/*.yaml     template deploy.yaml atau compose (text/template)
+//
+// Template yang nama file-nya mengandung "compose" dibaca sebagai file
+// compose; selain itu sebagai deploy.yaml.
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/zakirkun/neon/internal/config/compose"
+	"github.com/zakirkun/neon/internal/config/deploy"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	MetadataFile = "bundle.yaml"
+	ValuesFile   = "values.yaml"
+	SchemaFile   = "values.schema.yaml"
+	TemplatesDir = "templates"
+)
+
+type Metadata struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+}
+
+type Bundle struct {
+	Metadata
+	Dir       string
+	Defaults  Values
+	Schema    *Schema
+	templates map[string]string
+}
+
+// Rendered adalah hasil render template bundle.
+type Rendered struct {
+	Deploy  *deploy.Config
+	Compose *compose.Config
+}
+
+// ServiceNames mengembalikan nama semua service hasil render.
+func (r *Rendered) ServiceNames() []string {
+	var names []string
+	for _, svc := range r.Deploy.Services {
+		names = append(names, svc.Name)
+	}
+	for name := range r.Compose.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Load(dir string) (*Bundle, error) {
+	b := &Bundle{Dir: dir, templates: make(map[string]string)}
+
+	data, err := os.ReadFile(filepath.Join(dir, MetadataFile))
+	if err != nil {
+		return nil, fmt.Errorf("bukan direktori bundle: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &b.Metadata); err != nil {
+		return nil, fmt.Errorf("gagal parse %s: %v", MetadataFile, err)
+	}
+	if b.Name == "" || b.Version == "" {
+		return nil, fmt.Errorf("%s harus berisi name dan version", MetadataFile)
+	}
+
+	b.Defaults, err = ReadValuesFile(filepath.Join(dir, ValuesFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if b.Defaults == nil {
+		b.Defaults = Values{}
+	}
+
+	b.Schema, err = loadSchema(filepath.Join(dir, SchemaFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, TemplatesDir, "*.y*ml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("bundle %s tidak memiliki template di %s/", b.Name, TemplatesDir)
+	}
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		b.templates[filepath.Base(f)] = string(content)
+	}
+
+	return b, nil
+}
+
+// Render menggabungkan values dengan default bundle, memvalidasinya terhadap
+// skema, lalu me-render semua template.
+func (b *Bundle) Render(release string, overrides Values) (*Rendered, Values, error) {
+	values := Merge(b.Defaults.Clone(), overrides)
+	if b.Schema != nil {
+		if err := b.Schema.Validate(values); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	data := map[string]interface{}{
+		"Values":  map[string]interface{}(values),
+		"Release": map[string]interface{}{"Name": release},
+		"Bundle":  map[string]interface{}{"Name": b.Name, "Version": b.Version},
+	}
+
+	out := &Rendered{
+		Deploy:  &deploy.Config{},
+		Compose: &compose.Config{Services: map[string]compose.Service{}, Secrets: map[string]compose.Secret{}},
+	}
+
+	names := make([]string, 0, len(b.templates))
+	for name := range b.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		tmpl, err := template.New(name).Funcs(funcMap()).Option("missingkey=zero").Parse(b.templates[name])
+		if err != nil {
+			return nil, nil, fmt.Errorf("template %s: %v", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, nil, fmt.Errorf("template %s: %v", name, err)
+		}
+		if len(bytes.TrimSpace(buf.Bytes())) == 0 {
+			continue
+		}
+
+		if strings.Contains(name, "compose") {
+			var cfg compose.Config
+			if err := validate.Decode(name, buf.Bytes(), &cfg); err != nil {
+				return nil, nil, fmt.Errorf("hasil render bukan compose yang valid:\n%v", err)
+			}
+			for svcName, svc := range cfg.Services {
+				out.Compose.Services[svcName] = svc
+			}
+			for secretName, secret := range cfg.Secrets {
+				out.Compose.Secrets[secretName] = secret
+			}
+			continue
+		}
+
+		var cfg deploy.Config
+		if err := validate.Decode(name, buf.Bytes(), &cfg); err != nil {
+			return nil, nil, fmt.Errorf("hasil render bukan deploy.yaml yang valid:\n%v", err)
+		}
+		out.Deploy.Services = append(out.Deploy.Services, cfg.Services...)
+	}
+
+	return out, values, nil
+}
+
+// Manifest menghasilkan isi YAML hasil render untuk ditampilkan.
+func (r *Rendered) Manifest() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if len(r.Deploy.Services) > 0 {
+		if err := enc.Encode(r.Deploy); err != nil {
+			return nil, err
+		}
+	}
+	if len(r.Compose.Services) > 0 {
+		if err := enc.Encode(r.Compose); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
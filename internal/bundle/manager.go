@@ -0,0 +1,248 @@
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/zakirkun/neon/internal/docker"
+)
+
+// Release adalah satu instalasi bundle di cluster, direkonstruksi dari label
+// service.
+type Release struct {
+	Name      string
+	Bundle    string
+	Version   string
+	Values    Values
+	Services  []string
+	UpdatedAt time.Time
+}
+
+type Manager struct {
+	client   *docker.Client
+	deployer *docker.Deployer
+	out      io.Writer
+}
+
+func NewManager(client *docker.Client, deployer *docker.Deployer, out io.Writer) *Manager {
+	return &Manager{client: client, deployer: deployer, out: out}
+}
+
+// Install me-render bundle dan men-deploy semua service-nya sebagai release
+// baru.
+func (m *Manager) Install(ctx context.Context, b *Bundle, release string, overrides Values) error {
+	existing, err := m.Get(ctx, release)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("release %s sudah ada (bundle %s %s), gunakan `neon bundle upgrade`", release, existing.Bundle, existing.Version)
+	}
+
+	_, err = m.apply(ctx, b, release, overrides)
+	return err
+}
+
+// Upgrade men-deploy ulang release dengan bundle/values baru dan menghapus
+// service yang tidak lagi dihasilkan bundle. Dengan reuseValues, values
+// release sebelumnya menjadi dasar sebelum overrides diterapkan.
+func (m *Manager) Upgrade(ctx context.Context, b *Bundle, release string, overrides Values, reuseValues bool) error {
+	existing, err := m.Get(ctx, release)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("release %s tidak ditemukan, gunakan `neon bundle install`", release)
+	}
+	if existing.Bundle != b.Name {
+		return fmt.Errorf("release %s berasal dari bundle %s, bukan %s", release, existing.Bundle, b.Name)
+	}
+
+	if reuseValues {
+		overrides = Merge(existing.Values.Clone(), overrides)
+	}
+
+	fmt.Fprintf(m.out, "Upgrade release %s: %s %s -> %s\n", release, b.Name, existing.Version, b.Version)
+
+	rendered, err := m.apply(ctx, b, release, overrides)
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[string]bool)
+	for _, name := range rendered.ServiceNames() {
+		keep[name] = true
+	}
+	for _, name := range existing.Services {
+		if keep[name] {
+			continue
+		}
+		fmt.Fprintf(m.out, "Menghapus service yang tidak lagi ada di bundle: %s\n", name)
+		if err := m.client.ServiceRemove(ctx, name); err != nil {
+			return fmt.Errorf("gagal menghapus service %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Uninstall menghapus semua service milik release.
+func (m *Manager) Uninstall(ctx context.Context, release string) error {
+	services, err := m.releaseServices(ctx, release)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		return fmt.Errorf("release %s tidak ditemukan", release)
+	}
+
+	for _, svc := range services {
+		fmt.Fprintf(m.out, "Menghapus service: %s\n", svc.Spec.Name)
+		if err := m.client.ServiceRemove(ctx, svc.ID); err != nil {
+			return fmt.Errorf("gagal menghapus service %s: %v", svc.Spec.Name, err)
+		}
+	}
+	return nil
+}
+
+// List mengembalikan semua release yang ter-install, urut berdasarkan nama.
+func (m *Manager) List(ctx context.Context) ([]Release, error) {
+	services, err := m.client.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", docker.LabelBundleRelease)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*Release)
+	for _, svc := range services {
+		name := svc.Spec.Labels[docker.LabelBundleRelease]
+		r, ok := byName[name]
+		if !ok {
+			r = &Release{Name: name}
+			byName[name] = r
+		}
+		r.add(svc)
+	}
+
+	releases := make([]Release, 0, len(byName))
+	for _, r := range byName {
+		sort.Strings(r.Services)
+		releases = append(releases, *r)
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Name < releases[j].Name })
+	return releases, nil
+}
+
+// Get mengembalikan release bernama name, atau nil jika tidak ada.
+func (m *Manager) Get(ctx context.Context, name string) (*Release, error) {
+	services, err := m.releaseServices(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, nil
+	}
+
+	r := &Release{Name: name}
+	for _, svc := range services {
+		r.add(svc)
+	}
+	sort.Strings(r.Services)
+	return r, nil
+}
+
+func (r *Release) add(svc swarm.Service) {
+	r.Services = append(r.Services, svc.Spec.Name)
+
+	// Service yang paling baru di-update menentukan versi dan values release
+	if !svc.UpdatedAt.After(r.UpdatedAt) {
+		return
+	}
+	r.UpdatedAt = svc.UpdatedAt
+	r.Bundle = svc.Spec.Labels[docker.LabelBundleName]
+	r.Version = svc.Spec.Labels[docker.LabelBundleVersion]
+
+	values := Values{}
+	if raw := svc.Spec.Labels[docker.LabelBundleValues]; raw != "" {
+		json.Unmarshal([]byte(raw), &values)
+	}
+	r.Values = values
+}
+
+func (m *Manager) releaseServices(ctx context.Context, release string) ([]swarm.Service, error) {
+	return m.client.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", docker.LabelBundleRelease+"="+release)),
+	})
+}
+
+// apply me-render bundle, memasang label release pada setiap service, lalu
+// men-deploy-nya lewat Deployer.
+func (m *Manager) apply(ctx context.Context, b *Bundle, release string, overrides Values) (*Rendered, error) {
+	rendered, values, err := b.Render(release, overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	// Label service dapat dibaca siapa pun yang memiliki akses ke cluster,
+	// sehingga secret plaintext tidak ikut disimpan; ENC[...] dan referensi
+	// secret tetap disimpan apa adanya
+	stored, redacted := values.Redacted()
+	if len(redacted) > 0 {
+		fmt.Fprintf(m.out, "Values rahasia tidak disimpan di label release: %s (gunakan ENC[...] atau referensi secret agar --reuse-values dapat memakainya)\n", strings.Join(redacted, ", "))
+	}
+	valuesJSON, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan values: %v", err)
+	}
+	labels := map[string]string{
+		docker.LabelBundleRelease: release,
+		docker.LabelBundleName:    b.Name,
+		docker.LabelBundleVersion: b.Version,
+		docker.LabelBundleValues:  string(valuesJSON),
+	}
+
+	for i := range rendered.Deploy.Services {
+		svc := &rendered.Deploy.Services[i]
+		svc.Labels = withLabels(svc.Labels, labels)
+
+		fmt.Fprintf(m.out, "Deploying service: %s\n", svc.Name)
+		if err := m.deployer.DeployFromConfig(ctx, svc); err != nil {
+			return nil, fmt.Errorf("gagal deploy service %s: %v", svc.Name, err)
+		}
+	}
+
+	order, err := rendered.Compose.DeployOrder()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range order {
+		svc := rendered.Compose.Services[name]
+		svc.Deploy.Labels = withLabels(svc.Deploy.Labels, labels)
+
+		fmt.Fprintf(m.out, "Deploying service: %s\n", name)
+		if err := m.deployer.DeployComposeService(ctx, name, &svc, rendered.Compose.Secrets); err != nil {
+			return nil, fmt.Errorf("gagal deploy service %s: %v", name, err)
+		}
+	}
+
+	return rendered, nil
+}
+
+func withLabels(base, extra map[string]string) map[string]string {
+	out := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range extra {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema adalah subset JSON Schema untuk values: type, properties, required,
+// enum, minimum, dan maximum.
+type Schema struct {
+	Type       string             `yaml:"type"`
+	Properties map[string]*Schema `yaml:"properties"`
+	Required   []string           `yaml:"required"`
+	Enum       []interface{}      `yaml:"enum"`
+	Minimum    *float64           `yaml:"minimum"`
+	Maximum    *float64           `yaml:"maximum"`
+}
+
+func loadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema Schema
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("gagal parse %s: %v", path, err)
+	}
+	return &schema, nil
+}
+
+// Validate memeriksa values dan melaporkan semua pelanggaran sekaligus.
+func (s *Schema) Validate(values Values) error {
+	var errs []string
+	s.validate("values", map[string]interface{}(values), &errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("values tidak sesuai skema:\n  - %s", strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, v interface{}, errs *[]string) {
+	if s.Type != "" && v != nil && !matchesType(s.Type, v) {
+		*errs = append(*errs, fmt.Sprintf("%s: harus bertipe %s, bukan %T", path, s.Type, v))
+		return
+	}
+
+	if len(s.Enum) > 0 && v != nil {
+		found := false
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, fmt.Sprintf("%s: %v bukan salah satu dari %v", path, v, s.Enum))
+		}
+	}
+
+	if n, ok := toFloat(v); ok {
+		if s.Minimum != nil && n < *s.Minimum {
+			*errs = append(*errs, fmt.Sprintf("%s: %v lebih kecil dari minimum %v", path, v, *s.Minimum))
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			*errs = append(*errs, fmt.Sprintf("%s: %v lebih besar dari maximum %v", path, v, *s.Maximum))
+		}
+	}
+
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			*errs = append(*errs, fmt.Sprintf("%s.%s: wajib diisi", path, name))
+		}
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if child, ok := obj[name]; ok {
+			s.Properties[name].validate(path+"."+name, child, errs)
+		}
+	}
+}
+
+func matchesType(t string, v interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "integer":
+		switch n := v.(type) {
+		case int, int64, uint64:
+			return true
+		case float64:
+			return n == float64(int64(n))
+		}
+		return false
+	case "number":
+		_, ok := toFloat(v)
+		return ok
+	}
+	return true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
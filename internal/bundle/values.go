@@ -0,0 +1,145 @@
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zakirkun/neon/internal/config/crypt"
+	"github.com/zakirkun/neon/internal/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+// Values adalah pohon nilai yang dipakai template (.Values).
+type Values map[string]interface{}
+
+func ReadValuesFile(path string) (Values, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal ke map biasa agar map bersarang tidak ikut bertipe Values
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("gagal parse %s: %v", path, err)
+	}
+	return Values(values), nil
+}
+
+// Clone membuat salinan dalam (deep copy) values.
+func (v Values) Clone() Values {
+	out := make(Values, len(v))
+	for k, val := range v {
+		if m, ok := val.(map[string]interface{}); ok {
+			out[k] = map[string]interface{}(Values(m).Clone())
+			continue
+		}
+		out[k] = val
+	}
+	return out
+}
+
+// Redacted mengembalikan salinan values tanpa secret plaintext, yaitu nilai
+// yang key-nya cocok dengan crypt.DefaultPattern tetapi bukan ENC[...] atau
+// referensi secret, beserta path key yang dihapus (urut).
+func (v Values) Redacted() (Values, []string) {
+	var removed []string
+	out := redact(v, "", &removed)
+	sort.Strings(removed)
+	return out, removed
+}
+
+func redact(v Values, prefix string, removed *[]string) Values {
+	out := make(Values, len(v))
+	for k, val := range v {
+		path := prefix + k
+		if m, ok := val.(map[string]interface{}); ok {
+			out[k] = map[string]interface{}(redact(Values(m), path+".", removed))
+			continue
+		}
+		if val != nil && crypt.DefaultPattern.MatchString(k) && !isProtected(val) {
+			*removed = append(*removed, path)
+			continue
+		}
+		out[k] = val
+	}
+	return out
+}
+
+// isProtected melaporkan apakah nilai sudah dienkripsi atau merujuk ke
+// secret di tempat lain. Untuk list, semua item harus terlindungi.
+func isProtected(val interface{}) bool {
+	switch val := val.(type) {
+	case string:
+		return val == "" || crypt.IsEncrypted(val) || secrets.IsRef(val)
+	case []interface{}:
+		for _, item := range val {
+			if !isProtected(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Merge menimpa dst dengan src secara rekursif untuk map bersarang. Nilai
+// selain map (termasuk list) diganti seluruhnya.
+func Merge(dst, src Values) Values {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[k] = map[string]interface{}(Merge(Values(dstMap), Values(srcMap)))
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// ParseSet mengubah ekspresi --set "a.b=1,c=x" menjadi Values. Nilai
+// true/false dan angka dikonversi ke tipe yang sesuai.
+func ParseSet(expr string) (Values, error) {
+	values := Values{}
+	for _, pair := range strings.Split(expr, ",") {
+		key, raw, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("format --set tidak valid: %q (gunakan key=value)", pair)
+		}
+
+		parts := strings.Split(key, ".")
+		node := map[string]interface{}(values)
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := node[p].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				node[p] = next
+			}
+			node = next
+		}
+		node[parts[len(parts)-1]] = parseScalar(raw)
+	}
+	return values, nil
+}
+
+func parseScalar(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,43 @@
+package bundle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValuesRedacted(t *testing.T) {
+	enc := "ENC[AES256_GCM,data:abc,iv:def,tag:ghi,kid:k1]"
+	values := Values{
+		"image": map[string]interface{}{"tag": "1.2.0"},
+		"db": map[string]interface{}{
+			"host":     "db",
+			"password": "s3cret",
+			"token":    12345,
+		},
+		"apiKey":      enc,
+		"secretRef":   "vault://kv/app#token",
+		"tokens":      []interface{}{"a", enc},
+		"accessKeys":  []interface{}{enc},
+		"secretEmpty": "",
+	}
+
+	stored, removed := values.Redacted()
+
+	if got, want := strings.Join(removed, ","), "db.password,db.token,tokens"; got != want {
+		t.Errorf("dihapus = %s, ingin %s", got, want)
+	}
+	db := stored["db"].(map[string]interface{})
+	if _, ok := db["password"]; ok {
+		t.Error("db.password masih tersimpan")
+	}
+	if db["host"] != "db" || stored["apiKey"] != enc || stored["secretRef"] != "vault://kv/app#token" {
+		t.Errorf("values tersimpan = %v", stored)
+	}
+	if _, ok := stored["accessKeys"]; !ok {
+		t.Error("accessKeys terenkripsi ikut terhapus")
+	}
+	// Values asal tidak berubah
+	if values["db"].(map[string]interface{})["password"] != "s3cret" {
+		t.Error("values asal ikut berubah")
+	}
+}
@@ -0,0 +1,38 @@
+// Package annotation berisi penanda command dan flag yang dibaca root
+// command untuk menentukan apakah Docker daemon atau swarm dibutuhkan.
+package annotation
+
+import "github.com/spf13/cobra"
+
+// NoDocker menandai command yang tidak membutuhkan Docker daemon, misalnya
+// pengelolaan konfigurasi lokal. Pada flag, penanda berlaku jika flag
+// tersebut diisi.
+const NoDocker = "neon/no-docker"
+
+// NoSwarm menandai command yang tidak membutuhkan swarm aktif, misalnya
+// neon swarm init.
+const NoSwarm = "neon/no-swarm"
+
+// WithoutDocker menandai cmd dan semua subcommand-nya dengan NoDocker.
+func WithoutDocker(cmd *cobra.Command) *cobra.Command {
+	return set(cmd, NoDocker)
+}
+
+// WithoutSwarm menandai cmd dan semua subcommand-nya dengan NoSwarm.
+func WithoutSwarm(cmd *cobra.Command) *cobra.Command {
+	return set(cmd, NoSwarm)
+}
+
+// WithoutDockerFlag menandai flag cmd yang, jika diisi, membuat cmd tidak
+// membutuhkan Docker daemon (mis. neon build --print-dockerfile).
+func WithoutDockerFlag(cmd *cobra.Command, name string) {
+	cmd.Flags().SetAnnotation(name, NoDocker, []string{"true"})
+}
+
+func set(cmd *cobra.Command, key string) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[key] = "true"
+	return cmd
+}
@@ -0,0 +1,88 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/cli/annotation"
+	"github.com/zakirkun/neon/internal/config"
+	"github.com/zakirkun/neon/internal/docker"
+	"github.com/zakirkun/neon/internal/docker/builder"
+)
+
+func NewBuildCmd() *cobra.Command {
+	var (
+		tag             string
+		printDockerfile bool
+		timeout         time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "build [path]",
+		Short: "Build image dari direktori lokal",
+		Long: `Build image dari direktori lokal (default: direktori saat ini).
+
+Jika direktori tidak memiliki Dockerfile, neon mendeteksi jenis project
+dari go.mod, package.json, requirements.txt, atau pyproject.toml lalu
+memakai Dockerfile multi-stage hasil generate.`,
+		Example: `  neon build ./app --tag registry.example.com/app:dev
+  neon build --print-dockerfile`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+
+			if printDockerfile {
+				return printGenerated(dir)
+			}
+
+			client, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			deployer := docker.NewDeployer(client, config.Get())
+			if err := deployer.BuildImage(ctx, dir, tag); err != nil {
+				return fmt.Errorf("gagal build image: %v", err)
+			}
+
+			fmt.Println("Build selesai")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&tag, "tag", "t", "", "Nama dan tag image (default: <registry>/<nama direktori>:<revisi>)")
+	cmd.Flags().BoolVar(&printDockerfile, "print-dockerfile", false, "Tampilkan Dockerfile hasil generate tanpa build")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "Batas waktu build")
+	annotation.WithoutDockerFlag(cmd, "print-dockerfile")
+
+	return cmd
+}
+
+func printGenerated(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "Dockerfile")); err == nil {
+		fmt.Fprintf(os.Stderr, "Catatan: %s sudah memiliki Dockerfile, file tersebut yang akan dipakai saat build\n", dir)
+	}
+
+	project, err := builder.Detect(dir)
+	if err != nil {
+		return err
+	}
+
+	content, err := project.Dockerfile()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(string(content))
+	return nil
+}
@@ -0,0 +1,224 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/bundle"
+	"github.com/zakirkun/neon/internal/docker"
+)
+
+func NewBundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Install dan kelola bundle deploy bertemplate",
+		Long: `Bundle adalah paket deploy yang dapat dipakai ulang: direktori berisi
+bundle.yaml, values.yaml, values.schema.yaml (opsional), dan template
+deploy.yaml/compose di templates/. Nama bundle, versi, dan values yang
+dipakai dicatat di label service sehingga upgrade mengetahui apa yang
+sedang diubah.`,
+	}
+
+	cmd.AddCommand(
+		newInstallCmd(),
+		newUpgradeCmd(),
+		newUninstallCmd(),
+		newListCmd(),
+	)
+
+	return cmd
+}
+
+// valueFlags adalah flag values yang sama untuk install dan upgrade.
+type valueFlags struct {
+	files  []string
+	sets   []string
+	dryRun bool
+}
+
+func (f *valueFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&f.files, "values", "f", nil, "File values tambahan (dapat diulang)")
+	cmd.Flags().StringArrayVar(&f.sets, "set", nil, "Override values, misalnya --set image.tag=1.2.0 (dapat diulang)")
+	cmd.Flags().BoolVar(&f.dryRun, "dry-run", false, "Tampilkan hasil render tanpa deploy")
+}
+
+// values menggabungkan file values sesuai urutan lalu --set.
+func (f *valueFlags) values() (bundle.Values, error) {
+	values := bundle.Values{}
+	for _, path := range f.files {
+		v, err := bundle.ReadValuesFile(path)
+		if err != nil {
+			return nil, err
+		}
+		values = bundle.Merge(values, v)
+	}
+	for _, expr := range f.sets {
+		v, err := bundle.ParseSet(expr)
+		if err != nil {
+			return nil, err
+		}
+		values = bundle.Merge(values, v)
+	}
+	return values, nil
+}
+
+func printManifest(b *bundle.Bundle, release string, values bundle.Values) error {
+	rendered, _, err := b.Render(release, values)
+	if err != nil {
+		return err
+	}
+	manifest, err := rendered.Manifest()
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(manifest))
+	return nil
+}
+
+func newManager() (*bundle.Manager, error) {
+	client, err := docker.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return bundle.NewManager(client, docker.NewDeployer(client, nil), os.Stdout), nil
+}
+
+func newInstallCmd() *cobra.Command {
+	var flags valueFlags
+
+	cmd := &cobra.Command{
+		Use:     "install [release] [bundle-dir]",
+		Short:   "Install bundle sebagai release baru",
+		Example: `  neon bundle install web ./bundles/webapp -f prod-values.yaml --set replicas=3`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := bundle.Load(args[1])
+			if err != nil {
+				return err
+			}
+			values, err := flags.values()
+			if err != nil {
+				return err
+			}
+			if flags.dryRun {
+				return printManifest(b, args[0], values)
+			}
+
+			manager, err := newManager()
+			if err != nil {
+				return err
+			}
+			if err := manager.Install(context.Background(), b, args[0], values); err != nil {
+				return err
+			}
+
+			fmt.Printf("Release %s (%s %s) berhasil di-install\n", args[0], b.Name, b.Version)
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+	return cmd
+}
+
+func newUpgradeCmd() *cobra.Command {
+	var (
+		flags       valueFlags
+		reuseValues bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "upgrade [release] [bundle-dir]",
+		Short:   "Upgrade release ke versi bundle atau values baru",
+		Example: `  neon bundle upgrade web ./bundles/webapp --reuse-values --set image.tag=1.3.0`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := bundle.Load(args[1])
+			if err != nil {
+				return err
+			}
+			values, err := flags.values()
+			if err != nil {
+				return err
+			}
+
+			manager, err := newManager()
+			if err != nil {
+				return err
+			}
+
+			if flags.dryRun {
+				if reuseValues {
+					existing, err := manager.Get(context.Background(), args[0])
+					if err != nil {
+						return err
+					}
+					if existing != nil {
+						values = bundle.Merge(existing.Values.Clone(), values)
+					}
+				}
+				return printManifest(b, args[0], values)
+			}
+
+			if err := manager.Upgrade(context.Background(), b, args[0], values, reuseValues); err != nil {
+				return err
+			}
+
+			fmt.Printf("Release %s berhasil di-upgrade ke %s %s\n", args[0], b.Name, b.Version)
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().BoolVar(&reuseValues, "reuse-values", false, "Pakai values release sebelumnya sebagai dasar")
+	return cmd
+}
+
+func newUninstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall [release]",
+		Short: "Hapus semua service milik release",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := newManager()
+			if err != nil {
+				return err
+			}
+			if err := manager.Uninstall(context.Background(), args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Release %s berhasil dihapus\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Tampilkan release bundle yang ter-install",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := newManager()
+			if err != nil {
+				return err
+			}
+
+			releases, err := manager.List(context.Background())
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%-20s %-20s %-10s %-20s %s\n", "RELEASE", "BUNDLE", "VERSION", "UPDATED", "SERVICES")
+			fmt.Println(strings.Repeat("-", 95))
+			for _, r := range releases {
+				fmt.Printf("%-20s %-20s %-10s %-20s %s\n",
+					r.Name, r.Bundle, r.Version, r.UpdatedAt.Local().Format("2006-01-02 15:04:05"), strings.Join(r.Services, ","))
+			}
+			return nil
+		},
+	}
+}
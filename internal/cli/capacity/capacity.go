@@ -0,0 +1,204 @@
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/docker"
+	"github.com/zakirkun/neon/internal/docker/swarm"
+)
+
+func NewCapacityCmd() *cobra.Command {
+	var (
+		format string
+		whatIf []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "capacity",
+		Short: "Tampilkan reservasi CPU/memori per node dan task pending",
+		Long: `Jumlahkan reservasi CPU dan memori semua task yang sudah ditempatkan per
+node, bandingkan dengan kapasitas node, dan tampilkan sisanya. Task yang
+tertahan di pending dijelaskan: constraint yang tidak dipenuhi, batas max
+replicas per node, atau reservasi yang tidak muat di node mana pun.
+
+Dengan --what-if SERVICE=REPLICAS, neon mensimulasikan scale tanpa mengubah
+cluster dan keluar dengan error jika replika tambahan tidak muat.`,
+		Example: `  neon capacity
+  neon capacity --what-if api=6
+  neon capacity --what-if api=6 --what-if worker=10 -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "table" && format != "json" {
+				return fmt.Errorf("format %q tidak didukung (table, json)", format)
+			}
+			targets, err := parseTargets(whatIf)
+			if err != nil {
+				return err
+			}
+
+			client, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+			mgr := swarm.NewManager(client)
+			ctx := context.Background()
+
+			if len(targets) > 0 {
+				return runWhatIf(ctx, mgr, targets, format)
+			}
+
+			report, err := mgr.Capacity(ctx)
+			if err != nil {
+				return err
+			}
+			if format == "json" {
+				return printJSON(report)
+			}
+			return printReport(report)
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "o", "table", "Format output (table, json)")
+	cmd.Flags().StringArrayVar(&whatIf, "what-if", nil, "Simulasikan scale SERVICE=REPLICAS (dapat diulang)")
+	return cmd
+}
+
+type target struct {
+	name     string
+	replicas uint64
+}
+
+func parseTargets(args []string) ([]target, error) {
+	targets := make([]target, len(args))
+	for i, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		n, err := strconv.ParseUint(value, 10, 64)
+		if !ok || name == "" || err != nil {
+			return nil, fmt.Errorf("--what-if %q tidak valid (gunakan SERVICE=REPLICAS)", arg)
+		}
+		targets[i] = target{name: name, replicas: n}
+	}
+	return targets, nil
+}
+
+// runWhatIf mensimulasikan setiap target secara terpisah terhadap keadaan
+// cluster saat ini.
+func runWhatIf(ctx context.Context, mgr *swarm.Manager, targets []target, format string) error {
+	plans := make([]*swarm.ScalePlan, len(targets))
+	for i, t := range targets {
+		plan, err := mgr.PlanScale(ctx, t.name, t.replicas)
+		if err != nil {
+			return fmt.Errorf("%s: %v", t.name, err)
+		}
+		plans[i] = plan
+	}
+
+	if format == "json" {
+		if err := printJSON(plans); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range plans {
+			printPlan(p)
+		}
+	}
+
+	failed := 0
+	for _, p := range plans {
+		if !p.Fits() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d rencana scale tidak muat", failed)
+	}
+	return nil
+}
+
+func printPlan(p *swarm.ScalePlan) {
+	fmt.Printf("%s=%d (saat ini %d): ", p.Service, p.Replicas, p.Current)
+	if !p.Fits() {
+		fmt.Printf("TIDAK MUAT, %d task tidak dapat ditempatkan: %s\n", p.Unplaced, p.Reason)
+	} else {
+		fmt.Println("muat")
+	}
+
+	nodes := make([]string, 0, len(p.Placements))
+	for name := range p.Placements {
+		nodes = append(nodes, name)
+	}
+	sort.Strings(nodes)
+	for _, name := range nodes {
+		fmt.Printf("  +%d task di %s\n", p.Placements[name], name)
+	}
+}
+
+func printReport(r *swarm.CapacityReport) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tSTATUS\tTASKS\tCPU RESERVED\tCPU FREE\tMEMORY RESERVED\tMEMORY FREE")
+
+	var total swarm.NodeCapacity
+	for i := range r.Nodes {
+		n := &r.Nodes[i]
+		status := n.State
+		if n.Availability != "active" {
+			status += "/" + n.Availability
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s / %s\t%s\t%s / %s\t%s\n",
+			n.Name, status, n.Tasks,
+			cpus(n.ReservedCPUs), cpus(n.NanoCPUs), cpus(n.FreeCPUs()),
+			bytes(n.ReservedMemory), bytes(n.MemoryBytes), bytes(n.FreeMemory()))
+		if n.Schedulable {
+			total.NanoCPUs += n.NanoCPUs
+			total.MemoryBytes += n.MemoryBytes
+			total.ReservedCPUs += n.ReservedCPUs
+			total.ReservedMemory += n.ReservedMemory
+			total.Tasks += n.Tasks
+		}
+	}
+	fmt.Fprintf(w, "TOTAL (schedulable)\t\t%d\t%s / %s\t%s\t%s / %s\t%s\n", total.Tasks,
+		cpus(total.ReservedCPUs), cpus(total.NanoCPUs), cpus(total.FreeCPUs()),
+		bytes(total.ReservedMemory), bytes(total.MemoryBytes), bytes(total.FreeMemory()))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Pending) == 0 {
+		fmt.Println("\nTidak ada task pending.")
+		return nil
+	}
+	fmt.Printf("\nTask pending (%d):\n", len(r.Pending))
+	for _, p := range r.Pending {
+		fmt.Printf("  %s (%s CPU, %s): %s\n", p.Task, cpus(p.NanoCPUs), bytes(p.MemoryBytes), p.Reason)
+		if p.Fits && p.Error != "" {
+			fmt.Printf("    error scheduler: %s\n", p.Error)
+		}
+	}
+	return nil
+}
+
+func cpus(nano int64) string {
+	return strconv.FormatFloat(float64(nano)/1e9, 'f', -1, 64)
+}
+
+func bytes(n int64) string {
+	if n < 0 {
+		return "-" + units.BytesSize(float64(-n))
+	}
+	return units.BytesSize(float64(n))
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
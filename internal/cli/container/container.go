@@ -9,6 +9,7 @@ import (
 	"strconv"
 
 	dContainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/spf13/cobra"
 	"github.com/zakirkun/neon/internal/docker"
 	"github.com/zakirkun/neon/internal/docker/container"
@@ -138,13 +139,25 @@ func newLogsCmd() *cobra.Command {
 				Tail:       strconv.Itoa(tail),
 			}
 
-			logs, err := client.ContainerLogs(context.Background(), args[0], options)
+			ctx := context.Background()
+			info, err := client.ContainerInspect(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			logs, err := client.ContainerLogs(ctx, args[0], options)
 			if err != nil {
 				return err
 			}
 			defer logs.Close()
 
-			_, err = io.Copy(os.Stdout, logs)
+			// Tanpa TTY, stdout dan stderr dikirim dalam satu stream dengan
+			// header per frame
+			if info.Config != nil && info.Config.Tty {
+				_, err = io.Copy(os.Stdout, logs)
+				return err
+			}
+			_, err = stdcopy.StdCopy(os.Stdout, os.Stderr, logs)
 			return err
 		},
 	}
@@ -0,0 +1,224 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/config"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"gopkg.in/yaml.v3"
+)
+
+func NewContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Kelola context cluster",
+		Long: `Kelola context cluster di konfigurasi neon. Setiap context berisi endpoint
+Docker (unix://, tcp:// dengan TLS, atau ssh://), registry, dan pengaturan
+stack default.
+
+Context aktif dipilih dengan flag --context, variabel NEON_CONTEXT, atau
+current_context (lihat neon context use). Tanpa context, neon memakai
+DOCKER_HOST seperti Docker CLI.`,
+	}
+
+	cmd.AddCommand(
+		newListCmd(),
+		newUseCmd(),
+		newCreateCmd(),
+		newRemoveCmd(),
+		newInspectCmd(),
+	)
+
+	return cmd
+}
+
+func newListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "Tampilkan daftar context",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			active := config.ContextName()
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tHOST\tREGISTRY\tSTACK\tDESCRIPTION")
+
+			mark := func(name string) string {
+				if name == active || (active == "" && name == "default") {
+					return name + " *"
+				}
+				return name
+			}
+			fmt.Fprintf(w, "%s\t%s\t\t\t%s\n", mark("default"), envHost(), "DOCKER_HOST / socket lokal")
+			for _, name := range cfg.ContextNames() {
+				c := cfg.Contexts[name]
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", mark(name), c.Host, c.Registry.URL, c.Stack.Name, c.Description)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newUseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use NAME",
+		Short: "Jadikan context sebagai current_context",
+		Long: `Jadikan context sebagai current_context. "default" kembali memakai
+DOCKER_HOST atau socket lokal.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if name == "default" {
+				name = ""
+			}
+			if err := config.UseContext(name); err != nil {
+				return err
+			}
+
+			fmt.Printf("Context aktif: %s\n", args[0])
+			if env := os.Getenv("NEON_CONTEXT"); env != "" && env != args[0] {
+				fmt.Fprintf(os.Stderr, "Catatan: NEON_CONTEXT=%s masih menggantikan current_context\n", env)
+			}
+			return nil
+		},
+	}
+}
+
+func newCreateCmd() *cobra.Command {
+	var (
+		ctx config.Context
+		use bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Buat context baru",
+		Example: `  neon context create staging --host ssh://deploy@staging-manager
+  neon context create production --host tcp://10.0.0.10:2376 \
+    --tls-ca ~/.neon/certs/prod/ca.pem --tls-cert ~/.neon/certs/prod/cert.pem \
+    --tls-key ~/.neon/certs/prod/key.pem --registry registry.corp --stack shop`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if name == "default" {
+				return fmt.Errorf("nama context default sudah dipakai untuk DOCKER_HOST")
+			}
+			if err := validate.Check("name", name); err != nil {
+				return err
+			}
+			if config.Get().LookupContext(name) != nil {
+				return fmt.Errorf("context %q sudah ada; hapus dulu dengan neon context rm %s", name, name)
+			}
+			if err := validate.Check("dockerhost", ctx.Host); err != nil {
+				return err
+			}
+			if ctx.Stack.Name != "" {
+				if err := validate.Check("name", ctx.Stack.Name); err != nil {
+					return err
+				}
+			}
+
+			if err := config.SaveContext(name, &ctx); err != nil {
+				return err
+			}
+			fmt.Printf("Context %s dibuat\n", name)
+
+			if use {
+				if err := config.UseContext(name); err != nil {
+					return err
+				}
+				fmt.Printf("Context aktif: %s\n", name)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&ctx.Host, "host", "", "Endpoint Docker (unix://, tcp://host:port, ssh://user@host)")
+	cmd.Flags().StringVar(&ctx.Description, "description", "", "Deskripsi context")
+	cmd.Flags().StringVar(&ctx.Environment, "environment", "", "Environment cluster untuk policy (misalnya production)")
+	cmd.Flags().StringVar(&ctx.TLS.CA, "tls-ca", "", "Sertifikat CA untuk endpoint tcp")
+	cmd.Flags().StringVar(&ctx.TLS.Cert, "tls-cert", "", "Sertifikat client")
+	cmd.Flags().StringVar(&ctx.TLS.Key, "tls-key", "", "Private key client")
+	cmd.Flags().BoolVar(&ctx.TLS.SkipVerify, "tls-skip-verify", false, "Jangan verifikasi sertifikat server")
+	cmd.Flags().StringVar(&ctx.Registry.URL, "registry", "", "Registry image untuk context ini")
+	cmd.Flags().StringVar(&ctx.Registry.Username, "registry-username", "", "Username registry")
+	cmd.Flags().StringVar(&ctx.Registry.Password, "registry-password", "", "Password registry")
+	cmd.Flags().StringVar(&ctx.Stack.Name, "stack", "", "Nama stack default")
+	cmd.Flags().IntVar(&ctx.Stack.Replicas, "replicas", 0, "Jumlah replicas default")
+	cmd.Flags().BoolVar(&use, "use", false, "Langsung jadikan context aktif")
+	cmd.MarkFlagRequired("host")
+
+	return cmd
+}
+
+func newRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm NAME",
+		Aliases: []string{"remove"},
+		Short:   "Hapus context",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wasCurrent := config.Get().CurrentContext == args[0]
+			if err := config.RemoveContext(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Context %s dihapus\n", args[0])
+			if wasCurrent {
+				fmt.Println("Context aktif kembali ke default")
+			}
+			return nil
+		},
+	}
+}
+
+func newInspectCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "inspect [NAME]",
+		Short: "Tampilkan detail context (default: context aktif)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := config.ContextName()
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if name == "" || name == "default" {
+				fmt.Printf("name: default\nhost: %s\n", envHost())
+				return nil
+			}
+
+			ctx := config.Get().LookupContext(name)
+			if ctx == nil {
+				return fmt.Errorf("context %q tidak ditemukan", name)
+			}
+
+			shown := *ctx
+			if shown.Registry.Password != "" {
+				shown.Registry.Password = "********"
+			}
+			out, err := yaml.Marshal(struct {
+				Name           string `yaml:"name"`
+				Current        bool   `yaml:"current"`
+				config.Context `yaml:",inline"`
+			}{name, name == config.ContextName(), shown})
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(out))
+			return nil
+		},
+	}
+}
+
+// envHost mengembalikan endpoint context default.
+func envHost() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	return "unix:///var/run/docker.sock"
+}
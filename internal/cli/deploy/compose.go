@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/zakirkun/neon/internal/config/compose"
+	"github.com/zakirkun/neon/internal/config/project"
 	"github.com/zakirkun/neon/internal/docker"
 )
 
@@ -15,35 +16,52 @@ func newComposeCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "compose",
 		Short: "Deploy dari Docker Compose file",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			// Load compose file
-			composeConfig, err := compose.LoadFromFile(composePath)
-			if err != nil {
-				return err
-			}
+		Long: `Deploy semua service di file compose. Service di-deploy setelah service
+yang disebut di depends_on.
 
+Dengan --atomic, spec setiap service dicatat sebelum deploy dan neon
+menunggu semua service konvergen. Jika satu service gagal, semua service
+yang sudah tersentuh dikembalikan dengan urutan terbalik: service lama ke
+spec sebelumnya, service baru dihapus.`,
+		Example: `  neon deploy compose -f docker-compose.yml --atomic
+  neon deploy compose --atomic --wait-timeout 10m -m "rilis 2.4"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			// Inisialisasi Docker client
 			client, err := docker.NewClient()
 			if err != nil {
 				return err
 			}
 
-			ctx := context.Background()
-			deployer := docker.NewDeployer(client, nil)
-
-			// Deploy setiap service
-			for name, service := range composeConfig.Services {
-				fmt.Printf("Deploying service: %s\n", name)
-
-				if err := deployer.DeployComposeService(ctx, name, &service); err != nil {
-					return fmt.Errorf("gagal deploy service %s: %v", name, err)
-				}
-			}
-
-			return nil
+			deployer := docker.NewDeployer(client, nil).WithMessage(message)
+			return deployComposeFile(context.Background(), deployer, composePath, nil)
 		},
 	}
 
 	cmd.Flags().StringVarP(&composePath, "file", "f", "docker-compose.yml", "Path ke Docker Compose file")
+	addAtomicFlags(cmd)
 	return cmd
 }
+
+// deployComposeFile men-deploy semua service di file compose. Jika env tidak
+// nil, replicas dan environment dari neon.yaml diterapkan lebih dulu.
+func deployComposeFile(ctx context.Context, deployer *docker.Deployer, composePath string, env *project.Resolved) error {
+	// Load compose file
+	composeConfig, err := compose.LoadFromFile(composePath)
+	if err != nil {
+		return err
+	}
+	if env != nil {
+		env.ApplyCompose(composeConfig)
+	}
+
+	// Deploy setiap service, dependency lebih dulu
+	names, err := composeConfig.DeployOrder()
+	if err != nil {
+		return err
+	}
+	return deployStack(ctx, deployer, names, func(name string) error {
+		fmt.Printf("Deploying service: %s\n", name)
+		service := composeConfig.Services[name]
+		return deployer.DeployComposeService(ctx, name, &service, composeConfig.Secrets)
+	})
+}
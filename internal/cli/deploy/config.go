@@ -2,30 +2,37 @@ package deploy
 
 import (
 	"context"
-	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/cli/annotation"
 	"github.com/zakirkun/neon/internal/config/deploy"
+	"github.com/zakirkun/neon/internal/config/project"
 	"github.com/zakirkun/neon/internal/docker"
 	"gopkg.in/yaml.v3"
 )
 
 func newConfigDeployCmd() *cobra.Command {
-	var configFile string
+	var configFile, overlayEnv, overlayFile string
 
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Deploy services dari file konfigurasi",
+		Long: `Deploy services dari file konfigurasi deploy.yaml.
+
+Dengan --env, overlay <nama>.<env>.yaml di samping file dasar (misalnya
+deploy.staging.yaml) diterapkan di atas konfigurasi dasar. Overlay dapat
+mengganti image/tag, replicas, environment, resources, dan ports per
+service.
+
+Service di-deploy setelah service yang disebut di depends_on. Dengan
+--atomic, kegagalan satu service (termasuk service yang tidak konvergen
+dalam --wait-timeout) mengembalikan semua service yang sudah tersentuh
+dengan urutan terbalik.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			data, err := os.ReadFile(configFile)
+			config, err := loadDeployConfig(configFile, overlayEnv, overlayFile)
 			if err != nil {
-				return fmt.Errorf("gagal membaca file konfigurasi: %v", err)
-			}
-
-			var config deploy.Config
-			if err := yaml.Unmarshal(data, &config); err != nil {
-				return fmt.Errorf("gagal parse konfigurasi: %v", err)
+				return err
 			}
 
 			client, err := docker.NewClient()
@@ -33,19 +40,94 @@ func newConfigDeployCmd() *cobra.Command {
 				return err
 			}
 
-			ctx := context.Background()
-			deployer := docker.NewDeployer(client, nil)
+			deployer := docker.NewDeployer(client, nil).WithEnvironment(overlayEnv).WithMessage(message)
+			return deployServices(context.Background(), deployer, config)
+		},
+	}
+
+	cmd.AddCommand(annotation.WithoutDocker(newConfigRenderCmd(&configFile, &overlayEnv, &overlayFile)))
 
-			for _, svc := range config.Services {
-				if err := deployer.DeployFromConfig(ctx, &svc); err != nil {
-					return fmt.Errorf("gagal deploy service %s: %v", svc.Name, err)
-				}
+	cmd.PersistentFlags().StringVarP(&configFile, "file", "f", "config/deploy.yaml", "Path ke file konfigurasi deploy")
+	cmd.PersistentFlags().StringVarP(&overlayEnv, "env", "e", "", "Environment overlay (memakai <file>.<env>.yaml)")
+	cmd.PersistentFlags().StringVar(&overlayFile, "overlay", "", "Path overlay eksplisit, menggantikan lokasi dari --env")
+	addAtomicFlags(cmd)
+	return cmd
+}
+
+func newConfigRenderCmd(configFile, overlayEnv, overlayFile *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "render",
+		Short: "Tampilkan konfigurasi deploy hasil penggabungan overlay",
+		Example: `  neon deploy config render --env staging
+  neon deploy config render -f deploy.yaml --overlay overlays/prod.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Nilai ENC[...] tidak didekripsi agar secret tidak tampil di
+			// terminal atau log CI
+			config, err := deploy.LoadRawWithOverlay(*configFile, overlayPath(*configFile, *overlayEnv, *overlayFile))
+			if err != nil {
+				return err
 			}
 
-			return nil
+			enc := yaml.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent(2)
+			defer enc.Close()
+			return enc.Encode(config)
 		},
 	}
+}
 
-	cmd.Flags().StringVarP(&configFile, "file", "f", "config/deploy.yaml", "Path ke file konfigurasi deploy")
-	return cmd
+// loadDeployConfig memuat deploy.yaml beserta overlay-nya. Overlay
+// konvensional untuk env bersifat opsional; overlay eksplisit harus ada.
+func loadDeployConfig(configFile, env, overlay string) (*deploy.Config, error) {
+	return deploy.LoadWithOverlay(configFile, overlayPath(configFile, env, overlay))
+}
+
+// overlayPath mengembalikan overlay eksplisit, atau overlay konvensional
+// untuk env jika file tersebut ada.
+func overlayPath(configFile, env, overlay string) string {
+	if overlay == "" && env != "" {
+		overlay = deploy.OverlayPath(configFile, env)
+		if _, err := os.Stat(overlay); os.IsNotExist(err) {
+			overlay = ""
+		}
+	}
+	return overlay
+}
+
+// deployConfigFile men-deploy semua service di file deploy.yaml. Jika env
+// tidak nil, overlay environment dan nilai dari neon.yaml diterapkan lebih
+// dulu.
+func deployConfigFile(ctx context.Context, deployer *docker.Deployer, configFile string, env *project.Resolved) error {
+	var envName string
+	if env != nil {
+		envName = env.Environment
+	}
+
+	config, err := loadDeployConfig(configFile, envName, "")
+	if err != nil {
+		return err
+	}
+	if env != nil {
+		env.ApplyDeploy(config)
+	}
+
+	return deployServices(ctx, deployer, config)
+}
+
+func deployServices(ctx context.Context, deployer *docker.Deployer, config *deploy.Config) error {
+	services, err := config.Ordered()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(services))
+	byName := make(map[string]*deploy.ServiceConfig, len(services))
+	for i := range services {
+		names[i] = services[i].Name
+		byName[services[i].Name] = &services[i]
+	}
+	return deployStack(ctx, deployer, names, func(name string) error {
+		return deployer.DeployFromConfig(ctx, byName[name])
+	})
 }
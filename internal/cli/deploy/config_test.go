@@ -0,0 +1,56 @@
+package deploy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigRenderKeepsEncryptedValues(t *testing.T) {
+	// Tanpa kunci secret: render tidak boleh mencoba mendekripsi
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("NEON_SECRET_KEY", "")
+	t.Setenv("NEON_SECRET_KEY_FILE", "")
+
+	const (
+		dbPassword = "ENC[AES256_GCM,data:c2VjcmV0,iv:aXY=,tag:dGFn,kid:82c90da6]"
+		apiToken   = "ENC[AES256_GCM,data:dG9rZW4=,iv:aXY=,tag:dGFn,kid:82c90da6]"
+	)
+	base := filepath.Join(dir, "deploy.yaml")
+	writeFile(t, base, `services:
+  - name: api
+    image: registry.example.com/api:1.0
+    environment:
+      - DB_PASSWORD=`+dbPassword+`
+`)
+	writeFile(t, filepath.Join(dir, "deploy.staging.yaml"), `services:
+  - name: api
+    environment:
+      - API_TOKEN=`+apiToken+`
+`)
+
+	configFile, env, overlay := base, "staging", ""
+	cmd := newConfigRenderCmd(&configFile, &env, &overlay)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"DB_PASSWORD=" + dbPassword, "API_TOKEN=" + apiToken} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output render tidak mengandung %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
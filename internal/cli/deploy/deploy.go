@@ -3,39 +3,73 @@ package deploy
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/zakirkun/neon/internal/config"
+	"github.com/zakirkun/neon/internal/config/project"
 	"github.com/zakirkun/neon/internal/docker"
 )
 
 var (
-	configPath string
-	repoURL    string
+	repoURL     string
+	localPath   string
+	archivePath string
+	branch      string
+	service     string
+	timeout     time.Duration
+	noCache     bool
+	envName     string
+	projectDir  string
+	message     string
 )
 
-type DeployConfig struct {
-	RepoURL     string `yaml:"repo_url"`
-	Branch      string `yaml:"branch"`
-	ConfigFile  string `yaml:"config_file"`
-	Environment string `yaml:"environment"`
-}
-
 func NewDeployCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "deploy",
 		Short: "Deploy applications to Docker Swarm",
+		Long: `Deploy aplikasi dari repository git, direktori lokal, atau arsip
+build context ke Docker Swarm.
+
+Pipeline berjalan dalam empat tahap: menyiapkan sumber, build image,
+push image ke registry, lalu create/update service di swarm. Repository
+dapat berupa URL remote maupun path lokal (termasuk bare repository).
+Tanpa metadata git, tag image diambil dari hash isi build context.
+
+Jika direktori project memiliki neon.yaml, sumber, build, registry, context
+swarm, replicas, dan environment diambil dari file tersebut. Pilih
+environment dengan --env.`,
+		Example: `  neon deploy --env production
+  neon deploy --repo https://github.com/org/app.git --branch main
+  neon deploy --repo /srv/git/app.git --timeout 20m
+  neon deploy --path ./app
+  neon deploy --archive build.tar.gz --service api
+  neon deploy --context production --path ./app`,
+		Args: deployArgs,
+		RunE: runDeploy,
 	}
 
 	cmd.AddCommand(
 		newZeroDowntimeCmd(),
 		newConfigDeployCmd(),
 		newComposeCmd(),
+		newHistoryCmd(),
+		newRollbackCmd(),
 	)
 
-	cmd.Flags().StringVarP(&configPath, "config", "c", "config/config.yaml", "Path ke file konfigurasi")
-	cmd.Flags().StringVarP(&repoURL, "repo", "r", "", "URL repository GitHub")
+	cmd.PersistentFlags().StringVarP(&message, "message", "m", "", "Pesan yang disimpan pada revisi service (lihat neon deploy history)")
+
+	cmd.Flags().StringVarP(&repoURL, "repo", "r", "", "URL atau path repository git")
+	cmd.Flags().StringVarP(&localPath, "path", "p", "", "Direktori lokal sebagai build context")
+	cmd.Flags().StringVar(&archivePath, "archive", "", "Arsip build context (.tar, .tar.gz, .tgz, .tar.bz2, .tar.xz)")
+	cmd.Flags().StringVarP(&branch, "branch", "b", "main", "Branch yang akan di-deploy")
+	cmd.Flags().StringVarP(&service, "service", "s", "", "Nama service swarm (default: nama repository)")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Clone ulang repository tanpa memakai cache lokal")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "Batas waktu seluruh pipeline deploy")
+	cmd.Flags().StringVarP(&envName, "env", "e", "", "Environment di neon.yaml (misalnya staging, production)")
+	cmd.Flags().StringVar(&projectDir, "project", ".", "Direktori yang berisi neon.yaml")
+	cmd.MarkFlagsMutuallyExclusive("repo", "path", "archive")
 
 	return cmd
 }
@@ -80,35 +114,164 @@ func deployFromConfig(configFile string) error {
 // 	return nil
 // }
 
+// deployArgs dijalankan sebelum context cluster dimuat. Flag arsip build
+// context dulu bernama --context, yang sekarang memilih context cluster;
+// file arsip yang diberikan ke --context diarahkan ke --archive.
+func deployArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.NoArgs(cmd, args); err != nil {
+		return err
+	}
+	name, _ := cmd.Flags().GetString("context")
+	if !docker.IsArchive(name) {
+		return nil
+	}
+	if info, err := os.Stat(name); err == nil && info.Mode().IsRegular() {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("--context memilih context cluster; gunakan --archive %s untuk deploy dari arsip build context", name)
+	}
+	return nil
+}
+
 func runDeploy(cmd *cobra.Command, args []string) error {
+	// Load neon.yaml jika ada
+	proj, err := project.Load(projectDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if envName != "" && proj == nil {
+		return fmt.Errorf("--env membutuhkan %s di %s", project.FileName, projectDir)
+	}
+
+	hasSource := repoURL != "" || localPath != "" || archivePath != ""
+	var env *project.Resolved
+	if proj != nil && (envName != "" || !hasSource) {
+		if env, err = proj.Resolve(envName); err != nil {
+			return err
+		}
+	}
+
 	// Validasi input
-	if repoURL == "" {
-		return fmt.Errorf("URL repository harus diisi")
+	if !hasSource && env == nil {
+		return fmt.Errorf("salah satu dari --repo, --path, atau --archive harus diisi")
 	}
 
-	// Load konfigurasi
-	cfg := config.Get()
+	cfg := *config.Get()
+
+	opts := docker.DeployOptions{
+		RepoURL:     repoURL,
+		Branch:      branch,
+		Path:        localPath,
+		ContextFile: archivePath,
+		Service:     service,
+		NoCache:     noCache,
+	}
+
+	// Context dari --context diutamakan atas context environment neon.yaml
+	swarmContext := config.ContextName()
+	if env != nil {
+		if env.Context != "" && !cmd.Flags().Changed("context") {
+			swarmContext = env.Context
+			if ctx := cfg.LookupContext(swarmContext); ctx != nil {
+				cfg.ApplyContext(ctx)
+			}
+		}
+		applyEnvironment(cmd, env, &cfg, &opts)
+	}
 
 	// Inisialisasi Docker client
-	client, err := docker.NewClient()
+	client, err := docker.NewClientForContext(swarmContext)
 	if err != nil {
 		return fmt.Errorf("gagal menginisialisasi Docker client: %v", err)
 	}
 
 	// Buat context dengan timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Proses deployment
-	deployer := docker.NewDeployer(client, cfg)
+	deployer := docker.NewDeployer(client, &cfg).WithMessage(message)
+	if env != nil {
+		deployer = deployer.WithEnvironment(env.Environment)
+	}
+
+	if env != nil && env.Environment != "" {
+		fmt.Printf("Environment: %s\n", env.Environment)
+	}
+
+	// File deploy/compose dari neon.yaml menggantikan pipeline build
+	if env != nil && !hasSource {
+		switch {
+		case env.ComposeFile != "":
+			fmt.Printf("Memulai deployment dari compose file: %s\n", env.ComposeFile)
+			return deployComposeFile(ctx, deployer, env.ComposeFile, env)
+		case env.DeployFile != "":
+			fmt.Printf("Memulai deployment dari file konfigurasi: %s\n", env.DeployFile)
+			return deployConfigFile(ctx, deployer, env.DeployFile, env)
+		}
+	}
 
-	fmt.Printf("Memulai deployment dari repository: %s\n", repoURL)
+	switch {
+	case opts.RepoURL != "":
+		fmt.Printf("Memulai deployment dari repository: %s (branch %s)\n", opts.RepoURL, opts.Branch)
+	case opts.Path != "":
+		fmt.Printf("Memulai deployment dari direktori: %s\n", opts.Path)
+	default:
+		fmt.Printf("Memulai deployment dari arsip: %s\n", opts.ContextFile)
+	}
 
-	err = deployer.Deploy(ctx, repoURL)
+	stages, err := deployer.Deploy(ctx, opts)
+	printStages(stages)
 	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("deployment melewati batas waktu %s: %v", timeout, err)
+		}
 		return fmt.Errorf("gagal melakukan deployment: %v", err)
 	}
 
 	fmt.Println("Deployment berhasil dilakukan")
 	return nil
 }
+
+// applyEnvironment menerapkan nilai neon.yaml ke konfigurasi dan opsi
+// deploy. Flag yang diisi eksplisit di command line tetap diutamakan.
+func applyEnvironment(cmd *cobra.Command, env *project.Resolved, cfg *config.Config, opts *docker.DeployOptions) {
+	if env.Registry != "" {
+		cfg.Docker.Registry = env.Registry
+	}
+
+	if opts.RepoURL == "" && opts.Path == "" && opts.ContextFile == "" {
+		if env.RepoURL != "" {
+			opts.RepoURL = env.RepoURL
+		} else {
+			opts.Path = projectDir
+		}
+	}
+	if env.Branch != "" && !cmd.Flags().Changed("branch") {
+		opts.Branch = env.Branch
+	}
+
+	if opts.Service == "" {
+		opts.Service = env.Stack
+	}
+	opts.Stack = env.Stack
+	opts.BuildContext = env.Build.Context
+	opts.Dockerfile = env.Build.Dockerfile
+	opts.BuildArgs = env.Build.Args
+	opts.Replicas = env.Replicas
+	opts.Environment = env.Env
+}
+
+func printStages(stages []docker.StageResult) {
+	if len(stages) == 0 {
+		return
+	}
+
+	var total time.Duration
+	fmt.Println()
+	fmt.Printf("%-10s %s\n", "STAGE", "DURATION")
+	for _, st := range stages {
+		total += st.Duration
+		fmt.Printf("%-10s %s\n", st.Name, st.Duration.Round(time.Millisecond))
+	}
+	fmt.Printf("%-10s %s\n", "total", total.Round(time.Millisecond))
+}
@@ -0,0 +1,122 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/docker"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "history SERVICE",
+		Short: "Tampilkan riwayat revisi service",
+		Long: `Tampilkan revisi service yang pernah diterapkan neon: waktu, user, image,
+digest, commit git, dan pesan deploy (--message). Revisi terbaru ditandai *.
+
+Jumlah revisi yang disimpan diatur dengan deploy.history_limit (default 10).`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+
+			history, err := docker.NewDeployer(client, nil).History(context.Background(), args[0])
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(history)
+			}
+			if len(history) == 0 {
+				fmt.Printf("Service %s belum memiliki riwayat revisi\n", args[0])
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "REVISION\tTIME\tUSER\tIMAGE\tDIGEST\tGIT\tMESSAGE")
+			for i := len(history) - 1; i >= 0; i-- {
+				r := history[i]
+				mark := ""
+				if i == len(history)-1 {
+					mark = "*"
+				}
+				fmt.Fprintf(w, "%d%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					r.Revision, mark, r.Time.Local().Format("2006-01-02 15:04:05"), r.User,
+					r.Image, shortDigest(r.Digest), shortSHA(r.GitSHA), r.Message)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "o", "table", "Format output (table, json)")
+	return cmd
+}
+
+func newRollbackCmd() *cobra.Command {
+	var (
+		revision int
+		wait     time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rollback SERVICE",
+		Short: "Kembalikan service ke revisi sebelumnya",
+		Long: `Terapkan ulang spec dari revisi yang tersimpan, lalu tunggu sampai semua
+replika berjalan. Tanpa --to-revision, service dikembalikan ke revisi
+sebelum revisi terbaru.
+
+Jumlah replika saat ini dipertahankan. Referensi secret di spec di-resolve
+ulang, dan rollback dicatat sebagai revisi baru.`,
+		Example: `  neon deploy rollback api
+  neon deploy rollback api --to-revision 7 -m "revert fitur checkout"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+
+			deployer := docker.NewDeployer(client, nil).WithMessage(message)
+			target, err := deployer.Rollback(context.Background(), args[0], revision, wait)
+			if err != nil {
+				if target != nil {
+					return fmt.Errorf("rollback %s ke revisi %d diterapkan tetapi belum konvergen: %v", args[0], target.Revision, err)
+				}
+				return err
+			}
+
+			fmt.Printf("Service %s dikembalikan ke revisi %d (%s)\n", args[0], target.Revision, target.Image)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&revision, "to-revision", 0, "Revisi tujuan (default: revisi sebelumnya)")
+	cmd.Flags().DurationVar(&wait, "timeout", 5*time.Minute, "Batas waktu menunggu service konvergen")
+	return cmd
+}
+
+func shortDigest(digest string) string {
+	if i := len(digest) - 64; i > 0 && digest[i-1] == ':' {
+		return digest[:i+12]
+	}
+	return digest
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
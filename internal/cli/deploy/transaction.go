@@ -0,0 +1,85 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/docker"
+)
+
+var (
+	atomicDeploy bool
+	waitTimeout  time.Duration
+)
+
+// addAtomicFlags mendaftarkan flag mode transaksi pada perintah deploy
+// stack.
+func addAtomicFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&atomicDeploy, "atomic", false, "Kembalikan semua service ke spec sebelumnya jika satu service gagal")
+	cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", 5*time.Minute, "Batas waktu menunggu setiap service konvergen (dengan --atomic)")
+}
+
+// deployStack memanggil deployFn untuk setiap service sesuai urutan names.
+// Dengan --atomic, spec setiap service dicatat sebelum di-deploy dan semua
+// service ditunggu sampai konvergen; jika ada yang gagal, service yang
+// sudah tersentuh dikembalikan dengan urutan terbalik.
+func deployStack(ctx context.Context, deployer *docker.Deployer, names []string, deployFn func(name string) error) error {
+	if !atomicDeploy {
+		for _, name := range names {
+			if err := deployFn(name); err != nil {
+				return fmt.Errorf("gagal deploy service %s: %v", name, err)
+			}
+		}
+		return nil
+	}
+
+	tx := deployer.Begin()
+	err := func() error {
+		for _, name := range names {
+			if err := tx.Track(ctx, name); err != nil {
+				return err
+			}
+			if err := deployFn(name); err != nil {
+				return fmt.Errorf("gagal deploy service %s: %v", name, err)
+			}
+		}
+		fmt.Println("Menunggu semua service konvergen...")
+		return tx.Wait(ctx, waitTimeout)
+	}()
+	if err == nil {
+		return nil
+	}
+
+	fmt.Printf("Deploy gagal: %v\n", err)
+	fmt.Println("Rollback transaksi (urutan terbalik):")
+	// Rollback tetap dijalankan walaupun batas waktu deploy sudah habis
+	results := tx.Rollback(context.WithoutCancel(ctx), waitTimeout)
+
+	var (
+		failed   []string
+		reverted int
+	)
+	for _, r := range results {
+		name := r.Service
+		if r.Secret != "" {
+			name = "secret " + r.Secret
+		}
+		if r.Err != nil {
+			fmt.Printf("  %s: GAGAL dikembalikan: %v\n", name, r.Err)
+			failed = append(failed, name)
+			continue
+		}
+		fmt.Printf("  %s: %s\n", name, r.Action)
+		if r.Secret == "" && r.Action != docker.RollbackUnchanged {
+			reverted++
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%v; rollback gagal untuk %s, periksa service tersebut secara manual", err, strings.Join(failed, ", "))
+	}
+	return fmt.Errorf("%v; %d service dikembalikan ke keadaan sebelum deploy", err, reverted)
+}
@@ -3,10 +3,8 @@ package deploy
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/spf13/cobra"
@@ -31,61 +29,50 @@ func newZeroDowntimeCmd() *cobra.Command {
 				return err
 			}
 
-			// Get existing service
-			service, _, err := client.ServiceInspectWithRaw(context.Background(), args[0], types.ServiceInspectOptions{})
-			if err != nil {
-				return fmt.Errorf("service not found: %v", err)
-			}
-
-			// Prepare update config
-			updateConfig := &swarm.UpdateConfig{
-				Parallelism:   1,
-				Delay:         time.Duration(updateDelay),
-				Order:         "start-first", // Start new container before stopping old one
-				FailureAction: "rollback",
-				Monitor:       time.Duration(5 * time.Second),
-			}
-
-			// Prepare rollback config
-			rollbackConfig := &swarm.UpdateConfig{
-				Parallelism:   1,
-				Delay:         time.Duration(updateDelay),
-				Order:         "stop-first",
-				FailureAction: "pause",
-			}
-
-			// Update service spec
-			service.Spec.Mode.Replicated.Replicas = &replicas
-			service.Spec.TaskTemplate.ContainerSpec.Image = image
-			service.Spec.UpdateConfig = updateConfig
-			service.Spec.RollbackConfig = rollbackConfig
+			logger.Info("Starting zero-downtime deployment...")
 
-			// Health check config
-			service.Spec.TaskTemplate.ContainerSpec.Healthcheck = &container.HealthConfig{
-				Test:     []string{"CMD-SHELL", "curl -f http://localhost/health || exit 1"},
-				Interval: time.Duration(5 * time.Second),
-				Timeout:  time.Duration(3 * time.Second),
-				Retries:  3,
-			}
+			// Spec diubah lewat Deployer agar melewati policy dan tercatat
+			// di riwayat revisi
+			deployer := docker.NewDeployer(client, nil).WithMessage(message)
+			err = deployer.ModifyService(context.Background(), args[0], func(spec *swarm.ServiceSpec) error {
+				if spec.Mode.Replicated == nil {
+					return fmt.Errorf("service %s berjalan dalam mode global", spec.Name)
+				}
+				if spec.TaskTemplate.ContainerSpec == nil {
+					return fmt.Errorf("service %s bukan service container", spec.Name)
+				}
 
-			logger.Info("Starting zero-downtime deployment...")
+				// Start new container before stopping old one
+				spec.UpdateConfig = &swarm.UpdateConfig{
+					Parallelism:   1,
+					Delay:         time.Duration(updateDelay),
+					Order:         "start-first",
+					FailureAction: "rollback",
+					Monitor:       time.Duration(5 * time.Second),
+				}
+				spec.RollbackConfig = &swarm.UpdateConfig{
+					Parallelism:   1,
+					Delay:         time.Duration(updateDelay),
+					Order:         "stop-first",
+					FailureAction: "pause",
+				}
 
-			// Update service
-			response, err := client.ServiceUpdate(
-				context.Background(),
-				args[0],
-				service.Version,
-				service.Spec,
-				types.ServiceUpdateOptions{},
-			)
+				spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: &replicas}
+				cs := *spec.TaskTemplate.ContainerSpec
+				cs.Image = image
+				cs.Healthcheck = &container.HealthConfig{
+					Test:     []string{"CMD-SHELL", "curl -f http://localhost/health || exit 1"},
+					Interval: time.Duration(5 * time.Second),
+					Timeout:  time.Duration(3 * time.Second),
+					Retries:  3,
+				}
+				spec.TaskTemplate.ContainerSpec = &cs
+				return nil
+			})
 			if err != nil {
 				return fmt.Errorf("deployment failed: %v", err)
 			}
 
-			if len(response.Warnings) > 0 {
-				logger.Warn("Deployment warnings: " + strings.Join(response.Warnings, ", "))
-			}
-
 			logger.Info("Zero-downtime deployment completed successfully")
 			return nil
 		},
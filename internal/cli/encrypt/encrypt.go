@@ -0,0 +1,255 @@
+// Package encrypt berisi command neon encrypt, neon decrypt, dan neon rekey
+// untuk nilai ENC[...] di file konfigurasi.
+package encrypt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/config/crypt"
+	"gopkg.in/yaml.v3"
+)
+
+func NewEncryptCmd() *cobra.Command {
+	var (
+		value   string
+		inPlace bool
+		match   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "encrypt [file...]",
+		Short: "Enkripsi secret di file konfigurasi",
+		Long: `Enkripsi nilai secret di file konfigurasi neon, deploy.yaml, atau compose
+menjadi ENC[...]. Nilai yang dienkripsi adalah nilai dengan key yang cocok
+dengan --match (password, token, secret, api_key, ...) dan item environment
+KEY=VALUE dengan KEY yang cocok; hanya VALUE yang dienkripsi.
+
+Nilai ENC[...] didekripsi di memori saat file dimuat. Kunci dibaca dari
+$NEON_SECRET_KEY, $NEON_SECRET_KEY_FILE, atau ~/.neon/secret.key (buat
+dengan neon rekey --generate).`,
+		Example: `  neon encrypt -i ~/.neon/config.yaml deploy.yaml
+  neon encrypt --value 's3cret'
+  echo -n 's3cret' | neon encrypt --value -`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kr, err := crypt.LoadKeyring()
+			if err != nil {
+				return err
+			}
+
+			if cmd.Flags().Changed("value") {
+				plain, err := readValue(value)
+				if err != nil {
+					return err
+				}
+				enc, err := kr.Encrypt(plain)
+				if err != nil {
+					return err
+				}
+				fmt.Println(enc)
+				return nil
+			}
+
+			pattern := crypt.DefaultPattern
+			if match != "" {
+				if pattern, err = regexp.Compile(match); err != nil {
+					return fmt.Errorf("--match tidak valid: %v", err)
+				}
+			}
+
+			return transformFiles(args, inPlace, "dienkripsi", func(node *yaml.Node) (int, error) {
+				return crypt.EncryptNode(node, kr, pattern)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&value, "value", "v", "", "Enkripsi satu nilai dan tampilkan hasilnya (- untuk stdin)")
+	cmd.Flags().BoolVarP(&inPlace, "in-place", "i", false, "Tulis hasil ke file, bukan ke stdout")
+	cmd.Flags().StringVar(&match, "match", "", "Regex nama key yang dienkripsi (default: password|secret|token|api_key|...)")
+	return cmd
+}
+
+func NewDecryptCmd() *cobra.Command {
+	var (
+		value   string
+		inPlace bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "decrypt [file...]",
+		Short: "Dekripsi nilai ENC[...] di file konfigurasi",
+		Example: `  neon decrypt deploy.yaml
+  neon decrypt -i deploy.yaml
+  neon decrypt --value 'ENC[AES256_GCM,...]'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kr, err := crypt.LoadKeyring()
+			if err != nil {
+				return err
+			}
+
+			if cmd.Flags().Changed("value") {
+				enc, err := readValue(value)
+				if err != nil {
+					return err
+				}
+				plain, err := kr.Decrypt(strings.TrimSpace(enc))
+				if err != nil {
+					return err
+				}
+				fmt.Println(plain)
+				return nil
+			}
+
+			return transformFiles(args, inPlace, "didekripsi", func(node *yaml.Node) (int, error) {
+				return crypt.DecryptNode(node, kr)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&value, "value", "v", "", "Dekripsi satu nilai ENC[...] (- untuk stdin)")
+	cmd.Flags().BoolVarP(&inPlace, "in-place", "i", false, "Tulis hasil ke file, bukan ke stdout")
+	return cmd
+}
+
+func NewRekeyCmd() *cobra.Command {
+	var generate, dropOld bool
+
+	cmd := &cobra.Command{
+		Use:   "rekey [file...]",
+		Short: "Enkripsi ulang secret dengan kunci utama",
+		Long: `Enkripsi ulang semua nilai ENC[...] di file dengan kunci utama (kunci
+pertama di file kunci).
+
+Dengan --generate, kunci baru dibuat dan ditambahkan sebagai kunci utama di
+file kunci; kunci lama tetap disimpan untuk mendekripsi nilai lama. Setelah
+semua file di-rekey, --drop-old menghapus kunci lama dari file kunci.
+--drop-old hanya diterima bersama daftar file; sebutkan semua file yang
+masih memakai kunci lama.`,
+		Example: `  neon rekey --generate
+  neon rekey --generate --drop-old ~/.neon/config.yaml deploy.yaml deploy.production.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !generate && len(args) == 0 {
+				return fmt.Errorf("sebutkan file yang di-rekey atau gunakan --generate")
+			}
+			if dropOld && len(args) == 0 {
+				// Tanpa file, nilai yang dienkripsi dengan kunci lama tidak
+				// pernah di-rekey dan tidak bisa didekripsi lagi
+				return fmt.Errorf("--drop-old memerlukan daftar file yang di-rekey")
+			}
+			if (generate || dropOld) && os.Getenv(crypt.KeyEnv) != "" {
+				return fmt.Errorf("kunci dibaca dari $%s; perbarui variabel tersebut secara manual", crypt.KeyEnv)
+			}
+
+			keyFile := crypt.DefaultKeyFile()
+			if generate {
+				key, err := crypt.GenerateKey()
+				if err != nil {
+					return err
+				}
+				keys := []crypt.Key{key}
+				if kr, err := crypt.ReadKeyFile(keyFile); err == nil {
+					keys = append(keys, kr.Keys...)
+				} else if _, statErr := os.Stat(keyFile); statErr == nil {
+					return err
+				}
+				if err := crypt.WriteKeyFile(keyFile, keys); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Kunci baru %s ditulis ke %s\n", key.ID, keyFile)
+			}
+
+			kr, err := crypt.LoadKeyring()
+			if err != nil {
+				return err
+			}
+			if err := transformFiles(args, true, "dienkripsi ulang", func(node *yaml.Node) (int, error) {
+				return crypt.ReencryptNode(node, kr)
+			}); err != nil {
+				return err
+			}
+
+			if dropOld && len(kr.Keys) > 1 {
+				if err := crypt.WriteKeyFile(keyFile, kr.Keys[:1]); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "%d kunci lama dihapus dari %s\n", len(kr.Keys)-1, keyFile)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&generate, "generate", false, "Buat kunci utama baru sebelum rekey")
+	cmd.Flags().BoolVar(&dropOld, "drop-old", false, "Hapus kunci lama dari file kunci setelah rekey")
+	return cmd
+}
+
+// readValue mengembalikan value, atau isi stdin jika value adalah "-".
+func readValue(value string) (string, error) {
+	if value != "-" {
+		return value, nil
+	}
+	data, err := io.ReadAll(bufio.NewReader(os.Stdin))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// transformFiles menerapkan fn ke setiap file YAML. Tanpa inPlace hasilnya
+// ditulis ke stdout, sehingga hanya satu file yang diizinkan.
+func transformFiles(files []string, inPlace bool, verb string, fn func(*yaml.Node) (int, error)) error {
+	if len(files) == 0 {
+		return nil
+	}
+	if !inPlace && len(files) > 1 {
+		return fmt.Errorf("gunakan --in-place untuk memproses lebih dari satu file")
+	}
+
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		count, err := fn(&doc)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		enc.Close()
+
+		if !inPlace {
+			_, err := os.Stdout.Write(buf.Bytes())
+			return err
+		}
+		if count == 0 {
+			fmt.Fprintf(os.Stderr, "%s: tidak ada nilai yang %s\n", path, verb)
+			continue
+		}
+		if err := os.WriteFile(path, buf.Bytes(), info.Mode().Perm()); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d nilai %s\n", path, count, verb)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/docker"
+	"github.com/zakirkun/neon/internal/lint"
+)
+
+// defaultFiles dicari di direktori saat ini jika tidak ada argumen.
+var defaultFiles = []string{
+	"deploy.yaml",
+	"config/deploy.yaml",
+	"docker-compose.yml",
+	"docker-compose.yaml",
+	"compose.yml",
+	"compose.yaml",
+}
+
+func NewLintCmd() *cobra.Command {
+	var (
+		live      bool
+		stack     string
+		format    string
+		failOn    string
+		disable   []string
+		severity  []string
+		listRules bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lint [file...]",
+		Short: "Periksa praktik terbaik dan keamanan service",
+		Long: `Periksa service di deploy.yaml, file compose, atau service swarm yang
+sedang berjalan (--live) terhadap praktik terbaik dan keamanan: image
+:latest, tanpa limit/reservation, tanpa healthcheck, secret plaintext di
+environment, akses privileged atau network host, port publik untuk
+service internal, workload stateful dengan satu replica, dan restart
+policy none.
+
+Redam finding dengan komentar di dalam blok service:
+
+  services:
+    - name: db   # neon-lint-ignore single-replica-stateful
+
+komentar "# neon-lint-ignore-file rule" untuk seluruh file, atau label
+neon.lint.ignore=rule1,rule2 pada service. Tanpa nama rule, semua rule
+diredam.`,
+		Example: `  neon lint
+  neon lint deploy.yaml --format sarif > neon-lint.sarif
+  neon lint --live --stack shop --fail-on warning
+  neon lint --severity healthcheck=error --disable resource-reservations`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if listRules {
+				return printRules()
+			}
+
+			opts, err := parseOptions(disable, severity)
+			if err != nil {
+				return err
+			}
+			threshold := lint.Error + 1
+			if failOn != "none" {
+				if threshold, err = lint.ParseSeverity(failOn); err != nil {
+					return err
+				}
+			}
+
+			var services []*lint.Service
+			if live {
+				services, err = liveServices(stack)
+			} else {
+				services, err = fileServices(args)
+			}
+			if err != nil {
+				return err
+			}
+
+			findings := lint.Run(services, opts)
+			switch format {
+			case "text":
+				err = lint.WriteText(os.Stdout, findings)
+			case "json":
+				err = lint.WriteJSON(os.Stdout, findings)
+			case "sarif":
+				err = lint.WriteSARIF(os.Stdout, findings)
+			default:
+				return fmt.Errorf("format %q tidak dikenal (text, json, sarif)", format)
+			}
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for _, f := range findings {
+				if f.Severity >= threshold {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d finding dengan severity %s atau lebih tinggi", failed, failOn)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&live, "live", false, "Periksa service swarm yang sedang berjalan")
+	cmd.Flags().StringVar(&stack, "stack", "", "Hanya service di stack ini (dengan --live)")
+	cmd.Flags().StringVarP(&format, "format", "o", "text", "Format output: text, json, atau sarif")
+	cmd.Flags().StringVar(&failOn, "fail-on", "error", "Keluar dengan error jika ada finding dengan severity ini atau lebih tinggi (error, warning, info, none)")
+	cmd.Flags().StringSliceVar(&disable, "disable", nil, "Rule yang tidak dijalankan")
+	cmd.Flags().StringSliceVar(&severity, "severity", nil, "Ganti severity rule (rule=error|warning|info)")
+	cmd.Flags().BoolVar(&listRules, "list-rules", false, "Tampilkan semua rule")
+	cmd.MarkFlagsMutuallyExclusive("live", "list-rules")
+
+	return cmd
+}
+
+func parseOptions(disable, severity []string) (lint.Options, error) {
+	opts := lint.Options{
+		Disabled:   make(map[string]bool),
+		Severities: make(map[string]lint.Severity),
+	}
+
+	for _, id := range disable {
+		if _, ok := lint.RuleByID(id); !ok {
+			return opts, fmt.Errorf("rule %q tidak dikenal", id)
+		}
+		opts.Disabled[id] = true
+	}
+
+	for _, s := range severity {
+		id, level, ok := strings.Cut(s, "=")
+		if !ok {
+			return opts, fmt.Errorf("format --severity tidak valid: %s (gunakan rule=level)", s)
+		}
+		if _, ok := lint.RuleByID(id); !ok {
+			return opts, fmt.Errorf("rule %q tidak dikenal", id)
+		}
+		sev, err := lint.ParseSeverity(level)
+		if err != nil {
+			return opts, err
+		}
+		opts.Severities[id] = sev
+	}
+
+	return opts, nil
+}
+
+func fileServices(files []string) ([]*lint.Service, error) {
+	if len(files) == 0 {
+		for _, f := range defaultFiles {
+			if _, err := os.Stat(f); err == nil {
+				files = append(files, f)
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("tidak ada deploy.yaml atau file compose di direktori saat ini")
+		}
+	}
+
+	var (
+		services []*lint.Service
+		errs     []error
+	)
+	for _, f := range files {
+		s, err := lint.LoadFile(f)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		services = append(services, s...)
+	}
+	return services, errors.Join(errs...)
+}
+
+func liveServices(stack string) ([]*lint.Service, error) {
+	client, err := docker.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	args := filters.NewArgs()
+	if stack != "" {
+		args.Add("label", docker.LabelStackNamespace+"="+stack)
+	}
+	list, err := client.ServiceList(ctx, types.ServiceListOptions{Filters: args})
+	if err != nil {
+		return nil, err
+	}
+
+	networks, err := client.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(networks))
+	for _, n := range networks {
+		names[n.ID] = n.Name
+	}
+
+	services := make([]*lint.Service, 0, len(list))
+	for _, svc := range list {
+		services = append(services, lint.FromSwarm(svc, names))
+	}
+	return services, nil
+}
+
+func printRules() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tSEVERITY\tDESKRIPSI")
+	for _, r := range lint.Rules {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, r.Severity, r.Description)
+	}
+	return w.Flush()
+}
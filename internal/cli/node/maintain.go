@@ -0,0 +1,192 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	dockerswarm "github.com/docker/docker/api/types/swarm"
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/docker/swarm"
+)
+
+type maintainOptions struct {
+	hook      string
+	timeout   time.Duration
+	rebalance bool
+}
+
+func newMaintainCmd() *cobra.Command {
+	var opts maintainOptions
+
+	cmd := &cobra.Command{
+		Use:   "maintain NODE...",
+		Short: "Maintenance node satu per satu (drain, hook, aktifkan kembali)",
+		Long: `Jalankan maintenance bergilir pada node secara berurutan. Untuk setiap node:
+
+  1. drain node dan tunggu sampai semua task replicated berjalan sehat di
+     node lain
+  2. jalankan perintah --hook secara lokal (misalnya patch dan reboot)
+  3. tunggu node kembali Ready, lalu set availability active
+  4. dengan --rebalance, sebarkan ulang task service ke semua node
+
+Sebelum setiap node, cluster harus stabil. Proses berhenti jika drain
+membuat service tidak dapat dijadwalkan, reservasi resource tidak muat di
+node lain, atau manager yang tersisa tidak mencapai quorum. Node yang sedang
+dipakai neon tidak dapat di-maintain; gunakan --context ke manager lain.
+
+Hook dijalankan dengan sh -c dan variabel NEON_NODE (hostname),
+NEON_NODE_ID, dan NEON_NODE_ADDR.`,
+		Example: `  neon node maintain worker-1 worker-2 --hook 'ssh $NEON_NODE sudo apt-get -y upgrade && ssh $NEON_NODE sudo reboot' --rebalance`,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			local, err := mgr.LocalNodeID(ctx)
+			if err != nil {
+				return err
+			}
+
+			for i, name := range args {
+				fmt.Printf("==> [%d/%d] %s\n", i+1, len(args), name)
+				if err := maintainNode(ctx, mgr, name, local, opts); err != nil {
+					return fmt.Errorf("maintenance %s dihentikan: %v", name, err)
+				}
+			}
+			fmt.Printf("Maintenance %d node selesai\n", len(args))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.hook, "hook", "", "Perintah lokal yang dijalankan setelah node di-drain")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 10*time.Minute, "Batas waktu setiap tahap menunggu")
+	cmd.Flags().BoolVar(&opts.rebalance, "rebalance", false, "Sebarkan ulang task service setelah node aktif kembali")
+	return cmd
+}
+
+func maintainNode(ctx context.Context, mgr *swarm.Manager, name, local string, opts maintainOptions) error {
+	node, err := mgr.InspectNode(ctx, name)
+	if err != nil {
+		return err
+	}
+	if node.ID == local {
+		return fmt.Errorf("node ini adalah daemon yang sedang dipakai neon; jalankan dari manager lain dengan --context")
+	}
+
+	if err := checkMaintenance(ctx, mgr, node); err != nil {
+		return err
+	}
+
+	fmt.Println("Drain node...")
+	if err := mgr.UpdateNode(ctx, node.ID, func(spec *dockerswarm.NodeSpec) {
+		spec.Availability = dockerswarm.NodeAvailabilityDrain
+	}); err != nil {
+		return err
+	}
+
+	// Setelah drain berhasil, kegagalan berikutnya meninggalkan node dalam
+	// keadaan drain agar tidak menerima task selama maintenance belum selesai
+	drained := func(err error) error {
+		return fmt.Errorf("%v (node dibiarkan drain; aktifkan dengan 'neon node update %s --availability active')", err, name)
+	}
+
+	fmt.Println("Menunggu task dipindahkan ke node lain...")
+	if err := mgr.WaitConverged(ctx, node.ID, opts.timeout); err != nil {
+		return drained(err)
+	}
+
+	if opts.hook != "" {
+		fmt.Printf("Menjalankan hook: %s\n", opts.hook)
+		if err := runHook(ctx, opts.hook, node, opts.timeout); err != nil {
+			return drained(fmt.Errorf("hook gagal: %v", err))
+		}
+	}
+
+	fmt.Println("Menunggu node Ready...")
+	if err := mgr.WaitNodeReady(ctx, node.ID, opts.timeout); err != nil {
+		return drained(err)
+	}
+
+	if err := mgr.UpdateNode(ctx, node.ID, func(spec *dockerswarm.NodeSpec) {
+		spec.Availability = dockerswarm.NodeAvailabilityActive
+	}); err != nil {
+		return drained(err)
+	}
+	fmt.Println("Node aktif kembali")
+
+	if opts.rebalance {
+		updated, err := mgr.Rebalance(ctx)
+		if err != nil {
+			return err
+		}
+		if len(updated) > 0 {
+			fmt.Printf("Rebalance %d service\n", len(updated))
+		}
+	}
+
+	return mgr.WaitConverged(ctx, "", opts.timeout)
+}
+
+// checkMaintenance memastikan node dapat dikeluarkan sementara: cluster
+// stabil, quorum manager tetap terjaga, semua service tetap dapat
+// dijadwalkan, dan reservasi resource muat di node lain.
+func checkMaintenance(ctx context.Context, mgr *swarm.Manager, node *dockerswarm.Node) error {
+	pending, err := mgr.Pending(ctx, "")
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("cluster belum stabil; service belum berjalan penuh: %s", strings.Join(pending, ", "))
+	}
+
+	if node.ManagerStatus != nil {
+		nodes, err := mgr.ListNodes(ctx)
+		if err != nil {
+			return err
+		}
+		q := swarm.QuorumOf(nodes)
+		if node.ManagerStatus.Reachability == dockerswarm.ReachabilityReachable {
+			q.Reachable--
+		}
+		if !q.Healthy() {
+			return fmt.Errorf("manager yang tersisa (%d dari %d reachable) tidak mencapai quorum saat node ini mati", q.Reachable, q.Managers)
+		}
+	}
+
+	impacts, err := mgr.PlanNodeChange(ctx, node.ID, func(n *dockerswarm.Node) {
+		n.Spec.Availability = dockerswarm.NodeAvailabilityDrain
+	})
+	if err != nil {
+		return err
+	}
+	for _, i := range impacts {
+		if i.Unschedulable {
+			return fmt.Errorf("%s", i)
+		}
+	}
+
+	return mgr.CheckDrainCapacity(ctx, node.ID)
+}
+
+func runHook(ctx context.Context, hook string, node *dockerswarm.Node, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"NEON_NODE="+node.Description.Hostname,
+		"NEON_NODE_ID="+node.ID,
+		"NEON_NODE_ADDR="+node.Status.Addr,
+	)
+	return cmd.Run()
+}
@@ -0,0 +1,536 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	dockerswarm "github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"github.com/zakirkun/neon/internal/docker"
+	"github.com/zakirkun/neon/internal/docker/swarm"
+)
+
+func NewNodeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "node",
+		Short: "Kelola node Docker Swarm",
+	}
+
+	cmd.AddCommand(
+		newListCmd(),
+		newInspectCmd(),
+		newUpdateCmd(),
+		newPromoteCmd(),
+		newDemoteCmd(),
+		newLabelCmd(),
+		newRemoveCmd(),
+		newMaintainCmd(),
+	)
+
+	return cmd
+}
+
+func newManager() (*swarm.Manager, error) {
+	client, err := docker.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return swarm.NewManager(client), nil
+}
+
+func newListCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "Tampilkan daftar node",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			nodes, err := mgr.ListNodeInfo(context.Background())
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				return printJSON(nodes)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tHOSTNAME\tROLE\tAVAILABILITY\tSTATUS\tENGINE\tCPU\tMEMORY\tTASKS")
+			for _, n := range nodes {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d/%d\n",
+					shortID(n.ID), n.Description.Hostname, role(&n.Node), n.Spec.Availability,
+					n.Status.State, n.Description.Engine.EngineVersion,
+					cpus(n.Description.Resources.NanoCPUs), units.BytesSize(float64(n.Description.Resources.MemoryBytes)),
+					n.Running, n.Desired)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "o", "table", "Format output (table, json)")
+	return cmd
+}
+
+func newInspectCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "inspect NODE...",
+		Short: "Tampilkan detail node",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			var nodes []*dockerswarm.Node
+			for _, name := range args {
+				node, err := mgr.InspectNode(ctx, name)
+				if err != nil {
+					return err
+				}
+				nodes = append(nodes, node)
+			}
+
+			if format == "json" {
+				return printJSON(nodes)
+			}
+			for i, node := range nodes {
+				if i > 0 {
+					fmt.Println()
+				}
+				tasks, err := mgr.NodeTasks(ctx, node.ID)
+				if err != nil {
+					return err
+				}
+				printNode(node, tasks)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "o", "pretty", "Format output (pretty, json)")
+	return cmd
+}
+
+func printNode(n *dockerswarm.Node, tasks []dockerswarm.Task) {
+	fmt.Printf("ID:            %s\n", n.ID)
+	fmt.Printf("Hostname:      %s\n", n.Description.Hostname)
+	fmt.Printf("Role:          %s\n", role(n))
+	fmt.Printf("Availability:  %s\n", n.Spec.Availability)
+	fmt.Printf("Status:        %s", n.Status.State)
+	if n.Status.Message != "" {
+		fmt.Printf(" (%s)", n.Status.Message)
+	}
+	fmt.Println()
+	fmt.Printf("Address:       %s\n", n.Status.Addr)
+	if n.ManagerStatus != nil {
+		fmt.Printf("Manager addr:  %s (%s)\n", n.ManagerStatus.Addr, n.ManagerStatus.Reachability)
+	}
+	fmt.Printf("Platform:      %s/%s\n", n.Description.Platform.OS, n.Description.Platform.Architecture)
+	fmt.Printf("Engine:        %s\n", n.Description.Engine.EngineVersion)
+	fmt.Printf("Resources:     %s CPU, %s memory\n", cpus(n.Description.Resources.NanoCPUs), units.BytesSize(float64(n.Description.Resources.MemoryBytes)))
+	printLabels("Labels:", n.Spec.Labels)
+	printLabels("Engine labels:", n.Description.Engine.Labels)
+
+	running := 0
+	for _, t := range tasks {
+		if t.DesiredState == dockerswarm.TaskStateRunning {
+			running++
+		}
+	}
+	fmt.Printf("Tasks:         %d\n", running)
+}
+
+func printLabels(title string, labels map[string]string) {
+	if len(labels) == 0 {
+		fmt.Printf("%-14s -\n", title)
+		return
+	}
+	fmt.Println(title)
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("  %s=%s\n", k, labels[k])
+	}
+}
+
+func newUpdateCmd() *cobra.Command {
+	var (
+		availability string
+		force        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "update NODE",
+		Short: "Ubah availability node",
+		Long: `Ubah availability node: active menerima task, pause tidak menerima task
+baru, drain memindahkan semua task ke node lain.
+
+Jika perubahan membuat service tidak memiliki node yang dapat menjalankan
+task-nya, perubahan dibatalkan kecuali --force diberikan.`,
+		Example: "  neon node update worker-2 --availability drain",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validate.Check("oneof=active pause drain", availability); err != nil {
+				return fmt.Errorf("--availability: %v", err)
+			}
+			target := dockerswarm.NodeAvailability(availability)
+
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			impacts, err := mgr.PlanNodeChange(ctx, args[0], func(n *dockerswarm.Node) {
+				n.Spec.Availability = target
+			})
+			if err != nil {
+				return err
+			}
+			if err := checkImpacts(impacts, force); err != nil {
+				return err
+			}
+
+			if err := mgr.UpdateNode(ctx, args[0], func(spec *dockerswarm.NodeSpec) {
+				spec.Availability = target
+			}); err != nil {
+				return err
+			}
+			fmt.Printf("Node %s: availability %s\n", args[0], target)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&availability, "availability", "", "Availability node (active, pause, drain)")
+	cmd.Flags().BoolVar(&force, "force", false, "Terapkan meskipun ada service yang tidak dapat dijadwalkan")
+	cmd.MarkFlagRequired("availability")
+	return cmd
+}
+
+func newPromoteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "promote NODE...",
+		Short: "Jadikan node sebagai manager",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			nodes, err := mgr.ListNodes(ctx)
+			if err != nil {
+				return err
+			}
+			quorum := swarm.QuorumOf(nodes)
+
+			for _, name := range args {
+				node, err := mgr.InspectNode(ctx, name)
+				if err != nil {
+					return err
+				}
+				if node.Spec.Role == dockerswarm.NodeRoleManager {
+					fmt.Printf("Node %s sudah manager\n", name)
+					continue
+				}
+				if node.Status.State != dockerswarm.NodeStateReady {
+					fmt.Fprintf(os.Stderr, "Peringatan: node %s berstatus %s; manager yang tidak reachable mengurangi toleransi quorum\n", name, node.Status.State)
+				}
+
+				if err := mgr.UpdateNode(ctx, node.ID, func(spec *dockerswarm.NodeSpec) {
+					spec.Role = dockerswarm.NodeRoleManager
+				}); err != nil {
+					return err
+				}
+				quorum.Managers++
+				if node.Status.State == dockerswarm.NodeStateReady {
+					quorum.Reachable++
+				}
+				fmt.Printf("Node %s dipromosikan menjadi manager\n", name)
+			}
+
+			printQuorum(quorum)
+			if quorum.Managers%2 == 0 {
+				fmt.Fprintf(os.Stderr, "Peringatan: jumlah manager genap (%d) tidak menambah toleransi kegagalan; gunakan jumlah ganjil\n", quorum.Managers)
+			}
+			return nil
+		},
+	}
+}
+
+func newDemoteCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "demote NODE...",
+		Short: "Jadikan manager sebagai worker",
+		Long: `Jadikan manager sebagai worker. Demote ditolak jika node adalah manager
+terakhir, atau jika manager yang tersisa tidak lagi mencapai quorum (kecuali
+--force).`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			nodes, err := mgr.ListNodes(ctx)
+			if err != nil {
+				return err
+			}
+			quorum := swarm.QuorumOf(nodes)
+			tolerance := quorum.Tolerance()
+
+			for _, name := range args {
+				node, err := mgr.InspectNode(ctx, name)
+				if err != nil {
+					return err
+				}
+				if node.Spec.Role != dockerswarm.NodeRoleManager {
+					fmt.Printf("Node %s sudah worker\n", name)
+					continue
+				}
+
+				after := swarm.Quorum{Managers: quorum.Managers - 1, Reachable: quorum.Reachable}
+				if node.ManagerStatus != nil && node.ManagerStatus.Reachability == dockerswarm.ReachabilityReachable {
+					after.Reachable--
+				}
+				if after.Managers == 0 {
+					return fmt.Errorf("node %s adalah manager terakhir dan tidak dapat di-demote", name)
+				}
+				if !after.Healthy() && !force {
+					return fmt.Errorf("demote %s menyisakan %d dari %d manager yang reachable; cluster kehilangan quorum (gunakan --force untuk tetap melanjutkan)",
+						name, after.Reachable, after.Managers)
+				}
+				if node.ManagerStatus != nil && node.ManagerStatus.Leader {
+					fmt.Fprintf(os.Stderr, "Peringatan: %s adalah leader; leader baru akan dipilih\n", name)
+				}
+
+				if err := mgr.UpdateNode(ctx, node.ID, func(spec *dockerswarm.NodeSpec) {
+					spec.Role = dockerswarm.NodeRoleWorker
+				}); err != nil {
+					return err
+				}
+				quorum = after
+				fmt.Printf("Node %s di-demote menjadi worker\n", name)
+			}
+
+			printQuorum(quorum)
+			if quorum.Tolerance() < tolerance {
+				fmt.Fprintf(os.Stderr, "Peringatan: toleransi kegagalan manager turun dari %d menjadi %d\n", tolerance, quorum.Tolerance())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Demote meskipun quorum hilang")
+	return cmd
+}
+
+func newLabelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "label",
+		Short: "Kelola label node",
+		Long: `Kelola label node yang dipakai placement constraint (node.labels.<key>).
+
+Sebelum label diubah, constraint semua service diperiksa. Perubahan yang
+membuat service tidak memiliki node yang memenuhi constraint-nya dibatalkan
+kecuali --force diberikan.`,
+	}
+
+	var force bool
+	add := &cobra.Command{
+		Use:     "add NODE KEY=VALUE...",
+		Short:   "Tambah atau ubah label node",
+		Example: "  neon node label add worker-1 zone=eu-1 disk=ssd",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			labels := make(map[string]string)
+			for _, kv := range args[1:] {
+				key, value, _ := strings.Cut(kv, "=")
+				if key == "" {
+					return fmt.Errorf("label %q tidak valid (gunakan KEY=VALUE)", kv)
+				}
+				labels[key] = value
+			}
+			return updateLabels(args[0], force, func(l map[string]string) {
+				for k, v := range labels {
+					l[k] = v
+				}
+			})
+		},
+	}
+	add.Flags().BoolVar(&force, "force", false, "Terapkan meskipun ada service yang tidak dapat dijadwalkan")
+
+	rm := &cobra.Command{
+		Use:     "rm NODE KEY...",
+		Short:   "Hapus label node",
+		Example: "  neon node label rm worker-1 zone",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateLabels(args[0], force, func(l map[string]string) {
+				for _, k := range args[1:] {
+					delete(l, k)
+				}
+			})
+		},
+	}
+	rm.Flags().BoolVar(&force, "force", false, "Terapkan meskipun ada service yang tidak dapat dijadwalkan")
+
+	cmd.AddCommand(add, rm)
+	return cmd
+}
+
+func updateLabels(name string, force bool, change func(map[string]string)) error {
+	mgr, err := newManager()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	impacts, err := mgr.PlanNodeChange(ctx, name, func(n *dockerswarm.Node) {
+		change(n.Spec.Labels)
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkImpacts(impacts, force); err != nil {
+		return err
+	}
+
+	if err := mgr.UpdateNode(ctx, name, func(spec *dockerswarm.NodeSpec) {
+		if spec.Labels == nil {
+			spec.Labels = make(map[string]string)
+		}
+		change(spec.Labels)
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Label node %s diperbarui\n", name)
+	return nil
+}
+
+func newRemoveCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:     "rm NODE...",
+		Aliases: []string{"remove"},
+		Short:   "Hapus node dari swarm",
+		Long: `Hapus node dari swarm. Manager harus di-demote lebih dulu. Node yang masih
+Ready hanya dapat dihapus dengan --force; sebaiknya jalankan neon swarm leave
+di node tersebut.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			for _, name := range args {
+				node, err := mgr.InspectNode(ctx, name)
+				if err != nil {
+					return err
+				}
+				if node.Spec.Role == dockerswarm.NodeRoleManager {
+					return fmt.Errorf("node %s adalah manager; demote dulu dengan 'neon node demote %s'", name, name)
+				}
+				if node.Status.State == dockerswarm.NodeStateReady && !force {
+					return fmt.Errorf("node %s masih Ready; jalankan 'neon swarm leave' di node tersebut atau gunakan --force", name)
+				}
+
+				impacts, err := mgr.PlanNodeChange(ctx, node.ID, nil)
+				if err != nil {
+					return err
+				}
+				if err := checkImpacts(impacts, force); err != nil {
+					return err
+				}
+
+				if err := mgr.RemoveNode(ctx, node.ID, force); err != nil {
+					return err
+				}
+				fmt.Printf("Node %s dihapus\n", name)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Hapus node yang masih Ready atau meskipun ada service yang tidak dapat dijadwalkan")
+	return cmd
+}
+
+// checkImpacts menampilkan dampak perubahan node. Service yang tidak dapat
+// dijadwalkan membatalkan perubahan kecuali force.
+func checkImpacts(impacts []swarm.Impact, force bool) error {
+	blocking := 0
+	for _, i := range impacts {
+		prefix := "  "
+		if i.Unschedulable {
+			prefix = "! "
+			blocking++
+		}
+		fmt.Fprintf(os.Stderr, "%s%s\n", prefix, i)
+	}
+	if blocking > 0 && !force {
+		return fmt.Errorf("%d service tidak dapat dijadwalkan setelah perubahan ini; gunakan --force untuk tetap menerapkan", blocking)
+	}
+	return nil
+}
+
+func printQuorum(q swarm.Quorum) {
+	fmt.Printf("Manager: %d (%d reachable), toleransi kegagalan: %d\n", q.Managers, q.Reachable, q.Tolerance())
+}
+
+func role(n *dockerswarm.Node) string {
+	if n.ManagerStatus == nil {
+		return string(n.Spec.Role)
+	}
+	if n.ManagerStatus.Leader {
+		return "manager (leader)"
+	}
+	if n.ManagerStatus.Reachability != dockerswarm.ReachabilityReachable {
+		return fmt.Sprintf("manager (%s)", n.ManagerStatus.Reachability)
+	}
+	return "manager"
+}
+
+func cpus(nano int64) string {
+	return fmt.Sprintf("%g", float64(nano)/1e9)
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -2,13 +2,33 @@ package cli
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/zakirkun/neon/internal/cli/annotation"
 	"github.com/zakirkun/neon/internal/cli/autoscale"
+	"github.com/zakirkun/neon/internal/cli/build"
+	"github.com/zakirkun/neon/internal/cli/bundle"
+	"github.com/zakirkun/neon/internal/cli/capacity"
 	"github.com/zakirkun/neon/internal/cli/container"
+	"github.com/zakirkun/neon/internal/cli/context"
 	"github.com/zakirkun/neon/internal/cli/deploy"
+	"github.com/zakirkun/neon/internal/cli/encrypt"
 	"github.com/zakirkun/neon/internal/cli/image"
+	"github.com/zakirkun/neon/internal/cli/lint"
 	"github.com/zakirkun/neon/internal/cli/network"
+	"github.com/zakirkun/neon/internal/cli/node"
+	"github.com/zakirkun/neon/internal/cli/service"
+	"github.com/zakirkun/neon/internal/cli/status"
 	"github.com/zakirkun/neon/internal/cli/swarm"
+	"github.com/zakirkun/neon/internal/cli/validate"
 	"github.com/zakirkun/neon/internal/cli/volume"
+	"github.com/zakirkun/neon/internal/cli/why"
+	"github.com/zakirkun/neon/internal/config"
+)
+
+var (
+	configFile  string
+	contextName string
+	startup     func(cmd *cobra.Command) error
 )
 
 var rootCmd = &cobra.Command{
@@ -16,17 +36,84 @@ var rootCmd = &cobra.Command{
 	Short: "Neon - DevOps Management Tool",
 	Long: `Neon adalah tools untuk membantu manajemen DevOps seperti
 deployment, scaling, dan pengelolaan Docker/Docker Swarm.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Flag sudah valid; error setelah ini bukan kesalahan pemakaian
+		cmd.SilenceUsage = true
+
+		config.SetPath(configFile)
+		config.SetContext(contextName)
+		if startup != nil {
+			return startup(cmd)
+		}
+		return nil
+	},
+}
+
+// SetStartup mengatur fungsi yang dijalankan sebelum setiap command, setelah
+// flag global (--config, --context) diterapkan.
+func SetStartup(fn func(cmd *cobra.Command) error) {
+	startup = fn
+}
+
+// SetVersion mengaktifkan flag --version.
+func SetVersion(version string) {
+	rootCmd.Version = version
+	rootCmd.SetVersionTemplate("Neon v{{.Version}}\n")
+}
+
+// NeedsSwarm melaporkan apakah cmd membutuhkan Docker dalam mode swarm.
+func NeedsSwarm(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[annotation.NoSwarm] == "true" {
+			return false
+		}
+	}
+	return NeedsDocker(cmd)
+}
+
+// NeedsDocker melaporkan apakah cmd membutuhkan koneksi ke Docker daemon.
+// Flag bertanda annotation.NoDocker yang diisi juga melewati pemeriksaan.
+func NeedsDocker(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[annotation.NoDocker] == "true" || c.Name() == "help" || c.Name() == "completion" {
+			return false
+		}
+	}
+
+	needs := true
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if len(f.Annotations[annotation.NoDocker]) > 0 {
+			needs = false
+		}
+	})
+	return needs
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", config.DefaultPath(), "Path ke file konfigurasi")
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "Context cluster (default: $NEON_CONTEXT atau current_context)")
+
 	rootCmd.AddCommand(
 		deploy.NewDeployCmd(),
+		build.NewBuildCmd(),
+		bundle.NewBundleCmd(),
 		container.NewContainerCmd(),
 		image.NewImageCmd(),
 		volume.NewVolumeCmd(),
 		network.NewNetworkCmd(),
-		swarm.NewSwarmCmd(),
+		annotation.WithoutSwarm(swarm.NewSwarmCmd()),
+		node.NewNodeCmd(),
+		service.NewServiceCmd(),
+		status.NewStatusCmd(),
+		why.NewWhyCmd(),
+		capacity.NewCapacityCmd(),
 		autoscale.NewAutoscaleCmd(),
+		annotation.WithoutDocker(context.NewContextCmd()),
+		annotation.WithoutDocker(validate.NewValidateCmd()),
+		annotation.WithoutDocker(lint.NewLintCmd()),
+		annotation.WithoutDocker(encrypt.NewEncryptCmd()),
+		annotation.WithoutDocker(encrypt.NewDecryptCmd()),
+		annotation.WithoutDocker(encrypt.NewRekeyCmd()),
 	)
 }
 
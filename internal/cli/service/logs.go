@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/docker/swarm"
+)
+
+// colors adalah warna ANSI untuk prefix task, dipilih bergiliran.
+var colors = []string{"36", "33", "32", "35", "34", "96", "93", "92", "95", "94"}
+
+type logsOptions struct {
+	swarm.LogOptions
+	timestamps bool
+	grep       string
+	format     string
+	noColor    bool
+}
+
+func newLogsCmd() *cobra.Command {
+	var opts logsOptions
+
+	cmd := &cobra.Command{
+		Use:   "logs SERVICE...",
+		Short: "Tampilkan log gabungan semua task service",
+		Long: `Tampilkan log semua task dari satu atau beberapa service. Setiap baris
+diberi prefix service.slot@node; stdout dan stderr dipisahkan dengan benar.
+
+Dengan -o json, setiap baris ditulis sebagai objek JSON (time, service,
+task, task_id, node, stream, message) untuk diproses tool lain.`,
+		Example: `  neon service logs web --since 10m --grep 'ERROR|WARN'
+  neon service logs web worker -f --tail 50
+  neon service logs web -o json | jq 'select(.stream == "stderr")'`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.format != "text" && opts.format != "json" {
+				return fmt.Errorf("format %q tidak didukung (text, json)", opts.format)
+			}
+			var pattern *regexp.Regexp
+			if opts.grep != "" {
+				var err error
+				if pattern, err = regexp.Compile(opts.grep); err != nil {
+					return fmt.Errorf("--grep: %v", err)
+				}
+			}
+
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			p := &logPrinter{
+				opts:    opts,
+				pattern: pattern,
+				color:   !opts.noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout),
+				colors:  make(map[string]string),
+			}
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(args))
+			for i, name := range args {
+				wg.Add(1)
+				go func(i int, name string) {
+					defer wg.Done()
+					if err := mgr.ServiceLogs(ctx, name, opts.LogOptions, p.print); err != nil {
+						errs[i] = fmt.Errorf("%s: %v", name, err)
+					}
+				}(i, name)
+			}
+			wg.Wait()
+
+			for _, err := range errs {
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Since, "since", "", "Tampilkan log sejak timestamp atau durasi relatif (misal 10m)")
+	flags.StringVar(&opts.Until, "until", "", "Tampilkan log sampai timestamp atau durasi relatif")
+	flags.StringVarP(&opts.Tail, "tail", "n", "all", "Jumlah baris terakhir per task")
+	flags.BoolVarP(&opts.Follow, "follow", "f", false, "Ikuti log baru")
+	flags.BoolVarP(&opts.timestamps, "timestamps", "t", false, "Tampilkan timestamp")
+	flags.StringVar(&opts.grep, "grep", "", "Hanya baris yang cocok dengan regex")
+	flags.StringVarP(&opts.format, "format", "o", "text", "Format output (text, json)")
+	flags.BoolVar(&opts.noColor, "no-color", false, "Tanpa warna")
+	return cmd
+}
+
+// logPrinter menulis baris log dari beberapa goroutine tanpa saling
+// bercampur.
+type logPrinter struct {
+	opts    logsOptions
+	pattern *regexp.Regexp
+	color   bool
+
+	mu     sync.Mutex
+	colors map[string]string
+}
+
+func (p *logPrinter) print(l swarm.LogLine) {
+	if p.pattern != nil && !p.pattern.MatchString(l.Message) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.opts.format == "json" {
+		data, _ := json.Marshal(l)
+		fmt.Fprintf(os.Stdout, "%s\n", data)
+		return
+	}
+
+	prefix := l.Service
+	if l.Task != "" {
+		prefix = l.Task + "@" + l.Node
+	}
+	if p.color {
+		c, ok := p.colors[prefix]
+		if !ok {
+			c = colors[len(p.colors)%len(colors)]
+			p.colors[prefix] = c
+		}
+		prefix = "\033[" + c + "m" + prefix + "\033[0m"
+	}
+
+	out := os.Stdout
+	if l.Stream == "stderr" {
+		out = os.Stderr
+	}
+	if p.opts.timestamps && !l.Time.IsZero() {
+		fmt.Fprintf(out, "%s %s | %s\n", l.Time.Format(time.RFC3339Nano), prefix, l.Message)
+		return
+	}
+	fmt.Fprintf(out, "%s | %s\n", prefix, l.Message)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,504 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	dockerswarm "github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/config/crypt"
+	"github.com/zakirkun/neon/internal/docker"
+	"github.com/zakirkun/neon/internal/docker/swarm"
+)
+
+func NewServiceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Kelola service Docker Swarm",
+	}
+
+	cmd.AddCommand(
+		newListCmd(),
+		newPsCmd(),
+		newInspectCmd(),
+		newScaleCmd(),
+		newUpdateCmd(),
+		newRemoveCmd(),
+		newRestartCmd(),
+		newLogsCmd(),
+	)
+
+	return cmd
+}
+
+func newManager() (*swarm.Manager, error) {
+	client, err := docker.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return swarm.NewManager(client), nil
+}
+
+func newListCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "Tampilkan daftar service",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			services, err := mgr.ListServiceInfo(context.Background())
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				return printJSON(services)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tMODE\tREPLICAS\tIMAGE\tPORTS")
+			for i := range services {
+				s := &services[i]
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\t%s\n",
+					shortID(s.ID), s.Spec.Name, s.Mode(), s.Running, s.Desired,
+					image(&s.Spec), ports(s.Endpoint.Ports))
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "o", "table", "Format output (table, json)")
+	return cmd
+}
+
+func newPsCmd() *cobra.Command {
+	var running bool
+
+	cmd := &cobra.Command{
+		Use:   "ps SERVICE...",
+		Short: "Tampilkan task service",
+		Long: `Tampilkan task service beserta node, state, error, dan exit code.
+Task lama yang sudah berhenti ikut ditampilkan kecuali --running.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			nodes, err := mgr.NodeNames(ctx)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tIMAGE\tNODE\tDESIRED\tSTATE\tERROR\tEXIT")
+			for _, name := range args {
+				svc, err := mgr.InspectService(ctx, name)
+				if err != nil {
+					return err
+				}
+				tasks, err := mgr.ServiceTasks(ctx, svc.ID)
+				if err != nil {
+					return err
+				}
+				for _, t := range tasks {
+					if running && t.DesiredState != dockerswarm.TaskStateRunning {
+						continue
+					}
+					node := nodes[t.NodeID]
+					if node == "" {
+						node = shortID(t.NodeID)
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+						shortID(t.ID), taskName(svc.Spec.Name, &t), taskImage(&t), node,
+						t.DesiredState, taskState(&t), t.Status.Err, exitCode(&t))
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&running, "running", false, "Hanya task yang seharusnya berjalan")
+	return cmd
+}
+
+func newInspectCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "inspect SERVICE...",
+		Short: "Tampilkan detail service",
+		Long: `Tampilkan detail service. Pada format pretty, nilai environment dengan
+nama yang terlihat seperti secret disamarkan; gunakan -o json untuk spec
+lengkap.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			var services []*dockerswarm.Service
+			for _, name := range args {
+				svc, err := mgr.InspectService(ctx, name)
+				if err != nil {
+					return err
+				}
+				services = append(services, svc)
+			}
+
+			if format == "json" {
+				return printJSON(services)
+			}
+			for i, svc := range services {
+				if i > 0 {
+					fmt.Println()
+				}
+				printService(svc)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "o", "pretty", "Format output (pretty, json)")
+	return cmd
+}
+
+func printService(svc *dockerswarm.Service) {
+	spec := &svc.Spec
+	fmt.Printf("ID:         %s\n", svc.ID)
+	fmt.Printf("Name:       %s\n", spec.Name)
+	if spec.Mode.Replicated != nil && spec.Mode.Replicated.Replicas != nil {
+		fmt.Printf("Mode:       replicated (%d replika)\n", *spec.Mode.Replicated.Replicas)
+	} else {
+		fmt.Printf("Mode:       global\n")
+	}
+	fmt.Printf("Image:      %s\n", image(spec))
+	fmt.Printf("Updated:    %s\n", svc.UpdatedAt.Local().Format(time.RFC3339))
+	if us := svc.UpdateStatus; us != nil {
+		fmt.Printf("Update:     %s", us.State)
+		if us.Message != "" {
+			fmt.Printf(" (%s)", us.Message)
+		}
+		fmt.Println()
+	}
+
+	if cs := spec.TaskTemplate.ContainerSpec; cs != nil {
+		if len(cs.Command) > 0 || len(cs.Args) > 0 {
+			fmt.Printf("Command:    %s\n", strings.Join(append(cs.Command, cs.Args...), " "))
+		}
+		if len(cs.Env) > 0 {
+			fmt.Println("Environment:")
+			for _, kv := range cs.Env {
+				key, value, _ := strings.Cut(kv, "=")
+				if crypt.DefaultPattern.MatchString(key) {
+					value = "********"
+				}
+				fmt.Printf("  %s=%s\n", key, value)
+			}
+		}
+		if len(cs.Secrets) > 0 {
+			fmt.Println("Secrets:")
+			for _, s := range cs.Secrets {
+				target := ""
+				if s.File != nil {
+					target = s.File.Name
+				}
+				fmt.Printf("  %s -> /run/secrets/%s\n", s.SecretName, target)
+			}
+		}
+	}
+
+	if len(spec.Labels) > 0 {
+		fmt.Println("Labels:")
+		keys := make([]string, 0, len(spec.Labels))
+		for k := range spec.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("  %s=%s\n", k, spec.Labels[k])
+		}
+	}
+
+	if r := spec.TaskTemplate.Resources; r != nil {
+		if r.Limits != nil && (r.Limits.NanoCPUs > 0 || r.Limits.MemoryBytes > 0) {
+			fmt.Printf("Limits:     %s\n", resources(r.Limits.NanoCPUs, r.Limits.MemoryBytes))
+		}
+		if r.Reservations != nil && (r.Reservations.NanoCPUs > 0 || r.Reservations.MemoryBytes > 0) {
+			fmt.Printf("Reserved:   %s\n", resources(r.Reservations.NanoCPUs, r.Reservations.MemoryBytes))
+		}
+	}
+	if p := spec.TaskTemplate.Placement; p != nil && len(p.Constraints) > 0 {
+		fmt.Printf("Placement:  %s\n", strings.Join(p.Constraints, ", "))
+	}
+	if len(svc.Endpoint.Ports) > 0 {
+		fmt.Printf("Ports:      %s\n", ports(svc.Endpoint.Ports))
+	}
+}
+
+func newScaleCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "scale SERVICE=REPLICAS...",
+		Short: "Ubah jumlah replika satu atau beberapa service",
+		Long: `Ubah jumlah replika service. Sebelum scale naik, neon memeriksa apakah
+replika tambahan muat di node berdasarkan reservasi CPU/memori, constraint,
+dan max replicas per node (lihat neon capacity --what-if). Gunakan --force
+untuk tetap scale; task yang tidak muat akan tertahan di pending.
+
+Jumlah replika baru dievaluasi terhadap policy deploy seperti neon deploy
+dan neon service update; --force tidak melewati policy.`,
+		Example: "  neon service scale web=3 worker=5",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targets := make([]struct {
+				name     string
+				replicas uint64
+			}, len(args))
+			for i, arg := range args {
+				name, value, ok := strings.Cut(arg, "=")
+				n, err := strconv.ParseUint(value, 10, 64)
+				if !ok || name == "" || err != nil {
+					return fmt.Errorf("argumen %q tidak valid (gunakan SERVICE=REPLICAS)", arg)
+				}
+				targets[i].name, targets[i].replicas = name, n
+			}
+
+			client, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+			mgr := swarm.NewManager(client)
+			deployer := docker.NewDeployer(client, nil)
+			ctx := context.Background()
+
+			failed := 0
+			for _, t := range targets {
+				if !force {
+					plan, err := mgr.PlanScale(ctx, t.name, t.replicas)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "%s: %v\n", t.name, err)
+						failed++
+						continue
+					}
+					if !plan.Fits() {
+						fmt.Fprintf(os.Stderr, "%s: %d replika tidak muat: %s (gunakan --force untuk tetap scale)\n", t.name, plan.Unplaced, plan.Reason)
+						failed++
+						continue
+					}
+				}
+				if err := deployer.ScaleService(ctx, t.name, t.replicas); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", t.name, err)
+					failed++
+					continue
+				}
+				fmt.Printf("%s di-scale ke %d replika\n", t.name, t.replicas)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d service gagal di-scale", failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Scale walaupun replika tambahan tidak muat di node")
+	return cmd
+}
+
+func newUpdateCmd() *cobra.Command {
+	var changes docker.ServiceChanges
+
+	cmd := &cobra.Command{
+		Use:   "update SERVICE",
+		Short: "Ubah spec service",
+		Long: `Ubah spec service yang sedang berjalan. Perubahan melewati policy dan
+resolusi secret yang sama dengan deploy, sehingga --env-add boleh berisi
+referensi vault://, file://, env://, atau exec://.
+
+Perubahan ini tidak ditulis ke file konfigurasi; deploy berikutnya
+mengembalikan nilai dari file.`,
+		Example: `  neon service update web --image myapp:1.4.2
+  neon service update web --env-add LOG_LEVEL=debug --env-rm DEBUG
+  neon service update web --limit-memory 512M --limit-cpu 0.5 --label-add team=payments`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().NFlag() == 0 {
+				return fmt.Errorf("tidak ada perubahan; lihat 'neon service update --help'")
+			}
+
+			client, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+			deployer := docker.NewDeployer(client, nil)
+			if err := deployer.UpdateService(context.Background(), args[0], &changes); err != nil {
+				return err
+			}
+			fmt.Printf("Service %s diperbarui\n", args[0])
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&changes.Image, "image", "", "Image baru")
+	flags.StringArrayVar(&changes.EnvAdd, "env-add", nil, "Tambah atau ubah environment (KEY=VALUE)")
+	flags.StringArrayVar(&changes.EnvRm, "env-rm", nil, "Hapus environment (KEY)")
+	flags.StringArrayVar(&changes.LabelAdd, "label-add", nil, "Tambah atau ubah label service (KEY=VALUE)")
+	flags.StringArrayVar(&changes.LabelRm, "label-rm", nil, "Hapus label service (KEY)")
+	flags.StringVar(&changes.LimitCPUs, "limit-cpu", "", "Batas CPU (misal 0.5)")
+	flags.StringVar(&changes.LimitMemory, "limit-memory", "", "Batas memori (misal 512M)")
+	flags.StringVar(&changes.ReservationCPUs, "reserve-cpu", "", "Reservasi CPU")
+	flags.StringVar(&changes.ReservationMemory, "reserve-memory", "", "Reservasi memori")
+	flags.StringArrayVar(&changes.PublishAdd, "publish-add", nil, "Publish port (PUBLISHED:TARGET)")
+	flags.StringArrayVar(&changes.PublishRm, "publish-rm", nil, "Hapus port (PUBLISHED atau PUBLISHED:TARGET)")
+	flags.BoolVar(&changes.Force, "force", false, "Paksa update meskipun spec tidak berubah")
+	return cmd
+}
+
+func newRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm SERVICE...",
+		Aliases: []string{"remove"},
+		Short:   "Hapus service",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			for _, name := range args {
+				if err := mgr.RemoveService(ctx, name); err != nil {
+					return err
+				}
+				fmt.Printf("Service %s dihapus\n", name)
+			}
+			return nil
+		},
+	}
+}
+
+func newRestartCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restart SERVICE...",
+		Short: "Restart semua task service secara bergilir",
+		Long: `Restart service dengan force update: task diganti satu per satu sesuai
+update_config service, tanpa mengubah spec.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+			deployer := docker.NewDeployer(client, nil)
+			ctx := context.Background()
+
+			for _, name := range args {
+				if err := deployer.UpdateService(ctx, name, &docker.ServiceChanges{Force: true}); err != nil {
+					return err
+				}
+				fmt.Printf("Service %s di-restart\n", name)
+			}
+			return nil
+		},
+	}
+}
+
+func taskName(service string, t *dockerswarm.Task) string {
+	if t.Slot > 0 {
+		return fmt.Sprintf("%s.%d", service, t.Slot)
+	}
+	return fmt.Sprintf("%s.%s", service, shortID(t.NodeID))
+}
+
+func taskImage(t *dockerswarm.Task) string {
+	if cs := t.Spec.ContainerSpec; cs != nil {
+		return trimDigest(cs.Image)
+	}
+	return ""
+}
+
+func taskState(t *dockerswarm.Task) string {
+	return fmt.Sprintf("%s (%s)", t.Status.State, units.HumanDuration(time.Since(t.Status.Timestamp)))
+}
+
+// exitCode hanya ditampilkan untuk task yang sudah berhenti.
+func exitCode(t *dockerswarm.Task) string {
+	switch t.Status.State {
+	case dockerswarm.TaskStateComplete, dockerswarm.TaskStateFailed, dockerswarm.TaskStateShutdown, dockerswarm.TaskStateRejected:
+		if cs := t.Status.ContainerStatus; cs != nil {
+			return strconv.Itoa(cs.ExitCode)
+		}
+	}
+	return "-"
+}
+
+func image(spec *dockerswarm.ServiceSpec) string {
+	if cs := spec.TaskTemplate.ContainerSpec; cs != nil {
+		return trimDigest(cs.Image)
+	}
+	return ""
+}
+
+// trimDigest membuang @sha256:... yang ditambahkan swarm saat resolve image.
+func trimDigest(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i]
+	}
+	return image
+}
+
+func ports(list []dockerswarm.PortConfig) string {
+	parts := make([]string, 0, len(list))
+	for _, p := range list {
+		if p.PublishedPort == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("*:%d->%d/%s", p.PublishedPort, p.TargetPort, p.Protocol))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func resources(nanoCPUs, memory int64) string {
+	var parts []string
+	if nanoCPUs > 0 {
+		parts = append(parts, fmt.Sprintf("%g CPU", float64(nanoCPUs)/1e9))
+	}
+	if memory > 0 {
+		parts = append(parts, units.BytesSize(float64(memory))+" memory")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,181 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/docker"
+	"github.com/zakirkun/neon/internal/docker/swarm"
+)
+
+func NewStatusCmd() *cobra.Command {
+	var (
+		format   string
+		watch    bool
+		interval time.Duration
+		window   time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "status [STACK]",
+		Short: "Tampilkan ringkasan stack atau seluruh cluster",
+		Long: `Tampilkan keadaan setiap service dalam satu layar, dikelompokkan per
+stack: image dan digest, replika berjalan/diinginkan, status update, revisi
+deploy terakhir beserta umurnya, jumlah task sehat dan gagal, kegagalan
+task terbaru, dan port yang dipublikasikan.
+
+Task dihitung gagal jika berstatus failed atau rejected dalam --window
+terakhir. Dengan --watch, tampilan diperbarui setiap --interval sampai
+dihentikan dengan Ctrl+C; -o json lalu menulis satu objek per baris.`,
+		Example: `  neon status
+  neon status shop --watch
+  neon status -o json | jq '.stacks[].services[] | select(.unhealthy > 0)'`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "table" && format != "json" {
+				return fmt.Errorf("format %q tidak didukung (table, json)", format)
+			}
+			var stack string
+			if len(args) > 0 {
+				stack = args[0]
+			}
+
+			client, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+			mgr := swarm.NewManager(client)
+
+			show := func(ctx context.Context) error {
+				status, err := mgr.Status(ctx, stack, window)
+				if err != nil {
+					return err
+				}
+				if format == "json" {
+					return printJSON(status, !watch)
+				}
+				if watch {
+					// Bersihkan layar sebelum menggambar ulang
+					fmt.Print("\033[H\033[2J")
+				}
+				return printStatus(os.Stdout, status)
+			}
+
+			if !watch {
+				return show(context.Background())
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if err := show(ctx); err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "o", "table", "Format output (table, json)")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Perbarui tampilan secara berkala")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "Jeda pembaruan dengan --watch")
+	cmd.Flags().DurationVar(&window, "window", time.Hour, "Rentang waktu kegagalan task yang dihitung")
+	return cmd
+}
+
+func printStatus(out io.Writer, status *swarm.ClusterStatus) error {
+	services := 0
+	for _, st := range status.Stacks {
+		services += len(st.Services)
+	}
+	fmt.Fprintf(out, "Cluster: %d/%d node ready, %d service\n", status.NodesReady, status.Nodes, services)
+
+	for _, st := range status.Stacks {
+		name := st.Name
+		if name == "" {
+			name = "(tanpa stack)"
+		}
+		fmt.Fprintf(out, "\nSTACK %s\n", name)
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  SERVICE\tIMAGE\tDIGEST\tREPLICAS\tUPDATE\tREVISION\tAGE\tHEALTH\tPORTS")
+		for _, s := range st.Services {
+			revision := "-"
+			if s.Revision > 0 {
+				revision = fmt.Sprint(s.Revision)
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%d/%d\t%s\t%s\t%s\t%d ok, %d gagal\t%s\n",
+				s.Name, s.Image, shortDigest(s.Digest), s.Running, s.Desired,
+				orDash(s.UpdateState), revision, age(s.DeployedAt),
+				s.Healthy, s.Unhealthy, orDash(strings.Join(s.Ports, ", ")))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		for _, s := range st.Services {
+			if s.UpdateMessage != "" && s.UpdateState != "completed" {
+				fmt.Fprintf(out, "  ! %s: update %s: %s\n", s.Name, s.UpdateState, s.UpdateMessage)
+			}
+			for _, f := range s.Failures {
+				fmt.Fprintf(out, "  ! %s", f.Task)
+				if f.Node != "" {
+					fmt.Fprintf(out, "@%s", f.Node)
+				}
+				fmt.Fprintf(out, " %s (%s): %s", f.State, units.HumanDuration(time.Since(f.Time)), f.Error)
+				if f.ExitCode != 0 {
+					fmt.Fprintf(out, " (exit %d)", f.ExitCode)
+				}
+				fmt.Fprintln(out)
+			}
+		}
+	}
+	return nil
+}
+
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return units.HumanDuration(time.Since(t))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func shortDigest(digest string) string {
+	if i := len(digest) - 64; i > 0 && digest[i-1] == ':' {
+		return digest[:i+12]
+	}
+	return digest
+}
+
+func printJSON(v interface{}, indent bool) error {
+	enc := json.NewEncoder(os.Stdout)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
@@ -1,10 +1,308 @@
 package swarm
 
-import "github.com/spf13/cobra"
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	dockerswarm "github.com/docker/docker/api/types/swarm"
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"github.com/zakirkun/neon/internal/docker"
+	"github.com/zakirkun/neon/internal/docker/swarm"
+)
 
 func NewSwarmCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "swarm",
 		Short: "Manajemen Docker Swarm cluster",
 	}
+
+	cmd.AddCommand(
+		newInitCmd(),
+		newJoinCmd(),
+		newLeaveCmd(),
+		newJoinTokenCmd(),
+	)
+
+	return cmd
+}
+
+func newManager() (*swarm.Manager, error) {
+	client, err := docker.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return swarm.NewManager(client), nil
+}
+
+func newInitCmd() *cobra.Command {
+	var (
+		opts         swarm.InitOptions
+		availability string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Buat swarm baru dengan node ini sebagai manager",
+		Example: `  neon swarm init --advertise-addr 10.0.0.10
+  neon swarm init --advertise-addr eth1 --data-path-addr eth2 --default-addr-pool 10.20.0.0/16 --autolock`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, pool := range opts.DefaultAddrPool {
+				if _, _, err := net.ParseCIDR(pool); err != nil {
+					return fmt.Errorf("--default-addr-pool %q bukan CIDR yang valid", pool)
+				}
+			}
+			if opts.SubnetSize > 29 {
+				return fmt.Errorf("--default-addr-pool-mask-length harus antara 1 dan 29")
+			}
+			if err := validate.Check("oneof=active pause drain", availability); err != nil {
+				return fmt.Errorf("--availability: %v", err)
+			}
+			opts.Availability = dockerswarm.NodeAvailability(availability)
+
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			nodeID, err := mgr.InitSwarm(ctx, opts)
+			if err != nil {
+				return fmt.Errorf("gagal inisialisasi swarm: %v", err)
+			}
+			fmt.Printf("Swarm diinisialisasi: node %s sekarang manager.\n", nodeID)
+
+			if info, err := mgr.JoinInfo(ctx); err == nil {
+				fmt.Println("\nUntuk menambahkan worker ke swarm ini, jalankan:")
+				fmt.Printf("\n    neon swarm join --token %s %s\n\n", info.Tokens.Worker, info.ManagerAddr)
+				fmt.Println("Untuk menambahkan manager, jalankan 'neon swarm join-token manager'.")
+			}
+
+			if opts.Autolock {
+				key, err := mgr.UnlockKey(ctx)
+				if err != nil {
+					return fmt.Errorf("gagal membaca unlock key: %v", err)
+				}
+				fmt.Println("\nAutolock aktif. Simpan unlock key berikut di tempat aman; manager yang")
+				fmt.Println("restart tidak dapat bergabung kembali tanpa key ini (docker swarm unlock):")
+				fmt.Printf("\n    %s\n", key)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.AdvertiseAddr, "advertise-addr", "", "Alamat yang diumumkan ke node lain (IP[:port] atau interface)")
+	cmd.Flags().StringVar(&opts.ListenAddr, "listen-addr", swarm.DefaultListenAddr, "Alamat listen manager (IP[:port] atau interface)")
+	cmd.Flags().StringVar(&opts.DataPathAddr, "data-path-addr", "", "Alamat atau interface untuk traffic data overlay")
+	cmd.Flags().Uint32Var(&opts.DataPathPort, "data-path-port", 0, "Port UDP VXLAN untuk traffic data (default 4789)")
+	cmd.Flags().StringSliceVar(&opts.DefaultAddrPool, "default-addr-pool", nil, "CIDR untuk subnet network overlay (dapat diulang)")
+	cmd.Flags().Uint32Var(&opts.SubnetSize, "default-addr-pool-mask-length", 24, "Panjang mask subnet dari default-addr-pool")
+	cmd.Flags().BoolVar(&opts.Autolock, "autolock", false, "Kunci manager dengan unlock key setelah restart")
+	cmd.Flags().StringVar(&availability, "availability", "active", "Availability node (active, pause, drain)")
+	return cmd
+}
+
+func newJoinCmd() *cobra.Command {
+	var (
+		opts         swarm.JoinOptions
+		availability string
+		fromContext  string
+		role         string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "join [HOST:PORT]",
+		Short: "Gabungkan node ini ke swarm sebagai worker atau manager",
+		Long: `Gabungkan node ini (context aktif) ke swarm yang sudah ada.
+
+Dengan --token, peran node ditentukan oleh token (lihat neon swarm join-token).
+Dengan --from-context, token dan alamat manager diambil dari cluster di context
+tersebut, dan --role memilih worker atau manager.`,
+		Example: `  neon swarm join --token SWMTKN-1-... 10.0.0.10:2377
+  neon --context node3 swarm join --from-context production --role manager`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validate.Check("oneof=active pause drain", availability); err != nil {
+				return fmt.Errorf("--availability: %v", err)
+			}
+			opts.Availability = dockerswarm.NodeAvailability(availability)
+			if len(args) == 1 {
+				opts.RemoteAddrs = []string{args[0]}
+			}
+
+			ctx := context.Background()
+			switch {
+			case fromContext != "":
+				if opts.Token != "" {
+					return fmt.Errorf("--token dan --from-context tidak dapat dipakai bersamaan")
+				}
+				if err := validate.Check("oneof=worker manager", role); err != nil {
+					return fmt.Errorf("--role: %v", err)
+				}
+				if err := joinInfoFromContext(ctx, fromContext, role, &opts); err != nil {
+					return err
+				}
+			case opts.Token == "":
+				return fmt.Errorf("--token atau --from-context wajib diisi")
+			case cmd.Flags().Changed("role"):
+				return fmt.Errorf("--role hanya untuk --from-context; dengan --token peran ditentukan oleh token")
+			}
+			if len(opts.RemoteAddrs) == 0 {
+				return fmt.Errorf("alamat manager (HOST:PORT) wajib diisi")
+			}
+
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			if err := mgr.JoinSwarm(ctx, opts); err != nil {
+				return fmt.Errorf("gagal bergabung ke swarm: %v", err)
+			}
+
+			if fromContext != "" {
+				fmt.Printf("Node ini bergabung ke swarm sebagai %s.\n", role)
+			} else {
+				fmt.Println("Node ini bergabung ke swarm.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Token, "token", "", "Token join worker atau manager")
+	cmd.Flags().StringVar(&fromContext, "from-context", "", "Ambil token dan alamat manager dari context cluster ini")
+	cmd.Flags().StringVar(&role, "role", "worker", "Peran node dengan --from-context (worker, manager)")
+	cmd.Flags().StringVar(&opts.AdvertiseAddr, "advertise-addr", "", "Alamat yang diumumkan ke node lain (IP[:port] atau interface)")
+	cmd.Flags().StringVar(&opts.ListenAddr, "listen-addr", swarm.DefaultListenAddr, "Alamat listen untuk manager (IP[:port] atau interface)")
+	cmd.Flags().StringVar(&opts.DataPathAddr, "data-path-addr", "", "Alamat atau interface untuk traffic data overlay")
+	cmd.Flags().StringVar(&availability, "availability", "active", "Availability node (active, pause, drain)")
+	return cmd
+}
+
+// joinInfoFromContext mengisi token dan alamat manager dari cluster di
+// context name.
+func joinInfoFromContext(ctx context.Context, name, role string, opts *swarm.JoinOptions) error {
+	client, err := docker.NewClientForContext(name)
+	if err != nil {
+		return err
+	}
+	info, err := swarm.NewManager(client).JoinInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("gagal membaca token join dari context %s (harus manager): %v", name, err)
+	}
+
+	opts.Token = info.Tokens.Worker
+	if role == "manager" {
+		opts.Token = info.Tokens.Manager
+	}
+	if len(opts.RemoteAddrs) == 0 && info.ManagerAddr != "" {
+		opts.RemoteAddrs = []string{info.ManagerAddr}
+	}
+	return nil
+}
+
+func newLeaveCmd() *cobra.Command {
+	var forceCount int
+
+	cmd := &cobra.Command{
+		Use:   "leave",
+		Short: "Keluarkan node ini dari swarm",
+		Long: `Keluarkan node ini (context aktif) dari swarm.
+
+Worker dapat langsung keluar. Manager harus di-demote lebih dulu; --force
+memaksa manager keluar. neon menolak --force jika node ini manager terakhir
+(state swarm hilang) atau jika manager yang tersisa kehilangan quorum, kecuali
+--force diberikan dua kali (--force --force).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			plan, err := mgr.PlanLeave(ctx)
+			if err != nil {
+				return err
+			}
+
+			if plan.Manager {
+				switch {
+				case plan.LastManager() && forceCount < 2:
+					return fmt.Errorf("node ini adalah manager terakhir; keluar akan menghapus seluruh state swarm (%d service, %d node).\n"+
+						"Gunakan --force --force jika memang ingin membubarkan cluster", plan.Services, plan.Nodes)
+				case plan.LosesQuorum() && forceCount < 2:
+					return fmt.Errorf("hanya %d dari %d manager yang reachable; tanpa node ini cluster kehilangan quorum dan tidak dapat dikelola.\n"+
+						"Pulihkan manager lain lebih dulu, atau gunakan --force --force", plan.Reachable, plan.Managers)
+				case forceCount == 0:
+					return fmt.Errorf("node ini adalah manager; demote dulu dengan 'neon node demote %s' lalu jalankan ulang, atau gunakan --force", plan.NodeID)
+				}
+				if plan.LastManager() {
+					fmt.Fprintln(os.Stderr, "Peringatan: manager terakhir keluar, state swarm dihapus.")
+				}
+			}
+
+			if err := mgr.LeaveSwarm(ctx, forceCount > 0); err != nil {
+				return fmt.Errorf("gagal keluar dari swarm: %v", err)
+			}
+			fmt.Println("Node ini telah keluar dari swarm.")
+			return nil
+		},
+	}
+
+	cmd.Flags().CountVarP(&forceCount, "force", "f", "Paksa keluar (ulangi untuk manager terakhir atau saat quorum hilang)")
+	return cmd
+}
+
+func newJoinTokenCmd() *cobra.Command {
+	var rotate, quiet bool
+
+	cmd := &cobra.Command{
+		Use:       "join-token (worker|manager)",
+		Short:     "Tampilkan atau rotasi token join",
+		Example:   "  neon swarm join-token worker\n  neon swarm join-token manager --rotate",
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: []string{"worker", "manager"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			role := args[0]
+
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			if rotate {
+				if err := mgr.RotateJoinToken(ctx, role == "worker", role == "manager"); err != nil {
+					return fmt.Errorf("gagal merotasi token: %v", err)
+				}
+				if !quiet {
+					fmt.Printf("Token join %s dirotasi; token lama tidak berlaku lagi.\n\n", role)
+				}
+			}
+
+			info, err := mgr.JoinInfo(ctx)
+			if err != nil {
+				return fmt.Errorf("gagal membaca token join (harus dijalankan di manager): %v", err)
+			}
+			token := info.Tokens.Worker
+			if role == "manager" {
+				token = info.Tokens.Manager
+			}
+
+			if quiet {
+				fmt.Println(token)
+				return nil
+			}
+			fmt.Printf("Untuk menambahkan %s ke swarm ini, jalankan:\n", role)
+			fmt.Printf("\n    neon swarm join --token %s %s\n", token, info.ManagerAddr)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&rotate, "rotate", false, "Ganti token dengan token baru")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Tampilkan token saja")
+	return cmd
 }
@@ -0,0 +1,215 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/config"
+	"github.com/zakirkun/neon/internal/config/compose"
+	"github.com/zakirkun/neon/internal/config/deploy"
+	"github.com/zakirkun/neon/internal/config/project"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"github.com/zakirkun/neon/internal/policy"
+	"gopkg.in/yaml.v3"
+)
+
+// Jenis file yang dapat divalidasi.
+const (
+	kindConfig  = "config"
+	kindDeploy  = "deploy"
+	kindOverlay = "overlay"
+	kindCompose = "compose"
+	kindProject = "project"
+	kindPolicy  = "policy"
+)
+
+// defaultFiles dicari di direktori saat ini jika tidak ada argumen.
+var defaultFiles = []string{
+	project.FileName,
+	"deploy.yaml",
+	"config/deploy.yaml",
+	"docker-compose.yml",
+	"docker-compose.yaml",
+	"compose.yml",
+	"compose.yaml",
+}
+
+func NewValidateCmd() *cobra.Command {
+	var kind string
+
+	cmd := &cobra.Command{
+		Use:   "validate [file...]",
+		Short: "Validasi file konfigurasi neon",
+		Long: `Validasi config.yaml, deploy.yaml (beserta overlay), file compose, dan
+neon.yaml, dan file policy secara ketat: key yang tidak dikenal, tipe yang salah, unit memory,
+durasi, port, dan nilai enum dilaporkan dengan file, baris, dan kolom.
+
+Tanpa argumen, konfigurasi global dan file yang umum di direktori saat ini
+(neon.yaml, deploy.yaml, docker-compose.yml, ...) divalidasi. Untuk
+neon.yaml, file deploy/compose yang dirujuknya ikut divalidasi.
+
+Validasi yang sama dijalankan otomatis sebelum setiap deploy.`,
+		Example: `  neon validate
+  neon validate config/deploy.yaml config/deploy.staging.yaml
+  neon validate --type compose stack.yml`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files := args
+			if len(files) == 0 {
+				files = discover()
+				if len(files) == 0 {
+					return fmt.Errorf("tidak ada file konfigurasi yang ditemukan")
+				}
+			}
+
+			failed := 0
+			for _, file := range files {
+				k := kind
+				if k == "" {
+					var err error
+					if k, err = detectKind(file); err != nil {
+						fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+						failed++
+						continue
+					}
+				}
+
+				if err := validateFile(file, k); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					failed++
+					continue
+				}
+				fmt.Printf("OK  %s (%s)\n", file, k)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d dari %d file tidak valid", failed, len(files))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&kind, "type", "t", "", "Jenis file: config, deploy, overlay, compose, project, atau policy (default: deteksi otomatis)")
+	return cmd
+}
+
+// discover mengembalikan file konfigurasi yang ada: konfigurasi global lalu
+// file umum di direktori saat ini.
+func discover() []string {
+	var files []string
+	for _, f := range append([]string{config.Path()}, defaultFiles...) {
+		if _, err := os.Stat(f); err == nil {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// detectKind menebak jenis file dari nama dan bentuk isinya.
+func detectKind(path string) (string, error) {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	switch {
+	case base == project.FileName:
+		return kindProject, nil
+	case name == "policy":
+		return kindPolicy, nil
+	case strings.Contains(name, "compose"):
+		return kindCompose, nil
+	case strings.HasPrefix(name, "deploy."):
+		return kindOverlay, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var top map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &top); err != nil {
+		// Biarkan validasi melaporkan error sintaks dengan posisinya
+		return kindDeploy, nil
+	}
+
+	if services, ok := top["services"]; ok {
+		if services.Kind == yaml.MappingNode {
+			return kindCompose, nil
+		}
+		return kindDeploy, nil
+	}
+	if _, ok := top["rules"]; ok {
+		return kindPolicy, nil
+	}
+	for _, key := range []string{"docker", "swarm", "deploy", "cache", "policy"} {
+		if _, ok := top[key]; ok {
+			return kindConfig, nil
+		}
+	}
+	return "", fmt.Errorf("jenis file tidak dikenali, gunakan --type")
+}
+
+func validateFile(path, kind string) error {
+	switch kind {
+	case kindConfig:
+		var cfg config.Config
+		return validate.DecodeFile(path, &cfg)
+	case kindDeploy:
+		_, err := deploy.LoadFromFile(path)
+		return err
+	case kindOverlay:
+		_, err := deploy.LoadOverlay(path)
+		return err
+	case kindCompose:
+		_, err := compose.LoadFromFile(path)
+		return err
+	case kindProject:
+		return validateProject(path)
+	case kindPolicy:
+		_, err := policy.Load(path)
+		return err
+	default:
+		return fmt.Errorf("jenis file %q tidak dikenal", kind)
+	}
+}
+
+// validateProject memvalidasi neon.yaml beserta file deploy/compose yang
+// dirujuknya dan overlay per environment.
+func validateProject(path string) error {
+	if filepath.Base(path) != project.FileName {
+		var cfg project.Config
+		return validate.DecodeFile(path, &cfg)
+	}
+
+	proj, err := project.Load(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if proj.Deploy.File != "" {
+		file := filepath.Join(proj.Dir, proj.Deploy.File)
+		if _, err := deploy.LoadFromFile(file); err != nil {
+			errs = append(errs, err)
+		}
+		for _, env := range proj.EnvironmentNames() {
+			overlay := deploy.OverlayPath(file, env)
+			if _, err := os.Stat(overlay); err != nil {
+				continue
+			}
+			if _, err := deploy.LoadWithOverlay(file, overlay); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if proj.Deploy.Compose != "" {
+		if _, err := compose.LoadFromFile(filepath.Join(proj.Dir, proj.Deploy.Compose)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
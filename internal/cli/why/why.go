@@ -0,0 +1,128 @@
+package why
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"github.com/zakirkun/neon/internal/docker"
+	"github.com/zakirkun/neon/internal/docker/swarm"
+)
+
+// maxTasks adalah jumlah task yang ditampilkan per penyebab.
+const maxTasks = 5
+
+func NewWhyCmd() *cobra.Command {
+	var (
+		format string
+		all    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "why SERVICE",
+		Short: "Jelaskan mengapa task service gagal",
+		Long: `Kumpulkan error task, exit code, status OOM, error placement, kegagalan
+pull image, dan output healthcheck dari service, kelompokkan per penyebab,
+lalu tunjukkan akar masalah yang paling mungkin beserta saran perbaikan.
+
+Status OOM dan output healthcheck dibaca dari container, sehingga hanya
+tersedia untuk task di node yang dihubungi neon. Tanpa --all, hanya task
+dari spec service saat ini yang diperiksa.`,
+		Example: `  neon why api
+  neon why api --all -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("format %q tidak didukung (text, json)", format)
+			}
+
+			client, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+			d, err := swarm.NewManager(client).Why(context.Background(), args[0], all)
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(d)
+			}
+			printDiagnosis(d)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "o", "text", "Format output (text, json)")
+	cmd.Flags().BoolVar(&all, "all", false, "Sertakan task dari spec service sebelumnya")
+	return cmd
+}
+
+func printDiagnosis(d *swarm.Diagnosis) {
+	fmt.Printf("Service %s: %d/%d replika berjalan\n", d.Service, d.Running, d.Desired)
+	if d.UpdateMessage != "" && d.UpdateState != "completed" {
+		fmt.Printf("Update %s: %s\n", d.UpdateState, d.UpdateMessage)
+	}
+
+	if len(d.Findings) == 0 {
+		if d.Running >= d.Desired {
+			fmt.Println("Tidak ada task yang gagal.")
+		} else {
+			fmt.Println("Tidak ada task yang gagal; task mungkin masih dijadwalkan atau menarik image. Periksa lagi sebentar lagi.")
+		}
+		return
+	}
+
+	for i, f := range d.Findings {
+		if i == 0 {
+			fmt.Printf("\nAkar masalah yang paling mungkin: %s\n", f.Summary)
+		} else {
+			if i == 1 {
+				fmt.Println("\nMasalah lain:")
+			}
+			fmt.Printf("\n[%s] %s\n", f.Cause, f.Summary)
+		}
+		fmt.Printf("  Saran: %s\n", f.Fix)
+		printTasks(f.Tasks)
+	}
+
+	if d.Uninspected > 0 {
+		fmt.Printf("\nCatatan: %d container tidak dapat diperiksa (di node lain atau sudah dihapus); status OOM dan log healthcheck-nya tidak tersedia.\n", d.Uninspected)
+	}
+}
+
+func printTasks(tasks []swarm.TaskProblem) {
+	fmt.Printf("  Task (%d):\n", len(tasks))
+	for i, t := range tasks {
+		if i == maxTasks {
+			fmt.Printf("    ... %d task lainnya\n", len(tasks)-maxTasks)
+			return
+		}
+		name := t.Task
+		if t.Node != "" {
+			name += "@" + t.Node
+		}
+		var details []string
+		if t.ExitCode != 0 {
+			details = append(details, fmt.Sprintf("exit %d", t.ExitCode))
+		}
+		if t.OOMKilled {
+			details = append(details, "OOMKilled")
+		}
+		line := fmt.Sprintf("    %s %s (%s): %s", name, t.State, units.HumanDuration(time.Since(t.Time)), t.Error)
+		if len(details) > 0 {
+			line += " [" + strings.Join(details, ", ") + "]"
+		}
+		fmt.Println(line)
+		for _, h := range t.Health {
+			fmt.Printf("      healthcheck: %s\n", h)
+		}
+	}
+}
@@ -3,7 +3,13 @@ package compose
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/zakirkun/neon/internal/config/depgraph"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"github.com/zakirkun/neon/internal/secrets"
 	"gopkg.in/yaml.v3"
 )
 
@@ -12,17 +18,133 @@ type Config struct {
 	Services map[string]Service `yaml:"services"`
 	Networks map[string]Network `yaml:"networks"`
 	Volumes  map[string]Volume  `yaml:"volumes"`
+	Secrets  map[string]Secret  `yaml:"secrets"`
 }
 
 type Service struct {
-	Image       string            `yaml:"image"`
-	Build       *BuildConfig      `yaml:"build"`
-	Command     string            `yaml:"command"`
-	Environment map[string]string `yaml:"environment"`
-	Ports       []string          `yaml:"ports"`
-	Networks    []string          `yaml:"networks"`
-	Volumes     []string          `yaml:"volumes"`
-	Deploy      DeployConfig      `yaml:"deploy"`
+	Image       string       `yaml:"image"`
+	Build       *BuildConfig `yaml:"build"`
+	Command     Command      `yaml:"command"`
+	Environment Environment  `yaml:"environment"`
+	Ports       []string     `yaml:"ports" validate:"portmap"`
+	Networks    []string     `yaml:"networks"`
+	Volumes     []string     `yaml:"volumes"`
+	// Secrets adalah nama secret dari bagian secrets tingkat atas, di-mount
+	// ke /run/secrets/<nama>.
+	Secrets     []string     `yaml:"secrets"`
+	Healthcheck *Healthcheck `yaml:"healthcheck"`
+	Deploy      DeployConfig `yaml:"deploy"`
+	// DependsOn adalah service yang di-deploy lebih dulu.
+	DependsOn Dependencies `yaml:"depends_on"`
+
+	// Key compose yang valid tetapi belum didukung neon; hanya menghasilkan
+	// peringatan saat file dibaca.
+	Restart    interface{} `yaml:"restart,omitempty" validate:"unsupported=deploy.restart_policy"`
+	Labels     interface{} `yaml:"labels,omitempty" validate:"unsupported=deploy.labels"`
+	Entrypoint interface{} `yaml:"entrypoint,omitempty" validate:"unsupported"`
+	EnvFile    interface{} `yaml:"env_file,omitempty" validate:"unsupported=environment"`
+}
+
+// Command adalah command service. Bentuk string dan list sama-sama
+// diterima; bentuk string dipakai sebagai satu argumen.
+type Command []string
+
+func (c *Command) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*c = Command{node.Value}
+		return nil
+	case yaml.SequenceNode:
+		var args []string
+		if err := node.Decode(&args); err != nil {
+			return fmt.Errorf("harus berupa string atau list string")
+		}
+		*c = args
+		return nil
+	}
+	return fmt.Errorf("harus berupa string atau list string")
+}
+
+// Environment adalah environment service. Bentuk mapping (KEY: value) dan
+// list (- KEY=value) sama-sama diterima. Seperti docker compose, KEY tanpa
+// nilai diambil dari environment shell dan dilewati jika tidak ada.
+type Environment map[string]string
+
+func (e *Environment) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		env := make(Environment, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, val := node.Content[i], node.Content[i+1]
+			if val.Kind != yaml.ScalarNode {
+				return fmt.Errorf("baris %d: nilai %s harus berupa scalar", val.Line, key.Value)
+			}
+			if val.Tag == "!!null" {
+				if v, ok := os.LookupEnv(key.Value); ok {
+					env[key.Value] = v
+				}
+				continue
+			}
+			env[key.Value] = val.Value
+		}
+		*e = env
+		return nil
+	case yaml.SequenceNode:
+		env := make(Environment, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode || item.Value == "" {
+				return fmt.Errorf("baris %d: harus berupa KEY=value", item.Line)
+			}
+			key, val, ok := strings.Cut(item.Value, "=")
+			if !ok {
+				if v, ok := os.LookupEnv(key); ok {
+					env[key] = v
+				}
+				continue
+			}
+			env[key] = val
+		}
+		*e = env
+		return nil
+	}
+	return fmt.Errorf("harus berupa mapping atau list KEY=value")
+}
+
+// Dependencies adalah nama service di depends_on. Bentuk list
+// ([db, cache]) dan mapping ({db: {condition: service_healthy}}) sama-sama
+// diterima; dari bentuk mapping hanya nama service yang dipakai.
+type Dependencies []string
+
+func (d *Dependencies) UnmarshalYAML(node *yaml.Node) error {
+	var names []*yaml.Node
+	switch node.Kind {
+	case yaml.SequenceNode:
+		names = node.Content
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			names = append(names, node.Content[i])
+		}
+	default:
+		return fmt.Errorf("harus berupa list atau mapping nama service")
+	}
+
+	*d = make(Dependencies, 0, len(names))
+	for _, n := range names {
+		if n.Kind != yaml.ScalarNode || n.Value == "" {
+			return fmt.Errorf("baris %d: harus berupa nama service", n.Line)
+		}
+		*d = append(*d, n.Value)
+	}
+	return nil
+}
+
+type Healthcheck struct {
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval" validate:"duration"`
+	Timeout     string   `yaml:"timeout" validate:"duration"`
+	StartPeriod string   `yaml:"start_period" validate:"duration"`
+	Retries     int      `yaml:"retries"`
+	Disable     bool     `yaml:"disable"`
 }
 
 type BuildConfig struct {
@@ -36,22 +158,27 @@ type DeployConfig struct {
 	Resources    ResourceConfig `yaml:"resources"`
 	UpdateConfig UpdateConfig   `yaml:"update_config"`
 	Restart      RestartConfig  `yaml:"restart_policy"`
+	// Labels dipasang pada service swarm (bukan pada container).
+	Labels map[string]string `yaml:"labels"`
 }
 
 type ResourceConfig struct {
-	Limits struct {
-		CPUs   string `yaml:"cpus"`
-		Memory string `yaml:"memory"`
-	} `yaml:"limits"`
+	Limits       ResourceLimit `yaml:"limits"`
+	Reservations ResourceLimit `yaml:"reservations"`
+}
+
+type ResourceLimit struct {
+	CPUs   string `yaml:"cpus" validate:"cpus"`
+	Memory string `yaml:"memory" validate:"memory"`
 }
 
 type UpdateConfig struct {
 	Parallelism int    `yaml:"parallelism"`
-	Delay       string `yaml:"delay"`
+	Delay       string `yaml:"delay" validate:"duration"`
 }
 
 type RestartConfig struct {
-	Condition   string `yaml:"condition"`
+	Condition   string `yaml:"condition" validate:"oneof=none on-failure any"`
 	MaxAttempts int    `yaml:"max_attempts"`
 }
 
@@ -65,6 +192,98 @@ type Volume struct {
 	Name     string `yaml:"name"`
 }
 
+// Secret adalah definisi secret tingkat atas. Isinya diambil dari File,
+// variabel Environment, atau Source (URI vault://, file://, env://,
+// exec://, ekstensi neon). External memakai secret swarm yang sudah ada.
+type Secret struct {
+	File        string `yaml:"file"`
+	Environment string `yaml:"environment"`
+	Source      string `yaml:"source"`
+	External    bool   `yaml:"external"`
+	Name        string `yaml:"name" validate:"name"`
+}
+
+// URI mengembalikan sumber secret sebagai referensi secret, atau string
+// kosong untuk secret external.
+func (s Secret) URI() string {
+	switch {
+	case s.Source != "":
+		return s.Source
+	case s.File != "":
+		return "file://" + s.File
+	case s.Environment != "":
+		return "env://" + s.Environment
+	}
+	return ""
+}
+
+// Check memastikan secret memiliki tepat satu sumber.
+func (s Secret) Check() []validate.FieldError {
+	sources := 0
+	for _, v := range []string{s.File, s.Environment, s.Source} {
+		if v != "" {
+			sources++
+		}
+	}
+	if s.External {
+		if sources > 0 {
+			return []validate.FieldError{{Key: "external", Msg: "secret external tidak boleh memiliki file, environment, atau source"}}
+		}
+		return nil
+	}
+	if sources != 1 {
+		return []validate.FieldError{{Msg: "isi tepat satu dari file, environment, source, atau external: true"}}
+	}
+	if s.Source != "" && !secrets.IsRef(s.Source) {
+		return []validate.FieldError{{Key: "source", Msg: fmt.Sprintf("%q bukan referensi secret (vault://, file://, env://, exec://)", s.Source)}}
+	}
+	return nil
+}
+
+// Check memastikan setiap secret yang dipakai service didefinisikan dan
+// depends_on membentuk urutan yang valid.
+func (c Config) Check() []validate.FieldError {
+	var errs []validate.FieldError
+	for _, name := range sortedNames(c.Services) {
+		for _, secret := range c.Services[name].Secrets {
+			if _, ok := c.Secrets[secret]; !ok {
+				errs = append(errs, validate.FieldError{Key: "services", Msg: fmt.Sprintf("service %s memakai secret %q yang tidak didefinisikan di secrets", name, secret)})
+			}
+		}
+	}
+	if _, err := c.DeployOrder(); err != nil {
+		errs = append(errs, validate.FieldError{Key: "services", Msg: err.Error()})
+	}
+	return errs
+}
+
+// DeployOrder mengembalikan nama service dengan dependency (depends_on)
+// lebih dulu; service tanpa hubungan diurutkan berdasarkan nama.
+func (c Config) DeployOrder() ([]string, error) {
+	deps := make(map[string][]string, len(c.Services))
+	for name, svc := range c.Services {
+		deps[name] = svc.DependsOn
+	}
+	return depgraph.Sort(sortedNames(c.Services), deps)
+}
+
+func sortedNames(services map[string]Service) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check memastikan service memiliki image atau build.
+func (s Service) Check() []validate.FieldError {
+	if s.Image == "" && s.Build == nil {
+		return []validate.FieldError{{Msg: "image atau build wajib diisi"}}
+	}
+	return nil
+}
+
 func LoadFromFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -72,8 +291,18 @@ func LoadFromFile(path string) (*Config, error) {
 	}
 
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("gagal parse compose file: %v", err)
+	if err := validate.Decode(path, data, &config); err != nil {
+		return nil, err
+	}
+
+	// Seperti docker compose, file: relatif terhadap direktori file compose,
+	// bukan direktori kerja
+	dir := filepath.Dir(path)
+	for name, secret := range config.Secrets {
+		if secret.File != "" && !filepath.IsAbs(secret.File) {
+			secret.File = filepath.Join(dir, secret.File)
+			config.Secrets[name] = secret
+		}
 	}
 
 	return &config, nil
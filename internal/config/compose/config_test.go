@@ -0,0 +1,107 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromFileResolvesSecretFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "stack")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "docker-compose.yml")
+	content := `services:
+  web:
+    image: nginx
+    secrets: [db, ca, token]
+secrets:
+  db:
+    file: ./secrets/db.txt
+  ca:
+    file: /etc/ssl/ca.pem
+  token:
+    environment: API_TOKEN
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cfg.Secrets["db"].File, filepath.Join(dir, "secrets", "db.txt"); got != want {
+		t.Errorf("file db = %s, ingin %s", got, want)
+	}
+	if got := cfg.Secrets["ca"].File; got != "/etc/ssl/ca.pem" {
+		t.Errorf("file ca = %s, ingin /etc/ssl/ca.pem", got)
+	}
+	if got := cfg.Secrets["token"].URI(); got != "env://API_TOKEN" {
+		t.Errorf("URI token = %s, ingin env://API_TOKEN", got)
+	}
+}
+
+func TestLoadFromFileComposeSyntax(t *testing.T) {
+	t.Setenv("FROM_SHELL", "shell")
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	content := `services:
+  web:
+    image: nginx
+    restart: unless-stopped
+    labels:
+      team: web
+    entrypoint: ["/docker-entrypoint.sh"]
+    env_file: .env
+    command: ["nginx", "-g", "daemon off;"]
+    environment:
+      - MODE=production
+      - EMPTY=
+      - FROM_SHELL
+      - NOT_SET
+  worker:
+    image: worker
+    command: run
+    environment:
+      PORT: 8080
+      DEBUG: true
+      FROM_SHELL:
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	web, worker := cfg.Services["web"], cfg.Services["worker"]
+	if got := strings.Join(web.Command, "|"); got != "nginx|-g|daemon off;" {
+		t.Errorf("command web = %q", got)
+	}
+	if got := strings.Join(worker.Command, "|"); got != "run" {
+		t.Errorf("command worker = %q", got)
+	}
+
+	wantWeb := Environment{"MODE": "production", "EMPTY": "", "FROM_SHELL": "shell"}
+	wantWorker := Environment{"PORT": "8080", "DEBUG": "true", "FROM_SHELL": "shell"}
+	for name, tt := range map[string]struct{ got, want Environment }{
+		"web":    {web.Environment, wantWeb},
+		"worker": {worker.Environment, wantWorker},
+	} {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("environment %s = %v, ingin %v", name, tt.got, tt.want)
+			continue
+		}
+		for k, v := range tt.want {
+			if got, ok := tt.got[k]; !ok || got != v {
+				t.Errorf("environment %s = %v, ingin %v", name, tt.got, tt.want)
+				break
+			}
+		}
+	}
+}
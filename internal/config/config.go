@@ -1,11 +1,10 @@
 package config
 
 import (
-	"flag"
 	"os"
 	"path/filepath"
 
-	"gopkg.in/yaml.v3"
+	"github.com/zakirkun/neon/internal/config/validate"
 )
 
 var (
@@ -14,12 +13,28 @@ var (
 )
 
 func init() {
+	configPath = DefaultPath()
+}
+
+// DefaultPath mengembalikan lokasi default file konfigurasi,
+// ~/.neon/config.yaml.
+func DefaultPath() string {
 	homeDir, _ := os.UserHomeDir()
-	defaultConfig := filepath.Join(homeDir, ".neon", "config.yaml")
-	flag.StringVar(&configPath, "config", defaultConfig, "Path to config file")
+	return filepath.Join(homeDir, ".neon", "config.yaml")
+}
+
+// SetPath mengganti lokasi file konfigurasi (flag --config).
+func SetPath(path string) {
+	configPath = path
 }
 
 type Config struct {
+	GitHub struct {
+		// Token adalah personal access token untuk clone repository
+		// github.com lewat HTTPS.
+		Token string `yaml:"token"`
+	} `yaml:"github"`
+
 	Docker struct {
 		Registry string `yaml:"registry"`
 		Username string `yaml:"username"`
@@ -27,25 +42,79 @@ type Config struct {
 	} `yaml:"docker"`
 
 	Swarm struct {
-		ManagerNode string `yaml:"manager_node"`
 		NetworkName string `yaml:"network_name"`
+		// Stack adalah nama stack default untuk deploy monorepo.
+		Stack string `yaml:"stack" validate:"name"`
 	} `yaml:"swarm"`
 
 	Deploy struct {
 		Replicas      int    `yaml:"replicas"`
-		UpdateDelay   string `yaml:"update_delay"`
-		RollbackDelay string `yaml:"rollback_delay"`
-		FailureAction string `yaml:"failure_action"`
+		UpdateDelay   string `yaml:"update_delay" validate:"duration"`
+		RollbackDelay string `yaml:"rollback_delay" validate:"duration"`
+		FailureAction string `yaml:"failure_action" validate:"oneof=pause continue rollback"`
+		// HistoryLimit adalah jumlah revisi yang disimpan per service untuk
+		// `neon deploy rollback`; default 10.
+		HistoryLimit int `yaml:"history_limit"`
 	} `yaml:"deploy"`
+
+	Cache struct {
+		Dir      string `yaml:"dir"`
+		MaxSize  string `yaml:"max_size" validate:"size"`
+		Disabled bool   `yaml:"disabled"`
+	} `yaml:"cache"`
+
+	Policy struct {
+		// File adalah file policy deploy; default ~/.neon/policy.yaml.
+		File string `yaml:"file"`
+	} `yaml:"policy"`
+
+	Secrets struct {
+		Vault VaultConfig `yaml:"vault"`
+	} `yaml:"secrets"`
+
+	// Contexts adalah cluster tujuan yang dipilih dengan --context,
+	// $NEON_CONTEXT, atau current_context.
+	Contexts       map[string]Context `yaml:"contexts"`
+	CurrentContext string             `yaml:"current_context"`
+}
+
+// VaultConfig adalah koneksi ke HashiCorp Vault untuk referensi vault://.
+// Nilai kosong diambil dari variabel VAULT_*.
+type VaultConfig struct {
+	Address   string `yaml:"address"`
+	Token     string `yaml:"token"`
+	Namespace string `yaml:"namespace"`
+	AppRole   struct {
+		RoleID   string `yaml:"role_id"`
+		SecretID string `yaml:"secret_id"`
+		// Mount adalah path auth AppRole; default "approle".
+		Mount string `yaml:"mount"`
+	} `yaml:"approle"`
+}
+
+// Path mengembalikan lokasi file konfigurasi global (flag -config).
+func Path() string {
+	return configPath
 }
 
 func Load() error {
-	data, err := os.ReadFile(configPath)
-	if err != nil {
+	return LoadFile(configPath)
+}
+
+// LoadFile memuat konfigurasi dari path tertentu, menggantikan konfigurasi
+// global yang sudah dimuat sebelumnya. Pengaturan registry dan stack dari
+// context aktif diterapkan di atasnya.
+func LoadFile(path string) error {
+	var loaded Config
+	if err := validate.DecodeFile(path, &loaded); err != nil {
 		return err
 	}
 
-	return yaml.Unmarshal(data, &cfg)
+	cfg = loaded
+	if _, ctx := ActiveContext(); ctx != nil {
+		cfg.ApplyContext(ctx)
+	}
+	return nil
 }
 
 func Get() *Config {
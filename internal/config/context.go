@@ -0,0 +1,240 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Context adalah satu cluster tujuan: endpoint Docker, material TLS,
+// registry, dan pengaturan stack default.
+type Context struct {
+	Description string `yaml:"description,omitempty"`
+	// Environment adalah environment cluster (misalnya "production") yang
+	// dipakai policy jika deploy tidak menyebut environment sendiri.
+	Environment string `yaml:"environment,omitempty"`
+	// Host adalah endpoint Docker: unix:///var/run/docker.sock,
+	// tcp://host:2376, atau ssh://user@host[:port].
+	Host     string          `yaml:"host" validate:"required,dockerhost"`
+	TLS      ContextTLS      `yaml:"tls,omitempty"`
+	Registry ContextRegistry `yaml:"registry,omitempty"`
+	Stack    ContextStack    `yaml:"stack,omitempty"`
+}
+
+// ContextTLS berisi path sertifikat untuk endpoint tcp. TLS dipakai jika
+// salah satu field diisi.
+type ContextTLS struct {
+	CA         string `yaml:"ca,omitempty"`
+	Cert       string `yaml:"cert,omitempty"`
+	Key        string `yaml:"key,omitempty"`
+	SkipVerify bool   `yaml:"skip_verify,omitempty"`
+}
+
+// Enabled melaporkan apakah TLS dikonfigurasi.
+func (t ContextTLS) Enabled() bool {
+	return t.CA != "" || t.Cert != "" || t.Key != "" || t.SkipVerify
+}
+
+type ContextRegistry struct {
+	URL      string `yaml:"url,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+}
+
+// ContextStack adalah nilai default deploy untuk cluster ini.
+type ContextStack struct {
+	Name     string `yaml:"name,omitempty" validate:"name"`
+	Replicas int    `yaml:"replicas,omitempty"`
+}
+
+// contextOverride diisi dari flag --context.
+var contextOverride string
+
+// SetContext memilih context untuk proses ini, menggantikan $NEON_CONTEXT
+// dan current_context. Dipanggil sebelum Load.
+func SetContext(name string) {
+	contextOverride = name
+}
+
+// ContextName mengembalikan nama context aktif: flag --context, lalu
+// $NEON_CONTEXT, lalu current_context di konfigurasi. Kosong berarti
+// memakai DOCKER_HOST/socket default.
+func ContextName() string {
+	if contextOverride != "" {
+		return contextOverride
+	}
+	if env := os.Getenv("NEON_CONTEXT"); env != "" {
+		return env
+	}
+	return cfg.CurrentContext
+}
+
+// ActiveContext mengembalikan context aktif, atau nil jika tidak ada context
+// neon dengan nama tersebut.
+func ActiveContext() (string, *Context) {
+	name := ContextName()
+	return name, cfg.LookupContext(name)
+}
+
+// LookupContext mengembalikan context bernama name, atau nil.
+func (c *Config) LookupContext(name string) *Context {
+	if name == "" {
+		return nil
+	}
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		return nil
+	}
+	return &ctx
+}
+
+// ContextNames mengembalikan nama semua context secara berurutan.
+func (c *Config) ContextNames() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyContext menimpa registry dan nilai default deploy dengan pengaturan
+// dari ctx.
+func (c *Config) ApplyContext(ctx *Context) {
+	if ctx.Registry.URL != "" {
+		c.Docker.Registry = ctx.Registry.URL
+		c.Docker.Username = ctx.Registry.Username
+		c.Docker.Password = ctx.Registry.Password
+	}
+	if ctx.Stack.Name != "" {
+		c.Swarm.Stack = ctx.Stack.Name
+	}
+	if ctx.Stack.Replicas > 0 {
+		c.Deploy.Replicas = ctx.Stack.Replicas
+	}
+}
+
+// SaveContext menambahkan atau mengganti context di file konfigurasi.
+// Bagian lain file (termasuk komentar) dipertahankan.
+func SaveContext(name string, ctx *Context) error {
+	var value yaml.Node
+	if err := value.Encode(ctx); err != nil {
+		return err
+	}
+	return updateFile(func(root *yaml.Node) {
+		contexts := mappingEntry(root, "contexts", true)
+		if contexts.Kind != yaml.MappingNode {
+			*contexts = yaml.Node{Kind: yaml.MappingNode}
+		}
+		setMappingEntry(contexts, name, &value)
+	})
+}
+
+// RemoveContext menghapus context dari file konfigurasi. current_context
+// dikosongkan jika menunjuk context tersebut.
+func RemoveContext(name string) error {
+	if cfg.LookupContext(name) == nil {
+		return fmt.Errorf("context %q tidak ditemukan", name)
+	}
+	return updateFile(func(root *yaml.Node) {
+		if contexts := mappingEntry(root, "contexts", false); contexts != nil {
+			deleteMappingEntry(contexts, name)
+			if len(contexts.Content) == 0 {
+				deleteMappingEntry(root, "contexts")
+			}
+		}
+		if cfg.CurrentContext == name {
+			deleteMappingEntry(root, "current_context")
+		}
+	})
+}
+
+// UseContext menyimpan name sebagai current_context. Nama kosong kembali
+// ke DOCKER_HOST/socket default.
+func UseContext(name string) error {
+	if name != "" && cfg.LookupContext(name) == nil {
+		return fmt.Errorf("context %q tidak ditemukan", name)
+	}
+	return updateFile(func(root *yaml.Node) {
+		if name == "" {
+			deleteMappingEntry(root, "current_context")
+			return
+		}
+		setMappingEntry(root, "current_context", &yaml.Node{Kind: yaml.ScalarNode, Value: name})
+	})
+}
+
+// updateFile membaca file konfigurasi sebagai node YAML, menerapkan fn, lalu
+// menulisnya kembali dan memuat ulang konfigurasi.
+func updateFile(fn func(root *yaml.Node)) error {
+	doc := yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("%s: %v", configPath, err)
+		}
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+
+	fn(doc.Content[0])
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o700); err != nil {
+		return err
+	}
+	// Konfigurasi dapat berisi password registry
+	if err := os.WriteFile(configPath, out, 0o600); err != nil {
+		return err
+	}
+	return LoadFile(configPath)
+}
+
+// mappingEntry mengembalikan value untuk key di mapping; jika create dan key
+// belum ada, entry kosong ditambahkan.
+func mappingEntry(m *yaml.Node, key string, create bool) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	if !create {
+		return nil
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
+
+func setMappingEntry(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
+
+func deleteMappingEntry(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
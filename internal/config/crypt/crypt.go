@@ -0,0 +1,284 @@
+// Package crypt mengenkripsi nilai di file konfigurasi neon dengan format
+// mirip SOPS. Nilai terenkripsi ditulis sebagai
+//
+//	ENC[AES256_GCM,data:<base64>,iv:<base64>,tag:<base64>,kid:<id>]
+//
+// dan boleh muncul utuh sebagai nilai (password: ENC[...]) atau sebagai
+// bagian string (DB_PASSWORD=ENC[...]). Kunci AES-256 dibaca dari
+// $NEON_SECRET_KEY, file $NEON_SECRET_KEY_FILE, atau ~/.neon/secret.key.
+// Kunci pertama dipakai untuk enkripsi; semua kunci dicoba untuk dekripsi
+// berdasarkan kid.
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// KeyEnv berisi satu atau lebih kunci base64, dipisah koma.
+	KeyEnv = "NEON_SECRET_KEY"
+	// KeyFileEnv menunjuk file kunci selain lokasi default.
+	KeyFileEnv = "NEON_SECRET_KEY_FILE"
+
+	cipherName = "AES256_GCM"
+	keySize    = 32
+)
+
+var encPattern = regexp.MustCompile(`ENC\[[^\]]*\]`)
+
+// IsEncrypted melaporkan apakah s berisi nilai ENC[...].
+func IsEncrypted(s string) bool {
+	return strings.Contains(s, "ENC[")
+}
+
+// Key adalah kunci AES-256. ID diturunkan dari isi kunci dan disimpan di
+// setiap nilai terenkripsi.
+type Key struct {
+	ID   string
+	data []byte
+}
+
+// GenerateKey membuat kunci acak baru.
+func GenerateKey() (Key, error) {
+	data := make([]byte, keySize)
+	if _, err := rand.Read(data); err != nil {
+		return Key{}, err
+	}
+	return newKey(data), nil
+}
+
+// ParseKey membaca kunci dalam format base64.
+func ParseKey(s string) (Key, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(data) != keySize {
+		return Key{}, fmt.Errorf("kunci harus berupa %d byte dalam base64", keySize)
+	}
+	return newKey(data), nil
+}
+
+func newKey(data []byte) Key {
+	sum := sha256.Sum256(data)
+	return Key{ID: hex.EncodeToString(sum[:4]), data: data}
+}
+
+// String mengembalikan kunci dalam base64, format yang dibaca ParseKey.
+func (k Key) String() string {
+	return base64.StdEncoding.EncodeToString(k.data)
+}
+
+// Keyring adalah daftar kunci; kunci pertama adalah kunci utama.
+type Keyring struct {
+	Keys []Key
+	// Source adalah asal kunci, untuk pesan error dan rekey.
+	Source string
+}
+
+// DefaultKeyFile mengembalikan lokasi file kunci: $NEON_SECRET_KEY_FILE
+// atau ~/.neon/secret.key.
+func DefaultKeyFile() string {
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".neon", "secret.key")
+	}
+	return filepath.Join(homeDir, ".neon", "secret.key")
+}
+
+// LoadKeyring membaca kunci dari $NEON_SECRET_KEY, atau dari file kunci
+// jika variabel itu kosong.
+func LoadKeyring() (*Keyring, error) {
+	if env := os.Getenv(KeyEnv); env != "" {
+		kr := &Keyring{Source: "$" + KeyEnv}
+		for _, s := range strings.Split(env, ",") {
+			key, err := ParseKey(s)
+			if err != nil {
+				return nil, fmt.Errorf("$%s: %v", KeyEnv, err)
+			}
+			kr.Keys = append(kr.Keys, key)
+		}
+		return kr, nil
+	}
+	return ReadKeyFile(DefaultKeyFile())
+}
+
+// ReadKeyFile membaca file kunci: satu kunci base64 per baris, baris
+// kosong dan komentar (#) diabaikan.
+func ReadKeyFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("kunci enkripsi tidak ditemukan (set $%s atau buat %s dengan `neon rekey --generate`)", KeyEnv, path)
+		}
+		return nil, err
+	}
+
+	kr := &Keyring{Source: path}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := ParseKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, i+1, err)
+		}
+		kr.Keys = append(kr.Keys, key)
+	}
+	if len(kr.Keys) == 0 {
+		return nil, fmt.Errorf("%s tidak berisi kunci", path)
+	}
+	return kr, nil
+}
+
+// WriteKeyFile menulis keys ke path dengan mode 0600.
+func WriteKeyFile(path string, keys []Key) error {
+	var b strings.Builder
+	b.WriteString("# Kunci enkripsi neon. Baris pertama dipakai untuk enkripsi.\n")
+	for _, k := range keys {
+		b.WriteString(k.String())
+		b.WriteString("\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultKeyring *Keyring
+	defaultErr     error
+)
+
+// Default mengembalikan keyring dari LoadKeyring. Kunci hanya dibaca sekali
+// per proses dan hanya jika ada nilai yang perlu didekripsi.
+func Default() (*Keyring, error) {
+	defaultOnce.Do(func() {
+		defaultKeyring, defaultErr = LoadKeyring()
+	})
+	return defaultKeyring, defaultErr
+}
+
+// Encrypt mengenkripsi plaintext dengan kunci utama.
+func (kr *Keyring) Encrypt(plaintext string) (string, error) {
+	key := kr.Keys[0]
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), nil)
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[%s,data:%s,iv:%s,tag:%s,kid:%s]", cipherName, enc(data), enc(iv), enc(tag), key.ID), nil
+}
+
+// Decrypt mengganti setiap ENC[...] di s dengan plaintext-nya.
+func (kr *Keyring) Decrypt(s string) (string, error) {
+	var firstErr error
+	out := encPattern.ReplaceAllStringFunc(s, func(value string) string {
+		plain, err := kr.decryptValue(value)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return plain
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+// Reencrypt mendekripsi setiap ENC[...] di s lalu mengenkripsinya ulang
+// dengan kunci utama.
+func (kr *Keyring) Reencrypt(s string) (string, error) {
+	var firstErr error
+	out := encPattern.ReplaceAllStringFunc(s, func(value string) string {
+		plain, err := kr.decryptValue(value)
+		if err == nil {
+			value, err = kr.Encrypt(plain)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+func (kr *Keyring) decryptValue(value string) (string, error) {
+	fields := make(map[string]string)
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "ENC["), "]"), ",")
+	if len(parts) == 0 || parts[0] != cipherName {
+		return "", fmt.Errorf("nilai terenkripsi tidak didukung (hanya %s)", cipherName)
+	}
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, ":")
+		fields[k] = v
+	}
+
+	dec := base64.StdEncoding.DecodeString
+	data, err1 := dec(fields["data"])
+	iv, err2 := dec(fields["iv"])
+	tag, err3 := dec(fields["tag"])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return "", fmt.Errorf("nilai terenkripsi rusak")
+	}
+
+	sealed := append(append([]byte(nil), data...), tag...)
+	kid := fields["kid"]
+	for _, key := range kr.Keys {
+		if kid != "" && key.ID != kid {
+			continue
+		}
+		gcm, err := newGCM(key)
+		if err != nil {
+			return "", err
+		}
+		if len(iv) != gcm.NonceSize() {
+			return "", fmt.Errorf("nilai terenkripsi rusak")
+		}
+		plain, err := gcm.Open(nil, iv, sealed, nil)
+		if err != nil {
+			if kid == "" {
+				continue
+			}
+			return "", fmt.Errorf("gagal mendekripsi dengan kunci %s: data rusak atau diubah", key.ID)
+		}
+		return string(plain), nil
+	}
+	if kid == "" {
+		return "", fmt.Errorf("tidak ada kunci di %s yang dapat mendekripsi nilai", kr.Source)
+	}
+	return "", fmt.Errorf("kunci %s tidak ada di %s", kid, kr.Source)
+}
+
+func newGCM(key Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.data)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
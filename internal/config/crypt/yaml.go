@@ -0,0 +1,105 @@
+package crypt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPattern mencocokkan nama key yang nilainya dienkripsi oleh
+// `neon encrypt`.
+var DefaultPattern = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PRIVATE_?KEY|ACCESS_?KEY|CREDENTIALS?)`)
+
+// Scalars memanggil fn untuk setiap nilai scalar di bawah node. key adalah
+// key mapping dari nilai tersebut; untuk item list, key dari list-nya.
+func Scalars(node *yaml.Node, fn func(key string, n *yaml.Node) error) error {
+	return scalars(node, "", fn)
+}
+
+func scalars(node *yaml.Node, key string, fn func(string, *yaml.Node) error) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := scalars(item, key, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := scalars(node.Content[i+1], node.Content[i].Value, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(key, node)
+	}
+	return nil
+}
+
+// EncryptNode mengenkripsi nilai yang key-nya cocok dengan pattern, serta
+// item KEY=VALUE (environment) yang KEY-nya cocok. Key berakhiran _FILE
+// berisi path, bukan secret, dan dilewati. Mengembalikan jumlah nilai yang
+// dienkripsi.
+func EncryptNode(node *yaml.Node, kr *Keyring, pattern *regexp.Regexp) (int, error) {
+	count := 0
+	err := Scalars(node, func(key string, n *yaml.Node) error {
+		if n.Tag == "!!null" || n.Value == "" || IsEncrypted(n.Value) {
+			return nil
+		}
+
+		target, prefix := "", ""
+		if k, v, ok := strings.Cut(n.Value, "="); ok && isSecretKey(k, pattern) && v != "" {
+			target, prefix = v, k+"="
+		} else if isSecretKey(key, pattern) {
+			target = n.Value
+		} else {
+			return nil
+		}
+
+		enc, err := kr.Encrypt(target)
+		if err != nil {
+			return err
+		}
+		n.Value, n.Tag, n.Style = prefix+enc, "!!str", 0
+		count++
+		return nil
+	})
+	return count, err
+}
+
+func isSecretKey(key string, pattern *regexp.Regexp) bool {
+	if key == "" || strings.ContainsAny(key, " \t") || strings.HasSuffix(strings.ToUpper(key), "_FILE") {
+		return false
+	}
+	return pattern.MatchString(key)
+}
+
+// DecryptNode mengganti semua nilai ENC[...] di bawah node dengan
+// plaintext-nya.
+func DecryptNode(node *yaml.Node, kr *Keyring) (int, error) {
+	return transformNode(node, kr.Decrypt)
+}
+
+// ReencryptNode mengenkripsi ulang semua nilai ENC[...] dengan kunci utama.
+func ReencryptNode(node *yaml.Node, kr *Keyring) (int, error) {
+	return transformNode(node, kr.Reencrypt)
+}
+
+func transformNode(node *yaml.Node, fn func(string) (string, error)) (int, error) {
+	count := 0
+	err := Scalars(node, func(_ string, n *yaml.Node) error {
+		if !IsEncrypted(n.Value) {
+			return nil
+		}
+		value, err := fn(n.Value)
+		if err != nil {
+			return fmt.Errorf("baris %d: %v", n.Line, err)
+		}
+		n.Value = value
+		count++
+		return nil
+	})
+	return count, err
+}
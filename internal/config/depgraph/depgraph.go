@@ -0,0 +1,54 @@
+// Package depgraph mengurutkan service berdasarkan depends_on.
+package depgraph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sort mengembalikan names dengan setiap dependency sebelum service yang
+// membutuhkannya. Urutan names dipertahankan sejauh dependency
+// mengizinkan. Dependency yang tidak ada di names atau dependency
+// melingkar dikembalikan sebagai error.
+func Sort(names []string, deps map[string][]string) ([]string, error) {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency melingkar: %s -> %s", strings.Join(path, " -> "), name)
+		}
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if !known[dep] {
+				return fmt.Errorf("service %s bergantung pada %q yang tidak didefinisikan", name, dep)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
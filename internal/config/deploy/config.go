@@ -1,45 +1,150 @@
 package deploy
 
+import (
+	"fmt"
+	"os"
+
+	"github.com/zakirkun/neon/internal/config/depgraph"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"github.com/zakirkun/neon/internal/secrets"
+)
+
 type Config struct {
 	Services []ServiceConfig `yaml:"services"`
 }
 
 type ServiceConfig struct {
-	Name        string       `yaml:"name"`
-	Image       string       `yaml:"image"`
-	Replicas    uint64       `yaml:"replicas"`
-	Ports       []PortConfig `yaml:"ports"`
-	Environment []string     `yaml:"environment"`
-	Networks    []string     `yaml:"networks"`
-	Deploy      DeployConfig `yaml:"deploy"`
+	Name        string         `yaml:"name" validate:"required,name"`
+	Image       string         `yaml:"image,omitempty" validate:"required"`
+	Replicas    uint64         `yaml:"replicas,omitempty"`
+	Ports       []PortConfig   `yaml:"ports,omitempty"`
+	Environment []string       `yaml:"environment,omitempty" validate:"env"`
+	Networks    []string       `yaml:"networks,omitempty"`
+	Secrets     []SecretConfig `yaml:"secrets,omitempty"`
+	// Labels dipasang pada service swarm.
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Healthcheck *Healthcheck      `yaml:"healthcheck,omitempty"`
+	Deploy      DeployConfig      `yaml:"deploy,omitempty"`
+	// DependsOn adalah service yang di-deploy lebih dulu.
+	DependsOn []string `yaml:"depends_on,omitempty"`
+}
+
+// Healthcheck mengikuti format healthcheck compose. Test berupa
+// ["CMD", ...], ["CMD-SHELL", "..."], atau ["NONE"].
+type Healthcheck struct {
+	Test        []string `yaml:"test,omitempty"`
+	Interval    string   `yaml:"interval,omitempty" validate:"duration"`
+	Timeout     string   `yaml:"timeout,omitempty" validate:"duration"`
+	StartPeriod string   `yaml:"start_period,omitempty" validate:"duration"`
+	Retries     int      `yaml:"retries,omitempty"`
+}
+
+// SecretConfig adalah Docker secret yang di-mount ke
+// /run/secrets/<target>. Source berupa URI (vault://, file://, env://,
+// exec://); secret swarm dibuat dari isinya. Tanpa Source, secret swarm
+// bernama Name harus sudah ada.
+type SecretConfig struct {
+	Name   string `yaml:"name" validate:"required,name"`
+	Source string `yaml:"source,omitempty"`
+	// Target adalah nama file di /run/secrets; default Name.
+	Target string `yaml:"target,omitempty"`
+}
+
+// Check memastikan Source adalah referensi secret yang dikenal.
+func (s SecretConfig) Check() []validate.FieldError {
+	if s.Source != "" && !secrets.IsRef(s.Source) {
+		return []validate.FieldError{{Key: "source", Msg: fmt.Sprintf("%q bukan referensi secret (vault://, file://, env://, exec://)", s.Source)}}
+	}
+	return nil
 }
 
 type PortConfig struct {
-	Target    uint32 `yaml:"target"`
-	Published uint32 `yaml:"published"`
+	Target    uint32 `yaml:"target" validate:"required,port"`
+	Published uint32 `yaml:"published,omitempty" validate:"port"`
 }
 
 type DeployConfig struct {
-	UpdateConfig  UpdateConfig  `yaml:"update_config"`
-	RestartPolicy RestartPolicy `yaml:"restart_policy"`
-	Resources     Resources     `yaml:"resources"`
+	UpdateConfig  UpdateConfig  `yaml:"update_config,omitempty"`
+	RestartPolicy RestartPolicy `yaml:"restart_policy,omitempty"`
+	Resources     Resources     `yaml:"resources,omitempty"`
 }
 
 type UpdateConfig struct {
-	Parallelism uint64 `yaml:"parallelism"`
-	Delay       string `yaml:"delay"`
+	Parallelism uint64 `yaml:"parallelism,omitempty"`
+	Delay       string `yaml:"delay,omitempty" validate:"duration"`
 }
 
 type RestartPolicy struct {
-	Condition   string `yaml:"condition"`
-	MaxAttempts uint64 `yaml:"max_attempts"`
+	Condition   string `yaml:"condition,omitempty" validate:"oneof=none on-failure any"`
+	MaxAttempts uint64 `yaml:"max_attempts,omitempty"`
 }
 
 type Resources struct {
-	Limits ResourceLimit `yaml:"limits"`
+	Limits       ResourceLimit `yaml:"limits,omitempty"`
+	Reservations ResourceLimit `yaml:"reservations,omitempty"`
 }
 
 type ResourceLimit struct {
-	CPUs   string `yaml:"cpus"`
-	Memory string `yaml:"memory"`
+	CPUs   string `yaml:"cpus,omitempty" validate:"cpus"`
+	Memory string `yaml:"memory,omitempty" validate:"memory"`
+}
+
+// Check memastikan nama service unik.
+func (c Config) Check() []validate.FieldError {
+	var errs []validate.FieldError
+	seen := make(map[string]bool)
+	for _, svc := range c.Services {
+		if seen[svc.Name] {
+			errs = append(errs, validate.FieldError{Key: "services", Msg: fmt.Sprintf("service %q didefinisikan lebih dari sekali", svc.Name)})
+		}
+		seen[svc.Name] = true
+	}
+	if _, err := c.Ordered(); err != nil {
+		errs = append(errs, validate.FieldError{Key: "services", Msg: err.Error()})
+	}
+	return errs
 }
+
+// Ordered mengembalikan service dengan dependency (depends_on) lebih dulu;
+// selebihnya mengikuti urutan di file.
+func (c Config) Ordered() ([]ServiceConfig, error) {
+	names := make([]string, len(c.Services))
+	deps := make(map[string][]string, len(c.Services))
+	byName := make(map[string]ServiceConfig, len(c.Services))
+	for i, svc := range c.Services {
+		names[i] = svc.Name
+		deps[svc.Name] = svc.DependsOn
+		byName[svc.Name] = svc
+	}
+
+	order, err := depgraph.Sort(names, deps)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]ServiceConfig, len(order))
+	for i, name := range order {
+		services[i] = byName[name]
+	}
+	return services, nil
+}
+
+func LoadFromFile(path string) (*Config, error) {
+	return loadFile(path, validate.Decode)
+}
+
+func loadFile(path string, decode decodeFunc) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca file konfigurasi: %v", err)
+	}
+
+	var config Config
+	if err := decode(path, data, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// decodeFunc adalah validate.Decode atau validate.DecodeRaw.
+type decodeFunc func(file string, data []byte, out interface{}) error
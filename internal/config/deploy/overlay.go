@@ -0,0 +1,240 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zakirkun/neon/internal/config/validate"
+)
+
+// Overlay adalah patch per environment untuk Config dasar. Service dicocokkan
+// berdasarkan nama; field yang tidak diisi tidak mengubah nilai dasar.
+type Overlay struct {
+	Services []ServiceOverlay `yaml:"services"`
+}
+
+type ServiceOverlay struct {
+	Name string `yaml:"name" validate:"required"`
+	// Image menggantikan seluruh referensi image, Tag hanya mengganti tag-nya.
+	Image    *string `yaml:"image"`
+	Tag      *string `yaml:"tag"`
+	Replicas *uint64 `yaml:"replicas"`
+	// Environment digabung berdasarkan key; EnvironmentRemove menghapus key.
+	Environment       []string `yaml:"environment" validate:"env"`
+	EnvironmentRemove []string `yaml:"environment_remove"`
+	// Ports digabung berdasarkan target port.
+	Ports []PortConfig `yaml:"ports"`
+	// Networks ditambahkan ke daftar network dasar.
+	Networks []string      `yaml:"networks"`
+	Deploy   DeployOverlay `yaml:"deploy"`
+}
+
+type DeployOverlay struct {
+	UpdateConfig struct {
+		Parallelism *uint64 `yaml:"parallelism"`
+		Delay       string  `yaml:"delay" validate:"duration"`
+	} `yaml:"update_config"`
+	RestartPolicy struct {
+		Condition   string  `yaml:"condition" validate:"oneof=none on-failure any"`
+		MaxAttempts *uint64 `yaml:"max_attempts"`
+	} `yaml:"restart_policy"`
+	Resources Resources `yaml:"resources"`
+}
+
+// OverlayPath mengembalikan lokasi overlay konvensional untuk env, yaitu
+// <nama>.<env>.yaml di samping file dasar (deploy.yaml -> deploy.staging.yaml).
+func OverlayPath(base, env string) string {
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "." + env + ext
+}
+
+func LoadOverlay(path string) (*Overlay, error) {
+	return loadOverlay(path, validate.Decode)
+}
+
+func loadOverlay(path string, decode decodeFunc) (*Overlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var overlay Overlay
+	if err := decode(path, data, &overlay); err != nil {
+		return nil, err
+	}
+
+	return &overlay, nil
+}
+
+// LoadWithOverlay memuat file dasar lalu menerapkan overlay di atasnya.
+// overlayPath kosong berarti tanpa overlay.
+func LoadWithOverlay(basePath, overlayPath string) (*Config, error) {
+	return loadWithOverlay(basePath, overlayPath, validate.Decode)
+}
+
+// LoadRawWithOverlay sama dengan LoadWithOverlay tetapi nilai ENC[...]
+// dibiarkan terenkripsi, sehingga hasilnya aman ditampilkan dan kunci
+// secret tidak dibutuhkan.
+func LoadRawWithOverlay(basePath, overlayPath string) (*Config, error) {
+	return loadWithOverlay(basePath, overlayPath, validate.DecodeRaw)
+}
+
+func loadWithOverlay(basePath, overlayPath string, decode decodeFunc) (*Config, error) {
+	config, err := loadFile(basePath, decode)
+	if err != nil {
+		return nil, err
+	}
+	if overlayPath == "" {
+		return config, nil
+	}
+
+	overlay, err := loadOverlay(overlayPath, decode)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Apply(overlay); err != nil {
+		return nil, fmt.Errorf("%s: %v", overlayPath, err)
+	}
+
+	return config, nil
+}
+
+// Apply menerapkan overlay ke c. Service di overlay harus ada di c.
+func (c *Config) Apply(overlay *Overlay) error {
+	for _, patch := range overlay.Services {
+		svc := c.service(patch.Name)
+		if svc == nil {
+			return fmt.Errorf("service %q tidak ada di konfigurasi dasar", patch.Name)
+		}
+		svc.apply(&patch)
+	}
+	return nil
+}
+
+func (c *Config) service(name string) *ServiceConfig {
+	for i := range c.Services {
+		if c.Services[i].Name == name {
+			return &c.Services[i]
+		}
+	}
+	return nil
+}
+
+func (s *ServiceConfig) apply(patch *ServiceOverlay) {
+	if patch.Image != nil {
+		s.Image = *patch.Image
+	}
+	if patch.Tag != nil {
+		s.Image = withTag(s.Image, *patch.Tag)
+	}
+	if patch.Replicas != nil {
+		s.Replicas = *patch.Replicas
+	}
+
+	s.Environment = mergeEnv(s.Environment, patch.Environment, patch.EnvironmentRemove)
+	s.Ports = mergePorts(s.Ports, patch.Ports)
+	for _, n := range patch.Networks {
+		if !contains(s.Networks, n) {
+			s.Networks = append(s.Networks, n)
+		}
+	}
+
+	d := &s.Deploy
+	if patch.Deploy.UpdateConfig.Parallelism != nil {
+		d.UpdateConfig.Parallelism = *patch.Deploy.UpdateConfig.Parallelism
+	}
+	setIfNotEmpty(&d.UpdateConfig.Delay, patch.Deploy.UpdateConfig.Delay)
+	setIfNotEmpty(&d.RestartPolicy.Condition, patch.Deploy.RestartPolicy.Condition)
+	if patch.Deploy.RestartPolicy.MaxAttempts != nil {
+		d.RestartPolicy.MaxAttempts = *patch.Deploy.RestartPolicy.MaxAttempts
+	}
+	setIfNotEmpty(&d.Resources.Limits.CPUs, patch.Deploy.Resources.Limits.CPUs)
+	setIfNotEmpty(&d.Resources.Limits.Memory, patch.Deploy.Resources.Limits.Memory)
+	setIfNotEmpty(&d.Resources.Reservations.CPUs, patch.Deploy.Resources.Reservations.CPUs)
+	setIfNotEmpty(&d.Resources.Reservations.Memory, patch.Deploy.Resources.Reservations.Memory)
+}
+
+// withTag mengganti tag pada referensi image. Port registry
+// (registry:5000/app) tidak dianggap sebagai tag.
+func withTag(image, tag string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image + ":" + tag
+}
+
+// mergeEnv menggabungkan KEY=VALUE berdasarkan key dengan urutan dasar
+// dipertahankan, lalu menghapus key di remove.
+func mergeEnv(base, patch, remove []string) []string {
+	if len(patch) == 0 && len(remove) == 0 {
+		return base
+	}
+
+	merged := make([]string, 0, len(base)+len(patch))
+	index := make(map[string]int)
+	for _, kv := range append(append([]string(nil), base...), patch...) {
+		key := envKey(kv)
+		if i, ok := index[key]; ok {
+			merged[i] = kv
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, kv)
+	}
+
+	result := merged[:0]
+	for _, kv := range merged {
+		if !contains(remove, envKey(kv)) {
+			result = append(result, kv)
+		}
+	}
+	return result
+}
+
+// mergePorts menggabungkan port berdasarkan target port.
+func mergePorts(base, patch []PortConfig) []PortConfig {
+	if len(patch) == 0 {
+		return base
+	}
+
+	merged := append([]PortConfig(nil), base...)
+	for _, p := range patch {
+		replaced := false
+		for i := range merged {
+			if merged[i].Target == p.Target {
+				merged[i] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+func envKey(kv string) string {
+	key, _, _ := strings.Cut(kv, "=")
+	return key
+}
+
+func setIfNotEmpty(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
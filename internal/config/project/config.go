@@ -0,0 +1,126 @@
+// Package project memuat neon.yaml, file project yang disimpan di dalam
+// repository aplikasi.
+package project
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zakirkun/neon/internal/config/validate"
+)
+
+// FileName adalah nama file project di root repository.
+const FileName = "neon.yaml"
+
+type Config struct {
+	// Stack adalah nama stack swarm; service di-deploy sebagai <stack>_<nama>.
+	Stack        string                       `yaml:"stack" validate:"name"`
+	Repo         RepoConfig                   `yaml:"repo"`
+	Build        BuildConfig                  `yaml:"build"`
+	Deploy       DeployConfig                 `yaml:"deploy"`
+	Services     map[string]ServiceConfig     `yaml:"services"`
+	Environments map[string]EnvironmentConfig `yaml:"environments"`
+
+	// Dir adalah direktori tempat neon.yaml dibaca.
+	Dir string `yaml:"-"`
+}
+
+// RepoConfig adalah repository sumber aplikasi.
+type RepoConfig struct {
+	URL    string `yaml:"url"`
+	Branch string `yaml:"branch"`
+}
+
+// BuildConfig mengatur build image untuk project satu service.
+type BuildConfig struct {
+	// Context relatif terhadap root repository; default ".".
+	Context    string            `yaml:"context"`
+	Dockerfile string            `yaml:"dockerfile"`
+	Args       map[string]string `yaml:"args"`
+}
+
+// DeployConfig menunjuk file deploy.yaml atau compose yang dipakai sebagai
+// pengganti pipeline build. Path relatif terhadap neon.yaml.
+type DeployConfig struct {
+	File    string `yaml:"file"`
+	Compose string `yaml:"compose"`
+}
+
+// EnvironmentConfig berisi nilai yang berbeda per environment (staging,
+// production, ...).
+type EnvironmentConfig struct {
+	Branch   string `yaml:"branch"`
+	Registry string `yaml:"registry"`
+	// Context adalah nama context Docker tempat swarm environment ini.
+	Context string `yaml:"context"`
+	Stack   string `yaml:"stack" validate:"name"`
+	// Replicas per nama service.
+	Replicas map[string]uint64 `yaml:"replicas"`
+	// Environment ditambahkan ke environment setiap service (KEY=VALUE).
+	Environment []string `yaml:"environment" validate:"env"`
+}
+
+// ServiceConfig memetakan satu subdirektori repository ke satu service.
+type ServiceConfig struct {
+	// Path adalah direktori build context relatif terhadap root repository.
+	Path string `yaml:"path"`
+	// Dockerfile relatif terhadap Path; default "Dockerfile".
+	Dockerfile string `yaml:"dockerfile"`
+	// Watch adalah path tambahan (misalnya library bersama) yang perubahannya
+	// juga memicu build ulang service ini.
+	Watch       []string `yaml:"watch"`
+	Replicas    uint64   `yaml:"replicas"`
+	Ports       []string `yaml:"ports" validate:"portmap"`
+	Environment []string `yaml:"environment" validate:"env"`
+}
+
+// Load membaca neon.yaml dari direktori dir. Mengembalikan
+// os.ErrNotExist (dibungkus) jika file tidak ada.
+func Load(dir string) (*Config, error) {
+	var config Config
+	if err := validate.DecodeFile(filepath.Join(dir, FileName), &config); err != nil {
+		return nil, err
+	}
+	config.Dir = dir
+
+	for name, svc := range config.Services {
+		if svc.Path == "" {
+			svc.Path = "."
+		}
+		svc.Path = path.Clean(filepath.ToSlash(svc.Path))
+		if strings.HasPrefix(svc.Path, "../") || path.IsAbs(svc.Path) {
+			return nil, fmt.Errorf("service %s: path %s harus berada di dalam repository", name, svc.Path)
+		}
+		config.Services[name] = svc
+	}
+
+	return &config, nil
+}
+
+// ServiceNames mengembalikan nama service dalam urutan yang stabil.
+func (c *Config) ServiceNames() []string {
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Affected melaporkan apakah salah satu file yang berubah berada di bawah
+// Path atau Watch milik service.
+func (s ServiceConfig) Affected(changed []string) bool {
+	roots := append([]string{s.Path}, s.Watch...)
+	for _, file := range changed {
+		for _, root := range roots {
+			root = strings.TrimSuffix(path.Clean(filepath.ToSlash(root)), "/")
+			if root == "." || file == root || strings.HasPrefix(file, root+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zakirkun/neon/internal/config/compose"
+	"github.com/zakirkun/neon/internal/config/deploy"
+)
+
+// Resolved adalah hasil penggabungan pengaturan project dengan satu
+// environment.
+type Resolved struct {
+	// Environment adalah nama environment; kosong jika tanpa --env.
+	Environment string
+	Stack       string
+	RepoURL     string
+	Branch      string
+	Registry    string
+	Context     string
+	Build       BuildConfig
+	// DeployFile dan ComposeFile sudah berupa path absolut.
+	DeployFile  string
+	ComposeFile string
+	Replicas    map[string]uint64
+	Env         []string
+}
+
+// Resolve menggabungkan nilai project dengan environment name. Nama kosong
+// berarti memakai nilai dasar project saja.
+func (c *Config) Resolve(name string) (*Resolved, error) {
+	env := EnvironmentConfig{}
+	if name != "" {
+		var ok bool
+		env, ok = c.Environments[name]
+		if !ok {
+			return nil, fmt.Errorf("environment %q tidak ada di %s (tersedia: %s)",
+				name, FileName, strings.Join(c.EnvironmentNames(), ", "))
+		}
+	}
+
+	r := &Resolved{
+		Environment: name,
+		Stack:       firstNonEmpty(env.Stack, c.Stack),
+		RepoURL:     c.Repo.URL,
+		Branch:      firstNonEmpty(env.Branch, c.Repo.Branch),
+		Registry:    env.Registry,
+		Context:     env.Context,
+		Build:       c.Build,
+		DeployFile:  c.path(c.Deploy.File),
+		ComposeFile: c.path(c.Deploy.Compose),
+		Replicas:    env.Replicas,
+		Env:         env.Environment,
+	}
+
+	if r.DeployFile != "" && r.ComposeFile != "" {
+		return nil, fmt.Errorf("deploy.file dan deploy.compose tidak boleh diisi bersamaan")
+	}
+	return r, nil
+}
+
+// EnvironmentNames mengembalikan nama environment dalam urutan yang stabil.
+func (c *Config) EnvironmentNames() []string {
+	names := make([]string, 0, len(c.Environments))
+	for name := range c.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Config) path(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.Dir, p)
+}
+
+// ApplyDeploy menerapkan replicas dan environment ke konfigurasi deploy.yaml.
+func (r *Resolved) ApplyDeploy(cfg *deploy.Config) {
+	for i := range cfg.Services {
+		svc := &cfg.Services[i]
+		if n, ok := r.Replicas[svc.Name]; ok {
+			svc.Replicas = n
+		}
+		svc.Environment = MergeEnv(svc.Environment, r.Env)
+	}
+}
+
+// ApplyCompose menerapkan replicas dan environment ke file compose.
+func (r *Resolved) ApplyCompose(cfg *compose.Config) {
+	for name, svc := range cfg.Services {
+		if n, ok := r.Replicas[name]; ok {
+			svc.Deploy.Replicas = int(n)
+		}
+		if len(r.Env) > 0 && svc.Environment == nil {
+			svc.Environment = make(map[string]string)
+		}
+		for _, kv := range r.Env {
+			k, v, _ := strings.Cut(kv, "=")
+			svc.Environment[k] = v
+		}
+		cfg.Services[name] = svc
+	}
+}
+
+// MergeEnv menggabungkan daftar KEY=VALUE; entri di override menggantikan
+// key yang sama di base.
+func MergeEnv(base, override []string) []string {
+	if len(override) == 0 {
+		return base
+	}
+
+	index := make(map[string]int, len(base))
+	merged := append([]string(nil), base...)
+	for i, kv := range merged {
+		k, _, _ := strings.Cut(kv, "=")
+		index[k] = i
+	}
+	for _, kv := range override {
+		k, _, _ := strings.Cut(kv, "=")
+		if i, ok := index[k]; ok {
+			merged[i] = kv
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, kv)
+	}
+	return merged
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
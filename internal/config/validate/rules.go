@@ -0,0 +1,170 @@
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+)
+
+// rules berisi pemeriksa nilai yang dapat dipakai di tag `validate`.
+var rules = map[string]func(string) error{
+	"required":   func(string) error { return nil },
+	"memory":     checkMemory,
+	"size":       checkSize,
+	"cpus":       checkCPUs,
+	"duration":   checkDuration,
+	"port":       checkPort,
+	"portmap":    checkPortMapping,
+	"env":        checkEnv,
+	"name":       checkName,
+	"dockerhost": checkDockerHost,
+}
+
+// Check menerapkan satu aturan (misalnya "memory" atau "dockerhost") pada
+// value, untuk nilai yang tidak berasal dari file YAML seperti flag.
+func Check(rule, value string) error {
+	return applyRule(rule, value)
+}
+
+func applyRule(rule, value string) error {
+	if allowed, ok := strings.CutPrefix(rule, "oneof="); ok {
+		options := strings.Fields(allowed)
+		for _, o := range options {
+			if value == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q tidak valid (pilihan: %s)", value, strings.Join(options, ", "))
+	}
+
+	check, ok := rules[rule]
+	if !ok {
+		return fmt.Errorf("aturan validasi tidak dikenal: %s", rule)
+	}
+	return check(value)
+}
+
+// ParseMemory mengubah ukuran memory seperti "512M", "512m", "1.5GiB", atau
+// "1024" (bytes) menjadi bytes.
+func ParseMemory(s string) (int64, error) {
+	n, err := units.RAMInBytes(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("ukuran memory %q tidak valid (contoh: 512M, 1G, 256MiB)", s)
+	}
+	return n, nil
+}
+
+// ParseCPUs mengubah jumlah CPU seperti "0.5" atau "2" menjadi nanoCPUs.
+func ParseCPUs(s string) (int64, error) {
+	cpu, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || cpu <= 0 {
+		return 0, fmt.Errorf("jumlah CPU %q tidak valid (contoh: 0.5, 2)", s)
+	}
+	return int64(cpu * 1e9), nil
+}
+
+func checkMemory(s string) error {
+	n, err := ParseMemory(s)
+	if err != nil {
+		return err
+	}
+	if n < 4*1024*1024 {
+		return fmt.Errorf("ukuran memory %q terlalu kecil (minimal 4M; satuan lupa ditulis?)", s)
+	}
+	return nil
+}
+
+func checkSize(s string) error {
+	_, err := ParseMemory(s)
+	return err
+}
+
+func checkCPUs(s string) error {
+	_, err := ParseCPUs(s)
+	return err
+}
+
+func checkDuration(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("durasi %q tidak valid (contoh: 10s, 1m30s, 500ms)", s)
+	}
+	if d < 0 {
+		return fmt.Errorf("durasi %q tidak boleh negatif", s)
+	}
+	return nil
+}
+
+func checkPort(s string) error {
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n == 0 || n > 65535 {
+		return fmt.Errorf("port %q harus di antara 1 dan 65535", s)
+	}
+	return nil
+}
+
+// checkPortMapping memeriksa format "published:target" yang didukung
+// deploy compose.
+func checkPortMapping(s string) error {
+	published, target, ok := strings.Cut(s, ":")
+	if !ok || strings.Contains(target, ":") {
+		return fmt.Errorf("format port %q tidak valid (gunakan published:target, misalnya 8080:80)", s)
+	}
+	if err := checkPort(published); err != nil {
+		return err
+	}
+	return checkPort(target)
+}
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+func checkEnv(s string) error {
+	key, _, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("environment %q harus berformat KEY=VALUE", s)
+	}
+	if !envKeyPattern.MatchString(key) {
+		return fmt.Errorf("nama environment %q tidak valid", key)
+	}
+	return nil
+}
+
+// checkDockerHost memeriksa endpoint Docker: unix://, tcp://host:port,
+// ssh://[user@]host[:port], atau npipe://.
+func checkDockerHost(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("host %q tidak valid: %v", s, err)
+	}
+	switch u.Scheme {
+	case "unix", "npipe":
+		if u.Path == "" {
+			return fmt.Errorf("host %q tidak memiliki path socket", s)
+		}
+	case "tcp":
+		if u.Hostname() == "" || u.Port() == "" {
+			return fmt.Errorf("host %q harus berformat tcp://host:port", s)
+		}
+	case "ssh":
+		if u.Hostname() == "" {
+			return fmt.Errorf("host %q harus berformat ssh://[user@]host[:port]", s)
+		}
+	default:
+		return fmt.Errorf("host %q harus diawali unix://, tcp://, ssh://, atau npipe://", s)
+	}
+	return nil
+}
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+func checkName(s string) error {
+	if !namePattern.MatchString(s) || len(s) > 63 {
+		return fmt.Errorf("nama %q tidak valid (huruf, angka, '-', '_', '.'; maks. 63 karakter)", s)
+	}
+	return nil
+}
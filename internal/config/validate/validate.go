@@ -0,0 +1,454 @@
+// Package validate melakukan decoding YAML secara ketat: key yang tidak
+// dikenal, tipe yang salah, dan nilai yang tidak valid (unit memory, durasi,
+// port, enum) dilaporkan lengkap dengan file, baris, dan kolom.
+//
+// Aturan nilai ditulis sebagai struct tag `validate` pada tipe konfigurasi,
+// misalnya `validate:"required,memory"` atau
+// `validate:"oneof=none on-failure any"`. Field bertag
+// `validate:"unsupported"` adalah key yang valid di format aslinya tetapi
+// diabaikan neon; key tersebut hanya menghasilkan peringatan.
+package validate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zakirkun/neon/internal/config/crypt"
+	"gopkg.in/yaml.v3"
+)
+
+// warnOutput adalah tujuan peringatan, misalnya key yang diabaikan.
+var warnOutput io.Writer = os.Stderr
+
+// Error adalah satu masalah pada file konfigurasi.
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Path   string
+	Msg    string
+}
+
+func (e Error) Error() string {
+	pos := e.File
+	if e.Line > 0 {
+		pos = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %s", pos, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, e.Path, e.Msg)
+}
+
+// Errors adalah kumpulan Error dari satu atau lebih file.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// FieldError dikembalikan Checker untuk pemeriksaan antar-field. Key adalah
+// key YAML di mapping yang sedang diperiksa; kosong berarti mapping itu
+// sendiri.
+type FieldError struct {
+	Key string
+	Msg string
+}
+
+// Checker diimplementasikan tipe konfigurasi yang membutuhkan pemeriksaan
+// di luar aturan per field, misalnya "image atau build wajib diisi".
+type Checker interface {
+	Check() []FieldError
+}
+
+// DecodeFile membaca path, memvalidasinya terhadap tipe out, lalu men-decode
+// ke out. Semua masalah dikembalikan sekaligus sebagai Errors.
+func DecodeFile(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return Decode(path, data, out)
+}
+
+// Decode sama dengan DecodeFile untuk data yang sudah dibaca; file hanya
+// dipakai untuk pesan error. Nilai ENC[...] didekripsi di memori sebelum
+// divalidasi.
+func Decode(file string, data []byte, out interface{}) error {
+	return decode(file, data, out, false)
+}
+
+// DecodeRaw sama dengan Decode tetapi nilai ENC[...] dibiarkan terenkripsi
+// dan tidak diperiksa aturannya, sehingga tidak membutuhkan kunci.
+func DecodeRaw(file string, data []byte, out interface{}) error {
+	return decode(file, data, out, true)
+}
+
+func decode(file string, data []byte, out interface{}, raw bool) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Errors{syntaxError(file, err)}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	v := &validator{file: file, raw: raw}
+	if !raw {
+		v.decrypt(&doc)
+		if len(v.errs) > 0 {
+			return v.errs
+		}
+	}
+	v.walk(doc.Content[0], reflect.TypeOf(out).Elem(), "", nil)
+	for _, w := range v.warns {
+		fmt.Fprintf(warnOutput, "Peringatan: %s\n", w)
+	}
+	if len(v.errs) > 0 {
+		sort.SliceStable(v.errs, func(i, j int) bool {
+			if v.errs[i].Line != v.errs[j].Line {
+				return v.errs[i].Line < v.errs[j].Line
+			}
+			return v.errs[i].Column < v.errs[j].Column
+		})
+		return v.errs
+	}
+
+	if err := doc.Decode(out); err != nil {
+		return Errors{{File: file, Msg: err.Error()}}
+	}
+	return nil
+}
+
+// syntaxError mengubah error parser yaml.v3 ("yaml: line 3: ...") menjadi
+// Error dengan nomor baris.
+func syntaxError(file string, err error) Error {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	e := Error{File: file, Msg: msg}
+	if rest, ok := strings.CutPrefix(msg, "line "); ok {
+		if num, tail, ok := strings.Cut(rest, ": "); ok {
+			if line, err := strconv.Atoi(num); err == nil {
+				e.Line, e.Column, e.Msg = line, 1, tail
+			}
+		}
+	}
+	return e
+}
+
+type validator struct {
+	file  string
+	raw   bool
+	errs  Errors
+	warns Errors
+	// decrypted adalah node hasil dekripsi; nilainya tidak ditampilkan di
+	// pesan error.
+	decrypted map[*yaml.Node]bool
+}
+
+// decrypt mengganti nilai ENC[...] dengan plaintext. Kunci hanya dibaca jika
+// ada nilai terenkripsi.
+func (v *validator) decrypt(doc *yaml.Node) {
+	crypt.Scalars(doc, func(_ string, n *yaml.Node) error {
+		if !crypt.IsEncrypted(n.Value) {
+			return nil
+		}
+		kr, err := crypt.Default()
+		if err == nil {
+			n.Value, err = kr.Decrypt(n.Value)
+		}
+		if err != nil {
+			v.report(n, "", "%v", err)
+			return nil
+		}
+		retag(n)
+		if v.decrypted == nil {
+			v.decrypted = make(map[*yaml.Node]bool)
+		}
+		v.decrypted[n] = true
+		return nil
+	})
+}
+
+// retag memberi plaintext hasil dekripsi tag yang sama seperti jika ditulis
+// langsung, sehingga field int, float, dan bool juga dapat dienkripsi. Tag
+// null tidak dipakai agar secret seperti "null" atau "~" tidak hilang.
+func retag(n *yaml.Node) {
+	plain := yaml.Node{Kind: yaml.ScalarNode, Value: n.Value}
+	switch tag := plain.ShortTag(); tag {
+	case "!!int", "!!float", "!!bool":
+		n.Tag, n.Style = tag, 0
+	}
+}
+
+func (v *validator) report(node *yaml.Node, path, format string, args ...interface{}) {
+	v.errs = append(v.errs, v.newError(node, path, format, args...))
+}
+
+func (v *validator) warn(node *yaml.Node, path, format string, args ...interface{}) {
+	v.warns = append(v.warns, v.newError(node, path, format, args...))
+}
+
+func (v *validator) newError(node *yaml.Node, path, format string, args ...interface{}) Error {
+	return Error{
+		File:   v.file,
+		Line:   node.Line,
+		Column: node.Column,
+		Path:   path,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// walk memeriksa node terhadap tipe t. rules adalah aturan `validate` dari
+// field induk; untuk list dan map, aturan berlaku pada setiap elemennya.
+func (v *validator) walk(node *yaml.Node, t reflect.Type, path string, rules []string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Tipe dengan UnmarshalYAML sendiri memvalidasi bentuknya sendiri
+	if u, ok := reflect.New(t).Interface().(yaml.Unmarshaler); ok {
+		if err := node.Decode(u); err != nil {
+			v.report(node, path, "%v", err)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		v.walkStruct(node, t, path)
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.report(node, path, "harus berupa mapping, bukan %s", describe(node))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, val := node.Content[i], node.Content[i+1]
+			v.walk(val, t.Elem(), join(path, key.Value), rules)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.report(node, path, "harus berupa list, bukan %s", describe(node))
+			return
+		}
+		for i, item := range node.Content {
+			v.walk(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), rules)
+		}
+	case reflect.Interface:
+		// Nilai bebas
+	default:
+		if v.raw && crypt.IsEncrypted(node.Value) {
+			return
+		}
+		if !v.checkScalar(node, t, path) {
+			return
+		}
+		for _, rule := range rules {
+			err := applyRule(rule, node.Value)
+			switch {
+			case err == nil:
+			case v.decrypted[node]:
+				v.report(node, path, "nilai terenkripsi tidak memenuhi aturan %s", rule)
+			default:
+				v.report(node, path, "%v", err)
+			}
+		}
+	}
+}
+
+func (v *validator) walkStruct(node *yaml.Node, t reflect.Type, path string) {
+	if node.Kind != yaml.MappingNode {
+		v.report(node, path, "harus berupa mapping, bukan %s", describe(node))
+		return
+	}
+
+	fields := yamlFields(t)
+	seen := make(map[string]bool)
+	keys := make(map[string]*yaml.Node)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		fieldPath := join(path, key.Value)
+
+		if seen[key.Value] {
+			v.report(key, fieldPath, "key duplikat")
+			continue
+		}
+		seen[key.Value] = true
+		keys[key.Value] = key
+
+		field, ok := fields[key.Value]
+		if !ok {
+			v.report(key, fieldPath, "field tidak dikenal (yang valid: %s)", strings.Join(fieldNames(fields), ", "))
+			continue
+		}
+		if msg, ok := unsupported(field); ok {
+			v.warn(key, fieldPath, "%s", msg)
+			continue
+		}
+
+		v.walk(val, field.Type, fieldPath, rulesOf(field))
+	}
+
+	for name, field := range fields {
+		if !seen[name] && hasRule(field, "required") {
+			v.report(node, join(path, name), "wajib diisi")
+		}
+	}
+
+	if checker, ok := reflect.New(t).Interface().(Checker); ok {
+		if err := node.Decode(checker); err != nil {
+			// Error tipe sudah dilaporkan oleh walk
+			return
+		}
+		for _, fe := range checker.Check() {
+			at := node
+			if k, ok := keys[fe.Key]; ok {
+				at = k
+			}
+			v.report(at, join(path, fe.Key), "%s", fe.Msg)
+		}
+	}
+}
+
+// checkScalar memastikan node adalah scalar yang cocok dengan tipe t.
+func (v *validator) checkScalar(node *yaml.Node, t reflect.Type, path string) bool {
+	if node.Kind != yaml.ScalarNode {
+		v.report(node, path, "harus berupa nilai %s, bukan %s", t.Kind(), describe(node))
+		return false
+	}
+
+	var msg string
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, err := strconv.ParseInt(node.Value, 0, t.Bits()); err != nil || node.Tag != "!!int" {
+			msg = "harus berupa bilangan bulat"
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if _, err := strconv.ParseUint(node.Value, 0, t.Bits()); err != nil || node.Tag != "!!int" {
+			msg = "harus berupa bilangan bulat positif"
+		}
+	case reflect.Float32, reflect.Float64:
+		if node.Tag != "!!int" && node.Tag != "!!float" {
+			msg = "harus berupa angka"
+		}
+	case reflect.Bool:
+		if node.Tag != "!!bool" {
+			msg = "harus berupa true atau false"
+		}
+	}
+
+	switch {
+	case msg != "" && v.decrypted[node]:
+		v.report(node, path, "%s (nilai terenkripsi)", msg)
+		return false
+	case msg != "":
+		v.report(node, path, "%s, bukan %q", msg, node.Value)
+		return false
+	}
+	return true
+}
+
+// yamlFields memetakan nama key YAML ke field struct, termasuk field dari
+// struct inline.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("yaml")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			for k, inner := range yamlFields(f.Type) {
+				fields[k] = inner
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func fieldNames(fields map[string]reflect.StructField) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func rulesOf(f reflect.StructField) []string {
+	tag := f.Tag.Get("validate")
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+// unsupported mengembalikan pesan peringatan untuk field bertag
+// `validate:"unsupported"` atau `validate:"unsupported=<alternatif>"`.
+func unsupported(f reflect.StructField) (string, bool) {
+	for _, r := range rulesOf(f) {
+		if r == "unsupported" {
+			return "tidak didukung neon dan diabaikan", true
+		}
+		if alt, ok := strings.CutPrefix(r, "unsupported="); ok {
+			return fmt.Sprintf("tidak didukung neon dan diabaikan; gunakan %s", alt), true
+		}
+	}
+	return "", false
+}
+
+func hasRule(f reflect.StructField, name string) bool {
+	for _, r := range rulesOf(f) {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	if key == "" {
+		return path
+	}
+	return path + "." + key
+}
@@ -0,0 +1,91 @@
+package validate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/neon/internal/config/crypt"
+)
+
+type encrypted struct {
+	Port     int     `yaml:"port"`
+	Ratio    float64 `yaml:"ratio"`
+	Enabled  bool    `yaml:"enabled"`
+	Password string  `yaml:"password"`
+	Token    string  `yaml:"token"`
+	Missing  string  `yaml:"missing"`
+}
+
+func TestDecodeEncryptedScalars(t *testing.T) {
+	key, err := crypt.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(crypt.KeyEnv, key.String())
+	kr := &crypt.Keyring{Keys: []crypt.Key{key}}
+
+	enc := func(plain string) string {
+		t.Helper()
+		s, err := kr.Encrypt(plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	data := "port: " + enc("5432") + "\n" +
+		"ratio: \"" + enc("0.5") + "\"\n" +
+		"enabled: " + enc("true") + "\n" +
+		"password: " + enc("0x1F") + "\n" +
+		"token: " + enc("null") + "\n"
+
+	var out encrypted
+	if err := Decode("config.yaml", []byte(data), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := encrypted{Port: 5432, Ratio: 0.5, Enabled: true, Password: "0x1F", Token: "null"}
+	if out != want {
+		t.Errorf("hasil decode = %+v, ingin %+v", out, want)
+	}
+
+	// Plaintext yang bukan angka tetap ditolak untuk field int, tanpa
+	// menampilkan plaintext-nya
+	err = Decode("config.yaml", []byte("port: "+enc("s3cret")+"\n"), &out)
+	if err == nil || !strings.Contains(err.Error(), "harus berupa bilangan bulat (nilai terenkripsi)") {
+		t.Errorf("error = %v, ingin harus berupa bilangan bulat", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("error menampilkan plaintext: %v", err)
+	}
+}
+
+type withUnsupported struct {
+	Image   string      `yaml:"image"`
+	Restart interface{} `yaml:"restart" validate:"unsupported=deploy.restart_policy"`
+	Labels  interface{} `yaml:"labels" validate:"unsupported"`
+}
+
+func TestDecodeUnsupportedKeysWarn(t *testing.T) {
+	var buf bytes.Buffer
+	old := warnOutput
+	warnOutput = &buf
+	t.Cleanup(func() { warnOutput = old })
+
+	data := "image: nginx\nrestart: always\nlabels:\n  team: web\n"
+	var out withUnsupported
+	if err := Decode("docker-compose.yml", []byte(data), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Image != "nginx" {
+		t.Errorf("image = %q, ingin nginx", out.Image)
+	}
+
+	want := []string{
+		"Peringatan: docker-compose.yml:2:1: restart: tidak didukung neon dan diabaikan; gunakan deploy.restart_policy",
+		"Peringatan: docker-compose.yml:3:1: labels: tidak didukung neon dan diabaikan",
+	}
+	if got := strings.TrimSpace(buf.String()); got != strings.Join(want, "\n") {
+		t.Errorf("peringatan =\n%s\ningin\n%s", got, strings.Join(want, "\n"))
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/zakirkun/neon/internal/docker"
 	"github.com/zakirkun/neon/internal/docker/swarm"
+	"github.com/zakirkun/neon/internal/logger"
 )
 
 type ScalingRule struct {
@@ -21,14 +22,18 @@ type ScalingRule struct {
 type Manager struct {
 	client       *docker.Client
 	swarmManager *swarm.Manager
-	rules        map[string]ScalingRule
-	lastScale    map[string]time.Time
+	// deployer menerapkan perubahan replika agar melewati policy deploy,
+	// misalnya batas max_replicas.
+	deployer  *docker.Deployer
+	rules     map[string]ScalingRule
+	lastScale map[string]time.Time
 }
 
 func NewManager(client *docker.Client) *Manager {
 	return &Manager{
 		client:       client,
 		swarmManager: swarm.NewManager(client),
+		deployer:     docker.NewDeployer(client, nil),
 		rules:        make(map[string]ScalingRule),
 		lastScale:    make(map[string]time.Time),
 	}
@@ -72,15 +77,19 @@ func (m *Manager) checkAndScale(ctx context.Context) {
 		if cpuUsage > rule.CPUThreshold && currentReplicas < rule.MaxReplicas {
 			// Scale up
 			newReplicas := min(currentReplicas+rule.ScaleUpStep, rule.MaxReplicas)
-			err := m.swarmManager.ScaleService(ctx, serviceID, newReplicas)
-			if err == nil {
+			err := m.deployer.ScaleService(ctx, serviceID, newReplicas)
+			if err != nil {
+				logger.Warnf("Autoscale %s gagal: %v", serviceID, err)
+			} else {
 				m.lastScale[serviceID] = time.Now()
 			}
 		} else if cpuUsage < rule.CPUThreshold/2 && currentReplicas > rule.MinReplicas {
 			// Scale down
 			newReplicas := max(currentReplicas-rule.ScaleDownStep, rule.MinReplicas)
-			err := m.swarmManager.ScaleService(ctx, serviceID, newReplicas)
-			if err == nil {
+			err := m.deployer.ScaleService(ctx, serviceID, newReplicas)
+			if err != nil {
+				logger.Warnf("Autoscale %s gagal: %v", serviceID, err)
+			} else {
 				m.lastScale[serviceID] = time.Now()
 			}
 		}
@@ -0,0 +1,273 @@
+// Package builder mendeteksi jenis project (Go, Node.js, Python) dari isi
+// direktori dan menghasilkan Dockerfile multi-stage untuk project yang
+// tidak memiliki Dockerfile sendiri.
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Base image yang dipakai Dockerfile hasil generate. Versi sengaja dipatok
+// agar build dapat direproduksi; perbarui di sini saat upgrade.
+const (
+	GoVersion     = "1.22.4"
+	NodeVersion   = "20.15.1"
+	PythonVersion = "3.12.4"
+	AlpineVersion = "3.20"
+	DebianRelease = "bookworm"
+)
+
+// Language adalah jenis project yang dikenali builder.
+type Language string
+
+const (
+	Go     Language = "go"
+	Node   Language = "node"
+	Python Language = "python"
+)
+
+// Project adalah hasil deteksi sebuah direktori build context.
+type Project struct {
+	Language Language
+	// Version adalah versi runtime yang dipakai untuk base image.
+	Version string
+
+	// Go: package main yang di-build, relatif terhadap root project.
+	MainPackage string
+
+	// Node: perintah install dependency dan apakah ada script build.
+	InstallCmd string
+	HasBuild   bool
+	Start      []string
+
+	// Python: file dependency dan entrypoint.
+	Requirements string
+	Pyproject    bool
+}
+
+// ErrUnknownProject dikembalikan jika jenis project tidak dapat dideteksi.
+var ErrUnknownProject = errors.New("jenis project tidak dikenali (butuh go.mod, package.json, requirements.txt, atau pyproject.toml)")
+
+// Detect memeriksa dir dan mengenali jenis project dari file manifest-nya.
+func Detect(dir string) (*Project, error) {
+	switch {
+	case exists(dir, "go.mod"):
+		return detectGo(dir)
+	case exists(dir, "package.json"):
+		return detectNode(dir)
+	case exists(dir, "requirements.txt"), exists(dir, "pyproject.toml"):
+		return detectPython(dir)
+	default:
+		return nil, ErrUnknownProject
+	}
+}
+
+// Dockerfile menghasilkan isi Dockerfile untuk project.
+func (p *Project) Dockerfile() ([]byte, error) {
+	tmpl, ok := templates[p.Language]
+	if !ok {
+		return nil, fmt.Errorf("bahasa %s tidak didukung", p.Language)
+	}
+
+	var buf bytes.Buffer
+	data := struct {
+		*Project
+		Alpine string
+		Debian string
+	}{p, AlpineVersion, DebianRelease}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var goDirective = regexp.MustCompile(`^go\s+(\d+\.\d+(\.\d+)?)\s*$`)
+
+func detectGo(dir string) (*Project, error) {
+	p := &Project{Language: Go, Version: GoVersion}
+
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Versi image tetap GoVersion; direktif go hanya versi minimum
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := goDirective.FindStringSubmatch(strings.TrimSpace(scanner.Text())); m != nil {
+			if newerVersion(m[1], GoVersion) {
+				return nil, fmt.Errorf("go.mod memerlukan Go %s, lebih baru dari image builder (Go %s); gunakan Dockerfile sendiri", m[1], GoVersion)
+			}
+			break
+		}
+	}
+
+	p.MainPackage, err = findGoMain(dir)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// findGoMain mencari package main di root project, lalu di cmd/*.
+func findGoMain(dir string) (string, error) {
+	if hasGoMain(dir) {
+		return ".", nil
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "cmd"))
+	var mains []string
+	for _, e := range entries {
+		if e.IsDir() && hasGoMain(filepath.Join(dir, "cmd", e.Name())) {
+			mains = append(mains, "./cmd/"+e.Name())
+		}
+	}
+	if hasGoMain(filepath.Join(dir, "cmd")) {
+		mains = append(mains, "./cmd")
+	}
+	if len(mains) == 0 {
+		return "", fmt.Errorf("package main tidak ditemukan di root maupun cmd/")
+	}
+
+	sort.Strings(mains)
+	return mains[0], nil
+}
+
+var mainClause = regexp.MustCompile(`(?m)^package main\b`)
+
+func hasGoMain(dir string) bool {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if mainClause.Match(data) {
+			return true
+		}
+	}
+	return false
+}
+
+type packageJSON struct {
+	Main    string            `json:"main"`
+	Scripts map[string]string `json:"scripts"`
+	Engines struct {
+		Node string `json:"node"`
+	} `json:"engines"`
+}
+
+var minVersion = regexp.MustCompile(`\d+(\.\d+){0,2}`)
+
+func detectNode(dir string) (*Project, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("gagal parse package.json: %w", err)
+	}
+
+	// Versi image tetap NodeVersion; batas bawah engines.node hanya
+	// diperiksa agar project yang butuh Node lebih baru gagal lebih awal
+	p := &Project{Language: Node, Version: NodeVersion}
+	if m := minVersion.FindString(pkg.Engines.Node); m != "" && newerVersion(m, NodeVersion) {
+		return nil, fmt.Errorf("package.json memerlukan Node %s, lebih baru dari image builder (Node %s); gunakan Dockerfile sendiri", pkg.Engines.Node, NodeVersion)
+	}
+
+	switch {
+	case exists(dir, "package-lock.json"):
+		p.InstallCmd = "npm ci"
+	case exists(dir, "yarn.lock"):
+		p.InstallCmd = "corepack enable && yarn install --frozen-lockfile"
+	case exists(dir, "pnpm-lock.yaml"):
+		p.InstallCmd = "corepack enable && pnpm install --frozen-lockfile"
+	default:
+		p.InstallCmd = "npm install"
+	}
+
+	_, p.HasBuild = pkg.Scripts["build"]
+
+	switch {
+	case pkg.Scripts["start"] != "":
+		p.Start = []string{"npm", "start"}
+	case pkg.Main != "":
+		p.Start = []string{"node", pkg.Main}
+	default:
+		p.Start = []string{"node", "index.js"}
+	}
+	return p, nil
+}
+
+var requiresPython = regexp.MustCompile(`(?m)^requires-python\s*=\s*"[^"\d]*(\d+\.\d+)`)
+
+func detectPython(dir string) (*Project, error) {
+	p := &Project{Language: Python, Version: PythonVersion}
+
+	if exists(dir, "requirements.txt") {
+		p.Requirements = "requirements.txt"
+	}
+	if exists(dir, "pyproject.toml") {
+		p.Pyproject = true
+		data, err := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+		if err != nil {
+			return nil, err
+		}
+		if m := requiresPython.FindSubmatch(data); m != nil && newerVersion(string(m[1]), PythonVersion) {
+			return nil, fmt.Errorf("pyproject.toml memerlukan Python %s, lebih baru dari image builder (Python %s); gunakan Dockerfile sendiri", m[1], PythonVersion)
+		}
+	}
+
+	switch {
+	case exists(dir, "manage.py"):
+		p.Start = []string{"python", "manage.py", "runserver", "0.0.0.0:8000"}
+	case exists(dir, "main.py"):
+		p.Start = []string{"python", "main.py"}
+	case exists(dir, "app.py"):
+		p.Start = []string{"python", "app.py"}
+	default:
+		p.Start = []string{"python", "-m", "app"}
+	}
+	return p, nil
+}
+
+// newerVersion melaporkan apakah versi required (mis. 1.23 atau 22.1.0)
+// lebih baru dari pinned. Komponen yang tidak ada dianggap 0.
+func newerVersion(required, pinned string) bool {
+	req := strings.Split(required, ".")
+	pin := strings.Split(pinned, ".")
+	for i := 0; i < len(req) || i < len(pin); i++ {
+		var r, p int
+		if i < len(req) {
+			r, _ = strconv.Atoi(req[i])
+		}
+		if i < len(pin) {
+			p, _ = strconv.Atoi(pin[i])
+		}
+		if r != p {
+			return r > p
+		}
+	}
+	return false
+}
+
+func exists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
@@ -0,0 +1,79 @@
+package builder
+
+import (
+	"encoding/json"
+	"text/template"
+)
+
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+var templates = map[Language]*template.Template{
+	Go:     template.Must(template.New("go").Funcs(funcs).Parse(goDockerfile)),
+	Node:   template.Must(template.New("node").Funcs(funcs).Parse(nodeDockerfile)),
+	Python: template.Must(template.New("python").Funcs(funcs).Parse(pythonDockerfile)),
+}
+
+const goDockerfile = `# Generated by neon
+FROM golang:{{.Version}}-alpine{{.Alpine}} AS build
+WORKDIR /src
+COPY go.mod go.sum* ./
+RUN go mod download
+COPY . .
+RUN CGO_ENABLED=0 go build -trimpath -ldflags="-s -w" -o /out/app {{.MainPackage}}
+
+FROM alpine:{{.Alpine}}
+RUN apk add --no-cache ca-certificates tzdata \
+    && addgroup -S app && adduser -S -G app -u 10001 app
+COPY --from=build /out/app /usr/local/bin/app
+USER app
+ENTRYPOINT ["/usr/local/bin/app"]
+`
+
+const nodeDockerfile = `# Generated by neon
+FROM node:{{.Version}}-alpine{{.Alpine}} AS build
+WORKDIR /app
+COPY package*.json yarn.lock* pnpm-lock.yaml* ./
+RUN {{.InstallCmd}}
+COPY . .
+{{- if .HasBuild}}
+RUN npm run build
+{{- end}}
+RUN npm prune --omit=dev
+
+FROM node:{{.Version}}-alpine{{.Alpine}}
+ENV NODE_ENV=production
+WORKDIR /app
+COPY --from=build --chown=node:node /app ./
+USER node
+CMD {{json .Start}}
+`
+
+const pythonDockerfile = `# Generated by neon
+FROM python:{{.Version}}-slim-{{.Debian}} AS build
+ENV PIP_NO_CACHE_DIR=1 PIP_DISABLE_PIP_VERSION_CHECK=1
+RUN python -m venv /opt/venv
+ENV PATH=/opt/venv/bin:$PATH
+WORKDIR /app
+{{- if .Requirements}}
+COPY {{.Requirements}} ./
+RUN pip install -r {{.Requirements}}
+{{- end}}
+COPY . .
+{{- if .Pyproject}}
+RUN pip install .
+{{- end}}
+
+FROM python:{{.Version}}-slim-{{.Debian}}
+ENV PYTHONUNBUFFERED=1 PATH=/opt/venv/bin:$PATH
+RUN groupadd -r app && useradd -r -g app -u 10001 app
+WORKDIR /app
+COPY --from=build /opt/venv /opt/venv
+COPY --from=build --chown=app:app /app ./
+USER app
+CMD {{json .Start}}
+`
@@ -1,18 +1,36 @@
 package docker
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/zakirkun/neon/internal/config"
 )
 
 type Client struct {
 	*client.Client
 }
 
+// NewClient membuat client untuk context aktif (flag --context,
+// $NEON_CONTEXT, atau current_context). Tanpa context, client memakai
+// DOCKER_HOST dan variabel lingkungan Docker lainnya.
 func NewClient() (*Client, error) {
-	// Set API version to match server
-	client.WithVersion("1.45")
+	return NewClientForContext(config.ContextName())
+}
+
+// newEnvClient membuat client dari variabel lingkungan Docker.
+func newEnvClient() (*Client, error) {
+	if host := os.Getenv("DOCKER_HOST"); strings.HasPrefix(host, "ssh://") {
+		return newSSHClient(host)
+	}
 
 	cli, err := client.NewClientWithOpts(
 		client.FromEnv,
@@ -27,3 +45,119 @@ func NewClient() (*Client, error) {
 		Client: cli,
 	}, nil
 }
+
+// NewClientForContext membuat client untuk context bernama name. Context
+// neon di konfigurasi diutamakan; selain itu name dicari di context Docker
+// CLI (lihat `docker context ls`). Nama kosong atau "default" memakai
+// variabel lingkungan Docker.
+func NewClientForContext(name string) (*Client, error) {
+	if ctx := config.Get().LookupContext(name); ctx != nil {
+		return newContextClient(name, ctx)
+	}
+	if name == "" || name == "default" {
+		return newEnvClient()
+	}
+
+	host, err := dockerContextHost(name)
+	if err != nil {
+		return nil, fmt.Errorf("context %q tidak ditemukan di konfigurasi neon maupun Docker CLI: %v", name, err)
+	}
+	if strings.HasPrefix(host, "ssh://") {
+		return newSSHClient(host)
+	}
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost(host),
+		client.WithVersion("1.45"),
+		client.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client for context %s: %v", name, err)
+	}
+
+	return &Client{
+		Client: cli,
+	}, nil
+}
+
+// newContextClient membuat client untuk context neon.
+func newContextClient(name string, ctx *config.Context) (*Client, error) {
+	if strings.HasPrefix(ctx.Host, "ssh://") {
+		cli, err := newSSHClient(ctx.Host)
+		if err != nil {
+			return nil, fmt.Errorf("context %s: %v", name, err)
+		}
+		return cli, nil
+	}
+
+	opts := []client.Opt{
+		client.WithVersion("1.45"),
+		client.WithAPIVersionNegotiation(),
+	}
+	if ctx.TLS.Enabled() {
+		tlsConfig, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:             ctx.TLS.CA,
+			CertFile:           ctx.TLS.Cert,
+			KeyFile:            ctx.TLS.Key,
+			InsecureSkipVerify: ctx.TLS.SkipVerify,
+			ExclusiveRootPools: ctx.TLS.CA != "",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("context %s: konfigurasi TLS tidak valid: %v", name, err)
+		}
+		// WithHost harus setelah WithHTTPClient agar transport ini yang
+		// dikonfigurasi untuk host tersebut
+		opts = append(opts, client.WithHTTPClient(&http.Client{
+			Transport:     &http.Transport{TLSClientConfig: tlsConfig},
+			CheckRedirect: client.CheckRedirect,
+		}))
+	}
+	opts = append(opts, client.WithHost(ctx.Host))
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client for context %s: %v", name, err)
+	}
+
+	return &Client{
+		Client: cli,
+	}, nil
+}
+
+// dockerContextHost membaca endpoint Docker dari metadata context Docker CLI
+// di $DOCKER_CONFIG/contexts (default ~/.docker/contexts).
+func dockerContextHost(name string) (string, error) {
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configDir = filepath.Join(homeDir, ".docker")
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	metaFile := filepath.Join(configDir, "contexts", "meta", hex.EncodeToString(sum[:]), "meta.json")
+	data, err := os.ReadFile(metaFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("docker context %q not found", name)
+		}
+		return "", err
+	}
+
+	var meta struct {
+		Endpoints map[string]struct {
+			Host string `json:"Host"`
+		} `json:"Endpoints"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return "", fmt.Errorf("invalid metadata for docker context %q: %v", name, err)
+	}
+
+	host := meta.Endpoints["docker"].Host
+	if host == "" {
+		return "", fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+	return host, nil
+}
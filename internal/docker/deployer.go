@@ -3,6 +3,8 @@ package docker
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -10,51 +12,267 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/api/types/swarm"
-	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/go-units"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/zakirkun/neon/internal/config"
 	"github.com/zakirkun/neon/internal/config/compose"
 	"github.com/zakirkun/neon/internal/config/deploy"
+	"github.com/zakirkun/neon/internal/config/project"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"github.com/zakirkun/neon/internal/logger"
+	"github.com/zakirkun/neon/internal/policy"
+	"github.com/zakirkun/neon/internal/repocache"
+	"github.com/zakirkun/neon/internal/secrets"
 )
 
 type Deployer struct {
 	client *Client
 	config *config.Config
+	cache  *repocache.Cache
+	out    io.Writer
+
+	// policy dievaluasi sebelum setiap create/update service. policyErr
+	// menyimpan kegagalan memuat file policy; deploy ditolak selama error
+	// ini ada agar policy tidak terlewati diam-diam.
+	policy      *policy.Engine
+	policyErr   error
+	environment string
+
+	// secrets mengambil nilai vault://, file://, env://, dan exec:// dan
+	// menyimpannya selama satu proses deploy.
+	secrets *secrets.Resolver
+
+	// message disimpan pada revisi yang dicatat setiap create/update.
+	message string
+
+	// tx adalah transaksi aktif dari Begin; secret swarm yang dibuat selama
+	// transaksi dicatat di sana agar dapat dihapus saat rollback.
+	tx *Transaction
 }
 
 func NewDeployer(client *Client, cfg *config.Config) *Deployer {
-	return &Deployer{
-		client: client,
-		config: cfg,
+	if cfg == nil {
+		cfg = config.Get()
 	}
+	d := &Deployer{
+		client:  client,
+		config:  cfg,
+		cache:   newRepoCache(cfg),
+		out:     os.Stdout,
+		secrets: secrets.NewResolver(cfg),
+	}
+	d.policy, d.policyErr = loadPolicy(cfg)
+	// Environment policy default mengikuti context aktif sehingga semua
+	// perintah yang menulis spec memakai environment yang sama
+	if _, ctx := config.ActiveContext(); ctx != nil {
+		d.environment = ctx.Environment
+	}
+	return d
+}
+
+// loadPolicy memuat file policy dari konfigurasi, atau ~/.neon/policy.yaml
+// jika ada. File yang disebut eksplisit di konfigurasi wajib ada.
+func loadPolicy(cfg *config.Config) (*policy.Engine, error) {
+	path := cfg.Policy.File
+	if path == "" {
+		var err error
+		if path, err = policy.DefaultFile(); err != nil {
+			return nil, nil
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	return policy.Load(path)
+}
+
+// newRepoCache membuat cache repository dari konfigurasi. Cache dimatikan
+// (nil) jika dinonaktifkan atau lokasinya tidak dapat ditentukan.
+func newRepoCache(cfg *config.Config) *repocache.Cache {
+	if cfg.Cache.Disabled {
+		return nil
+	}
+
+	dir := cfg.Cache.Dir
+	if dir == "" {
+		var err error
+		if dir, err = repocache.DefaultDir(); err != nil {
+			logger.Warnf("Cache repository dinonaktifkan: %v", err)
+			return nil
+		}
+	}
+
+	var maxSize int64
+	if cfg.Cache.MaxSize != "" {
+		size, err := units.RAMInBytes(cfg.Cache.MaxSize)
+		if err != nil {
+			logger.Warnf("cache.max_size tidak valid (%s), memakai default: %v", cfg.Cache.MaxSize, err)
+		}
+		maxSize = size
+	}
+
+	return repocache.New(dir, maxSize)
 }
 
-func (d *Deployer) Deploy(ctx context.Context, repoURL string) error {
-	// 1. Clone repository
-	repoPath, err := d.cloneRepo(repoURL)
+// DeployOptions berisi parameter pipeline deploy. Tepat satu sumber harus
+// diisi: RepoURL, Path, atau ContextFile.
+type DeployOptions struct {
+	RepoURL string
+	Branch  string
+	// Path adalah direktori lokal yang dipakai langsung sebagai build context.
+	Path string
+	// ContextFile adalah arsip build context (.tar, .tar.gz, .tgz).
+	ContextFile string
+	// Service adalah nama service swarm; default diambil dari nama sumber.
+	Service string
+	// Stack menggantikan nama stack dari neon.yaml untuk deploy monorepo.
+	Stack string
+	// NoCache memaksa clone baru tanpa memakai cache repository lokal.
+	NoCache bool
+
+	// BuildContext adalah subdirektori sumber yang dipakai sebagai build
+	// context; default root sumber.
+	BuildContext string
+	Dockerfile   string
+	BuildArgs    map[string]string
+	// Replicas per nama service, menggantikan default dari konfigurasi.
+	Replicas map[string]uint64
+	// Environment ditambahkan ke setiap service (KEY=VALUE).
+	Environment []string
+}
+
+// StageResult mencatat durasi satu tahap pipeline deploy.
+type StageResult struct {
+	Name     string
+	Duration time.Duration
+}
+
+// stageRunner menjalankan tahap-tahap pipeline deploy secara berurutan dan
+// mencatat durasinya.
+type stageRunner struct {
+	out    io.Writer
+	total  int
+	stages []StageResult
+}
+
+func (r *stageRunner) run(name string, fn func() error) error {
+	fmt.Fprintf(r.out, "==> [%d/%d] %s\n", len(r.stages)+1, r.total, name)
+	start := time.Now()
+	err := fn()
+	r.stages = append(r.stages, StageResult{Name: name, Duration: time.Since(start)})
 	if err != nil {
+		logger.Errorf(err, "Stage %s gagal", name)
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	fmt.Fprintf(r.out, "    selesai dalam %s\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// Deploy menjalankan pipeline source -> build -> push -> deploy ke swarm.
+// Sumber dapat berupa repository git, direktori lokal, atau arsip build
+// context. Jika sumber memiliki neon.yaml dengan daftar services, setiap
+// service di-build dari subdirektorinya dan di-deploy sebagai satu stack.
+func (d *Deployer) Deploy(ctx context.Context, opts DeployOptions) ([]StageResult, error) {
+	if opts.Branch == "" {
+		opts.Branch = "main"
+	}
+
+	var (
+		runner    = &stageRunner{out: d.out, total: 4}
+		src       *buildSource
+		imageName string
+	)
+
+	// 1. Siapkan build context (clone repository, direktori, atau arsip)
+	stage := "clone"
+	if opts.RepoURL == "" {
+		stage = "prepare"
+	}
+	err := runner.run(stage, func() error {
+		var err error
+		src, err = d.resolveSource(ctx, opts)
 		return err
+	})
+	if err != nil {
+		return runner.stages, err
+	}
+	defer src.Cleanup()
+
+	if opts.Service == "" {
+		opts.Service = src.Name
+	}
+
+	// Monorepo: service didefinisikan di neon.yaml
+	if src.Dir != "" {
+		proj, err := project.Load(src.Dir)
+		if err != nil && !os.IsNotExist(err) {
+			return runner.stages, err
+		}
+		if proj != nil && len(proj.Services) > 0 {
+			err = d.deployProject(ctx, runner, src, proj, opts)
+			return runner.stages, err
+		}
 	}
-	defer os.RemoveAll(repoPath)
 
 	// 2. Build Docker image
-	imageName, err := d.buildImage(ctx, repoPath)
-	if err != nil {
+	err = runner.run("build", func() error {
+		buildSrc := *src
+		if opts.BuildContext != "" && src.Dir != "" {
+			buildSrc.Dir = filepath.Join(src.Dir, filepath.FromSlash(opts.BuildContext))
+		}
+		if opts.Dockerfile != "" {
+			buildSrc.Dockerfile = opts.Dockerfile
+		}
+		buildSrc.BuildArgs = opts.BuildArgs
+
+		var err error
+		imageName, err = d.buildImage(ctx, &buildSrc, d.imageRef(opts.Service, src.Revision))
 		return err
+	})
+	if err != nil {
+		return runner.stages, err
 	}
 
 	// 3. Push image ke registry
-	err = d.pushImage(ctx, imageName)
+	err = runner.run("push", func() error {
+		return d.pushImage(ctx, imageName)
+	})
 	if err != nil {
-		return err
+		return runner.stages, err
 	}
 
 	// 4. Deploy ke Swarm
-	return d.deployToSwarm(ctx, imageName)
+	err = runner.run("deploy", func() error {
+		return d.deployToSwarm(ctx, opts, imageName, src.Revision)
+	})
+	return runner.stages, err
+}
+
+// WithEnvironment mengembalikan salinan Deployer untuk environment env
+// (misalnya "production"), yang dipakai saat mengevaluasi policy. env
+// kosong mempertahankan environment dari context aktif.
+func (d *Deployer) WithEnvironment(env string) *Deployer {
+	dd := *d
+	if env != "" {
+		dd.environment = env
+	}
+	return &dd
+}
+
+// withOutput mengembalikan salinan Deployer yang menulis output ke w.
+func (d *Deployer) withOutput(w io.Writer) *Deployer {
+	dd := *d
+	dd.out = w
+	return &dd
 }
 
 func (d *Deployer) DeployFromConfig(ctx context.Context, svc *deploy.ServiceConfig) error {
@@ -63,21 +281,33 @@ func (d *Deployer) DeployFromConfig(ctx context.Context, svc *deploy.ServiceConf
 		return fmt.Errorf("gagal pull image: %v", err)
 	}
 
+	secretRefs, err := d.deploySecrets(ctx, svc.Secrets)
+	if err != nil {
+		return err
+	}
+
 	// Convert ke service spec
 	spec := &swarm.ServiceSpec{
 		Annotations: swarm.Annotations{
-			Name: svc.Name,
+			Name:   svc.Name,
+			Labels: svc.Labels,
 		},
 		TaskTemplate: swarm.TaskSpec{
 			ContainerSpec: &swarm.ContainerSpec{
-				Image: svc.Image,
-				Env:   svc.Environment,
+				Image:       svc.Image,
+				Env:         svc.Environment,
+				Secrets:     secretRefs,
+				Healthcheck: deployHealthcheck(svc.Healthcheck),
 			},
 			Resources: &swarm.ResourceRequirements{
 				Limits: &swarm.Limit{
 					NanoCPUs:    parseCPUs(svc.Deploy.Resources.Limits.CPUs),
 					MemoryBytes: parseMemory(svc.Deploy.Resources.Limits.Memory),
 				},
+				Reservations: &swarm.Resources{
+					NanoCPUs:    parseCPUs(svc.Deploy.Resources.Reservations.CPUs),
+					MemoryBytes: parseMemory(svc.Deploy.Resources.Reservations.Memory),
+				},
 			},
 			RestartPolicy: &swarm.RestartPolicy{
 				Condition:   swarm.RestartPolicyCondition(svc.Deploy.RestartPolicy.Condition),
@@ -98,16 +328,18 @@ func (d *Deployer) DeployFromConfig(ctx context.Context, svc *deploy.ServiceConf
 		},
 	}
 
-	_, err := d.client.ServiceCreate(ctx, *spec, types.ServiceCreateOptions{})
-	return err
+	return d.applyService(ctx, spec)
 }
 
-func (d *Deployer) DeployComposeService(ctx context.Context, name string, service *compose.Service) error {
+// DeployComposeService men-deploy satu service compose. secretDefs adalah
+// definisi secrets tingkat atas file compose.
+func (d *Deployer) DeployComposeService(ctx context.Context, name string, service *compose.Service, secretDefs map[string]compose.Secret) error {
 	// Build image jika diperlukan
 	var imageName string
 	if service.Build != nil {
 		var err error
-		imageName, err = d.buildImage(ctx, service.Build.Context)
+		src := &buildSource{Dir: service.Build.Context, Dockerfile: service.Build.Dockerfile}
+		imageName, err = d.buildImage(ctx, src, d.imageRef(name, ""))
 		if err != nil {
 			return fmt.Errorf("gagal build image: %v", err)
 		}
@@ -131,23 +363,35 @@ func (d *Deployer) DeployComposeService(ctx context.Context, name string, servic
 		env = append(env, fmt.Sprintf("%s=%s", k, v))
 	}
 
+	secretRefs, err := d.composeSecrets(ctx, service.Secrets, secretDefs)
+	if err != nil {
+		return err
+	}
+
 	// Create service spec
 	replicas := uint64(service.Deploy.Replicas)
 	spec := &swarm.ServiceSpec{
 		Annotations: swarm.Annotations{
-			Name: name,
+			Name:   name,
+			Labels: service.Deploy.Labels,
 		},
 		TaskTemplate: swarm.TaskSpec{
 			ContainerSpec: &swarm.ContainerSpec{
-				Image:   imageName,
-				Env:     env,
-				Command: []string{service.Command},
+				Image:       imageName,
+				Env:         env,
+				Secrets:     secretRefs,
+				Command:     service.Command,
+				Healthcheck: composeHealthcheck(service.Healthcheck),
 			},
 			Resources: &swarm.ResourceRequirements{
 				Limits: &swarm.Limit{
 					NanoCPUs:    parseCPUs(service.Deploy.Resources.Limits.CPUs),
 					MemoryBytes: parseMemory(service.Deploy.Resources.Limits.Memory),
 				},
+				Reservations: &swarm.Resources{
+					NanoCPUs:    parseCPUs(service.Deploy.Resources.Reservations.CPUs),
+					MemoryBytes: parseMemory(service.Deploy.Resources.Reservations.Memory),
+				},
 			},
 			RestartPolicy: &swarm.RestartPolicy{
 				Condition: swarm.RestartPolicyCondition(service.Deploy.Restart.Condition),
@@ -171,51 +415,132 @@ func (d *Deployer) DeployComposeService(ctx context.Context, name string, servic
 		},
 	}
 
-	_, err := d.client.ServiceCreate(ctx, *spec, types.ServiceCreateOptions{})
-	return err
+	return d.applyService(ctx, spec)
+}
+
+// checkoutSource mengambil isi branch dari cache repository jika tersedia,
+// atau melakukan clone baru ke direktori sementara.
+func (d *Deployer) checkoutSource(ctx context.Context, opts DeployOptions) (*buildSource, error) {
+	if d.cache == nil || opts.NoCache {
+		return d.cloneRepository(ctx, opts.RepoURL, opts.Branch)
+	}
+
+	wt, err := d.cache.Checkout(ctx, opts.RepoURL, opts.Branch, d.gitAuth(opts.RepoURL), d.out)
+	if err != nil {
+		return nil, err
+	}
+
+	return &buildSource{
+		Dir:      wt.Dir,
+		GitDir:   wt.GitDir,
+		Name:     nameFromRepo(opts.RepoURL),
+		Revision: wt.Revision,
+		cleanup:  func() { wt.Remove() },
+	}, nil
 }
 
-func (d *Deployer) cloneRepo(repoURL string) (string, error) {
-	// Implementasi Git clone menggunakan go-git
-	return d.cloneRepository(context.Background(), repoURL, "main")
+// gitAuth mengembalikan kredensial github.token untuk URL HTTPS
+// github.com, atau nil untuk repository lain.
+func (d *Deployer) gitAuth(repoURL string) transport.AuthMethod {
+	token := d.config.GitHub.Token
+	if token == "" {
+		return nil
+	}
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Scheme != "https" || !strings.EqualFold(u.Hostname(), "github.com") {
+		return nil
+	}
+	// GitHub menerima token sebagai password dengan username apa pun
+	return &githttp.BasicAuth{Username: "neon", Password: token}
 }
 
-func (d *Deployer) cloneRepository(ctx context.Context, repoURL string, branch string) (string, error) {
+func (d *Deployer) cloneRepository(ctx context.Context, repoURL string, branch string) (*buildSource, error) {
 	// Buat temporary directory untuk menyimpan hasil clone
 	tmpDir, err := os.MkdirTemp("", "repo-*")
 	if err != nil {
-		return "", fmt.Errorf("gagal membuat temporary directory: %w", err)
+		return nil, fmt.Errorf("gagal membuat temporary directory: %w", err)
 	}
 
 	// Clone options
 	cloneOpts := &git.CloneOptions{
 		URL:           repoURL,
-		Progress:      os.Stdout,
+		Auth:          d.gitAuth(repoURL),
+		Progress:      d.out,
 		SingleBranch:  true,
+		Depth:         1,
 		ReferenceName: plumbing.NewBranchReferenceName(branch),
 	}
 
 	// Lakukan git clone
-	_, err = git.PlainCloneContext(ctx, tmpDir, false, cloneOpts)
+	repo, err := git.PlainCloneContext(ctx, tmpDir, false, cloneOpts)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("gagal melakukan git clone: %w", err)
+	}
+
+	head, err := repo.Head()
 	if err != nil {
-		return "", fmt.Errorf("gagal melakukan git clone: %w", err)
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("gagal membaca HEAD repository: %w", err)
 	}
 
-	return tmpDir, nil
+	return &buildSource{
+		Dir:      tmpDir,
+		GitDir:   tmpDir,
+		Name:     nameFromRepo(repoURL),
+		Revision: head.Hash().String(),
+		cleanup:  func() { os.RemoveAll(tmpDir) },
+	}, nil
 }
 
-func (d *Deployer) buildImage(ctx context.Context, repoPath string) (string, error) {
-	tar, err := archive.TarWithOptions(repoPath, &archive.TarOptions{})
+// imageRef menyusun nama image <registry>/<name>:<tag>. Tag diambil dari
+// revisi git (atau hash isi build context) agar setiap deploy menghasilkan
+// image yang berbeda.
+func (d *Deployer) imageRef(name, revision string) string {
+	tag := "latest"
+	if len(revision) >= 12 {
+		tag = revision[:12]
+	}
+
+	ref := fmt.Sprintf("%s:%s", name, tag)
+	if registry := strings.TrimSuffix(d.config.Docker.Registry, "/"); registry != "" {
+		ref = registry + "/" + ref
+	}
+	return ref
+}
+
+func (d *Deployer) buildImage(ctx context.Context, src *buildSource, imageName string) (string, error) {
+	tar, err := src.Context()
 	if err != nil {
 		return "", fmt.Errorf("gagal membuat tar: %v", err)
 	}
 	defer tar.Close()
 
-	imageName := fmt.Sprintf("%s/%s:latest", d.config.Docker.Registry, filepath.Base(repoPath))
+	dockerfile := src.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+
+	// Project tanpa Dockerfile: generate dari deteksi bahasa
+	generated, err := src.generateDockerfile()
+	if err != nil {
+		return "", err
+	}
+	if generated != nil {
+		fmt.Fprintf(d.out, "    Dockerfile tidak ditemukan, memakai Dockerfile hasil generate (%s)\n", generated.Language)
+		tar = withFile(tar, generatedDockerfileName, generated.Content)
+		dockerfile = generatedDockerfileName
+	}
+
 	buildOptions := types.ImageBuildOptions{
 		Tags:       []string{imageName},
-		Dockerfile: "Dockerfile",
+		Dockerfile: dockerfile,
 		Remove:     true,
+		BuildArgs:  make(map[string]*string, len(src.BuildArgs)),
+	}
+	for k, v := range src.BuildArgs {
+		v := v
+		buildOptions.BuildArgs[k] = &v
 	}
 
 	resp, err := d.client.ImageBuild(ctx, tar, buildOptions)
@@ -224,36 +549,72 @@ func (d *Deployer) buildImage(ctx context.Context, repoPath string) (string, err
 	}
 	defer resp.Body.Close()
 
+	// Build baru selesai setelah stream output habis dibaca
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, d.out, 0, false, nil); err != nil {
+		return "", fmt.Errorf("gagal build image: %v", err)
+	}
+
 	return imageName, nil
 }
 
+// BuildImage mem-build image dari direktori lokal dengan tag imageName,
+// tanpa push maupun deploy.
+func (d *Deployer) BuildImage(ctx context.Context, dir, imageName string) error {
+	src, err := localSource(dir)
+	if err != nil {
+		return err
+	}
+	if imageName == "" {
+		imageName = d.imageRef(src.Name, src.Revision)
+	}
+
+	_, err = d.buildImage(ctx, src, imageName)
+	return err
+}
+
 func (d *Deployer) pushImage(ctx context.Context, imageName string) error {
-	authConfig := registry.AuthConfig{
-		Username: d.config.Docker.Username,
-		Password: d.config.Docker.Password,
+	if d.config.Docker.Registry == "" {
+		fmt.Fprintln(d.out, "    registry tidak dikonfigurasi, push dilewati")
+		return nil
 	}
 
-	_, err := d.client.ImagePush(ctx, imageName, image.PushOptions{
-		RegistryAuth: authConfig.Username + ":" + authConfig.Password,
+	auth, err := registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      d.config.Docker.Username,
+		Password:      d.config.Docker.Password,
+		ServerAddress: d.config.Docker.Registry,
 	})
 	if err != nil {
+		return fmt.Errorf("gagal menyiapkan auth registry: %v", err)
+	}
+
+	resp, err := d.client.ImagePush(ctx, imageName, image.PushOptions{
+		RegistryAuth: auth,
+	})
+	if err != nil {
+		return fmt.Errorf("gagal push image: %v", err)
+	}
+	defer resp.Close()
+
+	if err := jsonmessage.DisplayJSONMessagesStream(resp, d.out, 0, false, nil); err != nil {
 		return fmt.Errorf("gagal push image: %v", err)
 	}
 
 	return nil
 }
 
-func (d *Deployer) deployToSwarm(ctx context.Context, imageName string) error {
-	replicas := uint64(d.config.Deploy.Replicas)
+func (d *Deployer) deployToSwarm(ctx context.Context, opts DeployOptions, imageName, revision string) error {
+	replicas := d.replicasFor(opts, opts.Service, 0)
 	updateDelay, _ := time.ParseDuration(d.config.Deploy.UpdateDelay)
 
 	serviceSpec := &swarm.ServiceSpec{
 		Annotations: swarm.Annotations{
-			Name: filepath.Base(imageName),
+			Name:   opts.Service,
+			Labels: map[string]string{LabelRevision: revision},
 		},
 		TaskTemplate: swarm.TaskSpec{
 			ContainerSpec: &swarm.ContainerSpec{
 				Image: imageName,
+				Env:   opts.Environment,
 			},
 		},
 		Mode: swarm.ServiceMode{
@@ -267,8 +628,77 @@ func (d *Deployer) deployToSwarm(ctx context.Context, imageName string) error {
 		},
 	}
 
-	_, err := d.client.ServiceCreate(ctx, *serviceSpec, types.ServiceCreateOptions{})
-	return err
+	return d.applyService(ctx, serviceSpec)
+}
+
+// replicasFor menentukan jumlah replica service: override di opts, lalu
+// nilai dari file project, lalu default konfigurasi neon, minimal 1.
+func (d *Deployer) replicasFor(opts DeployOptions, name string, declared uint64) uint64 {
+	if n, ok := opts.Replicas[name]; ok {
+		return n
+	}
+	if declared > 0 {
+		return declared
+	}
+	if d.config.Deploy.Replicas > 0 {
+		return uint64(d.config.Deploy.Replicas)
+	}
+	return 1
+}
+
+// applyService membuat service baru atau meng-update service dengan nama
+// yang sama jika sudah ada.
+func (d *Deployer) applyService(ctx context.Context, spec *swarm.ServiceSpec) error {
+	if err := d.checkPolicy(spec); err != nil {
+		return err
+	}
+
+	resolved, err := d.resolveSpec(ctx, spec)
+	if err != nil {
+		return fmt.Errorf("service %s: %v", spec.Name, err)
+	}
+
+	existing, _, err := d.client.ServiceInspectWithRaw(ctx, spec.Name, types.ServiceInspectOptions{})
+	if err != nil {
+		if !errdefs.IsNotFound(err) {
+			return fmt.Errorf("gagal memeriksa service %s: %v", spec.Name, err)
+		}
+		if _, err := d.client.ServiceCreate(ctx, *resolved, types.ServiceCreateOptions{}); err != nil {
+			return err
+		}
+		d.recordRevision(ctx, spec)
+		return nil
+	}
+
+	resp, err := d.client.ServiceUpdate(ctx, existing.ID, existing.Version, *resolved, types.ServiceUpdateOptions{})
+	if err != nil {
+		return err
+	}
+	for _, w := range resp.Warnings {
+		logger.Warnf("Service %s: %s", spec.Name, w)
+	}
+	// Revisi menyimpan spec sebelum resolusi agar nilai secret tidak ikut
+	// tersimpan
+	d.recordRevision(ctx, spec)
+	return nil
+}
+
+// checkPolicy mengevaluasi spec terhadap policy. Peringatan ditulis ke
+// output; deny dikembalikan sebagai *policy.DeniedError.
+func (d *Deployer) checkPolicy(spec *swarm.ServiceSpec) error {
+	if d.policyErr != nil {
+		return fmt.Errorf("gagal memuat policy: %v", d.policyErr)
+	}
+
+	result := d.policy.Evaluate(&policy.Input{
+		Spec:        spec,
+		Environment: d.environment,
+		User:        policy.CurrentUser(),
+	})
+	for _, w := range result.Warnings() {
+		fmt.Fprintf(d.out, "Peringatan policy untuk %s: %s\n", spec.Name, w)
+	}
+	return result.Err(spec.Name)
 }
 
 func (d *Deployer) pullImage(ctx context.Context, images string) error {
@@ -279,53 +709,80 @@ func (d *Deployer) pullImage(ctx context.Context, images string) error {
 	return nil
 }
 
+// parseCPUs dan parseMemory memakai parser yang sama dengan validasi
+// konfigurasi, sehingga nilai yang lolos `neon validate` selalu terbaca.
+// Nilai kosong atau tidak valid berarti tanpa batas.
 func parseCPUs(cpus string) int64 {
 	if cpus == "" {
 		return 0
 	}
-
-	// Konversi CPU cores ke nanoCPUs (1 CPU = 1000000000 nanoCPUs)
-	// Contoh input: "0.5" untuk setengah core, "2" untuk 2 cores
-	var cpu float64
-	fmt.Sscanf(cpus, "%f", &cpu)
-	return int64(cpu * 1e9)
+	n, _ := validate.ParseCPUs(cpus)
+	return n
 }
 
 func parseMemory(memory string) int64 {
 	if memory == "" {
 		return 0
 	}
+	n, _ := validate.ParseMemory(memory)
+	return n
+}
 
-	// Konversi string memory ke bytes
-	// Format yang didukung: "1024", "1024b", "1024k", "1024m", "1024g"
-	var value float64
-	unit := "b"
+func parseDuration(duration string) time.Duration {
+	d, _ := time.ParseDuration(duration)
+	return d
+}
 
-	if len(memory) > 0 {
-		lastChar := memory[len(memory)-1]
-		if lastChar == 'b' || lastChar == 'k' || lastChar == 'm' || lastChar == 'g' {
-			unit = string(lastChar)
-			memory = memory[:len(memory)-1]
-		}
+// deployHealthcheck dan composeHealthcheck mengubah healthcheck dari file
+// konfigurasi ke format container. nil berarti memakai HEALTHCHECK image.
+func deployHealthcheck(hc *deploy.Healthcheck) *container.HealthConfig {
+	if hc == nil {
+		return nil
 	}
-
-	fmt.Sscanf(memory, "%f", &value)
-
-	switch unit {
-	case "k":
-		value *= 1024
-	case "m":
-		value *= 1024 * 1024
-	case "g":
-		value *= 1024 * 1024 * 1024
+	return &container.HealthConfig{
+		Test:        hc.Test,
+		Interval:    parseDuration(hc.Interval),
+		Timeout:     parseDuration(hc.Timeout),
+		StartPeriod: parseDuration(hc.StartPeriod),
+		Retries:     hc.Retries,
 	}
+}
 
-	return int64(value)
+func composeHealthcheck(hc *compose.Healthcheck) *container.HealthConfig {
+	if hc == nil {
+		return nil
+	}
+	if hc.Disable {
+		return &container.HealthConfig{Test: []string{"NONE"}}
+	}
+	return &container.HealthConfig{
+		Test:        hc.Test,
+		Interval:    parseDuration(hc.Interval),
+		Timeout:     parseDuration(hc.Timeout),
+		StartPeriod: parseDuration(hc.StartPeriod),
+		Retries:     hc.Retries,
+	}
 }
 
-func parseDuration(duration string) time.Duration {
-	d, _ := time.ParseDuration(duration)
-	return d
+// nameFromRepo menurunkan nama service/image dari URL atau path repository,
+// misalnya "https://github.com/org/my-app.git" menjadi "my-app".
+func nameFromRepo(repoURL string) string {
+	name := strings.TrimSuffix(strings.TrimRight(repoURL, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.ToLower(name)
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-_.")
 }
 
 func convertPorts(ports []deploy.PortConfig) []swarm.PortConfig {
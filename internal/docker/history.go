@@ -0,0 +1,355 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/zakirkun/neon/internal/logger"
+	"github.com/zakirkun/neon/internal/policy"
+)
+
+// defaultHistoryLimit adalah jumlah revisi per service yang disimpan jika
+// deploy.history_limit tidak diisi.
+const defaultHistoryLimit = 10
+
+// Revision adalah snapshot spec service yang pernah diterapkan neon.
+// Snapshot disimpan sebagai swarm config sehingga terlihat dari semua
+// manager dan semua operator.
+type Revision struct {
+	Revision int       `json:"revision"`
+	Service  string    `json:"service"`
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Message  string    `json:"message,omitempty"`
+	Image    string    `json:"image"`
+	// Digest adalah digest image saat deploy, jika dapat ditentukan.
+	Digest string `json:"digest,omitempty"`
+	// GitSHA adalah revisi sumber dari label neon.revision atau HEAD
+	// repository di direktori kerja.
+	GitSHA string `json:"git_sha,omitempty"`
+	// Spec adalah spec sebelum resolusi secret; referensi vault:// dan
+	// sejenisnya tetap berupa URI.
+	Spec swarm.ServiceSpec `json:"spec"`
+}
+
+// WithMessage mengembalikan salinan Deployer yang menyimpan msg pada
+// setiap revisi.
+func (d *Deployer) WithMessage(msg string) *Deployer {
+	dd := *d
+	dd.message = msg
+	return &dd
+}
+
+// recordRevision menyimpan spec sebagai revisi baru service. Kegagalan
+// hanya dicatat sebagai peringatan karena service sudah ter-update.
+func (d *Deployer) recordRevision(ctx context.Context, spec *swarm.ServiceSpec) {
+	if err := d.saveRevision(ctx, spec); err != nil {
+		logger.Warnf("Gagal menyimpan revisi service %s: %v", spec.Name, err)
+	}
+}
+
+func (d *Deployer) saveRevision(ctx context.Context, spec *swarm.ServiceSpec) error {
+	history, err := d.History(ctx, spec.Name)
+	if err != nil {
+		return err
+	}
+
+	rev := Revision{
+		Revision: 1,
+		Service:  spec.Name,
+		Time:     time.Now().UTC(),
+		User:     policy.CurrentUser(),
+		Message:  d.message,
+		GitSHA:   spec.Labels[LabelRevision],
+		Spec:     *spec,
+	}
+	if len(history) > 0 {
+		rev.Revision = history[len(history)-1].Revision + 1
+	}
+	if cs := spec.TaskTemplate.ContainerSpec; cs != nil {
+		rev.Image = cs.Image
+		rev.Digest = d.imageDigest(ctx, cs.Image)
+	}
+	if rev.GitSHA == "" {
+		rev.GitSHA = gitHead(".")
+	}
+
+	data, err := json.Marshal(rev)
+	if err != nil {
+		return err
+	}
+	_, err = d.client.ConfigCreate(ctx, swarm.ConfigSpec{
+		Annotations: swarm.Annotations{
+			Name: revisionConfigName(spec.Name, rev.Revision),
+			Labels: map[string]string{
+				LabelHistoryService:  spec.Name,
+				LabelHistoryRevision: strconv.Itoa(rev.Revision),
+			},
+		},
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.pruneHistory(ctx, append(history, rev))
+}
+
+// pruneHistory menghapus revisi tertua di atas deploy.history_limit.
+func (d *Deployer) pruneHistory(ctx context.Context, history []Revision) error {
+	limit := d.config.Deploy.HistoryLimit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	for len(history) > limit {
+		old := history[0]
+		history = history[1:]
+		if err := d.client.ConfigRemove(ctx, revisionConfigName(old.Service, old.Revision)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// History mengembalikan revisi service, dari yang terlama.
+func (d *Deployer) History(ctx context.Context, service string) ([]Revision, error) {
+	configs, err := d.client.ConfigList(ctx, types.ConfigListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", LabelHistoryService+"="+service)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]Revision, 0, len(configs))
+	for _, c := range configs {
+		rev, err := decodeRevision(ctx, d.client, c)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, *rev)
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Revision < history[j].Revision })
+	return history, nil
+}
+
+// LatestRevisions mengembalikan revisi terbaru setiap service yang
+// memiliki riwayat, dengan nama service sebagai key.
+func LatestRevisions(ctx context.Context, c *Client) (map[string]Revision, error) {
+	configs, err := c.ConfigList(ctx, types.ConfigListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", LabelHistoryService)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cukup decode config dengan nomor revisi tertinggi per service
+	latest := make(map[string]swarm.Config)
+	for _, cfg := range configs {
+		name := cfg.Spec.Labels[LabelHistoryService]
+		n, _ := strconv.Atoi(cfg.Spec.Labels[LabelHistoryRevision])
+		if prev, ok := latest[name]; ok {
+			if m, _ := strconv.Atoi(prev.Spec.Labels[LabelHistoryRevision]); m >= n {
+				continue
+			}
+		}
+		latest[name] = cfg
+	}
+
+	revisions := make(map[string]Revision, len(latest))
+	for name, cfg := range latest {
+		rev, err := decodeRevision(ctx, c, cfg)
+		if err != nil {
+			return nil, err
+		}
+		revisions[name] = *rev
+	}
+	return revisions, nil
+}
+
+func decodeRevision(ctx context.Context, c *Client, cfg swarm.Config) (*Revision, error) {
+	data := cfg.Spec.Data
+	if len(data) == 0 {
+		// Beberapa versi daemon tidak menyertakan isi config di list
+		full, _, err := c.ConfigInspectWithRaw(ctx, cfg.ID)
+		if err != nil {
+			return nil, err
+		}
+		data = full.Spec.Data
+	}
+	var rev Revision
+	if err := json.Unmarshal(data, &rev); err != nil {
+		return nil, fmt.Errorf("revisi %s rusak: %v", cfg.Spec.Name, err)
+	}
+	return &rev, nil
+}
+
+// Rollback menerapkan ulang revisi to dari service, atau revisi sebelum
+// revisi terakhir jika to 0, lalu menunggu service konvergen. Jumlah replika
+// saat ini dipertahankan agar hasil autoscale tidak hilang. Rollback
+// dicatat sebagai revisi baru.
+func (d *Deployer) Rollback(ctx context.Context, service string, to int, timeout time.Duration) (*Revision, error) {
+	history, err := d.History(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("service %s belum memiliki riwayat revisi", service)
+	}
+
+	var target *Revision
+	if to == 0 {
+		if len(history) < 2 {
+			return nil, fmt.Errorf("service %s hanya memiliki satu revisi", service)
+		}
+		target = &history[len(history)-2]
+	} else {
+		for i := range history {
+			if history[i].Revision == to {
+				target = &history[i]
+			}
+		}
+		if target == nil {
+			return nil, fmt.Errorf("revisi %d tidak ditemukan untuk service %s", to, service)
+		}
+	}
+
+	spec := target.Spec
+	existing, _, err := d.client.ServiceInspectWithRaw(ctx, service, types.ServiceInspectOptions{})
+	if err == nil && existing.Spec.Mode.Replicated != nil && spec.Mode.Replicated != nil {
+		replicas := *existing.Spec.Mode.Replicated.Replicas
+		spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: &replicas}
+	}
+
+	rd := d
+	if d.message == "" {
+		rd = d.WithMessage(fmt.Sprintf("rollback ke revisi %d", target.Revision))
+	}
+	if err := rd.applyService(ctx, &spec); err != nil {
+		return nil, err
+	}
+	if err := d.WaitService(ctx, service, timeout); err != nil {
+		return target, err
+	}
+	return target, nil
+}
+
+// WaitService menunggu sampai update service selesai dan semua replika
+// berjalan, atau timeout. Update yang di-pause atau di-rollback swarm
+// dikembalikan sebagai error.
+func (d *Deployer) WaitService(ctx context.Context, service string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		svc, _, err := d.client.ServiceInspectWithRaw(ctx, service, types.ServiceInspectOptions{})
+		if err != nil {
+			return err
+		}
+
+		state := swarm.UpdateStateCompleted
+		if svc.UpdateStatus != nil {
+			state = svc.UpdateStatus.State
+		}
+		switch state {
+		case swarm.UpdateStatePaused, swarm.UpdateStateRollbackStarted,
+			swarm.UpdateStateRollbackPaused, swarm.UpdateStateRollbackCompleted:
+			return fmt.Errorf("update service %s %s: %s", service, state, svc.UpdateStatus.Message)
+		}
+
+		running, desired, err := d.serviceReplicas(ctx, &svc)
+		if err != nil {
+			return err
+		}
+		if state == swarm.UpdateStateCompleted && running >= desired {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout setelah %s; service %s %d/%d replika berjalan", timeout, service, running, desired)
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// serviceReplicas menghitung task running dari spec terbaru dan jumlah
+// replika yang diinginkan. Service global menganggap setiap task yang
+// seharusnya berjalan sebagai replika.
+func (d *Deployer) serviceReplicas(ctx context.Context, svc *swarm.Service) (running, desired uint64, err error) {
+	tasks, err := d.client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("service", svc.ID),
+			filters.Arg("desired-state", "running"),
+		),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, t := range tasks {
+		desired++
+		if t.Status.State == swarm.TaskStateRunning && currentTask(&t, svc) {
+			running++
+		}
+	}
+	if r := svc.Spec.Mode.Replicated; r != nil && r.Replicas != nil {
+		desired = *r.Replicas
+	}
+	return running, desired, nil
+}
+
+// currentTask melaporkan apakah task dibuat dari spec service terbaru,
+// sehingga task lama yang belum diganti updater tidak ikut dihitung.
+func currentTask(t *swarm.Task, svc *swarm.Service) bool {
+	if t.Spec.ForceUpdate != svc.Spec.TaskTemplate.ForceUpdate {
+		return false
+	}
+	if t.Spec.ContainerSpec == nil || svc.Spec.TaskTemplate.ContainerSpec == nil {
+		return true
+	}
+	return t.Spec.ContainerSpec.Image == svc.Spec.TaskTemplate.ContainerSpec.Image
+}
+
+// imageDigest mencari digest image dari registry, lalu dari image lokal.
+func (d *Deployer) imageDigest(ctx context.Context, image string) string {
+	if info, err := d.client.DistributionInspect(ctx, image, ""); err == nil {
+		return string(info.Descriptor.Digest)
+	}
+	if info, _, err := d.client.ImageInspectWithRaw(ctx, image); err == nil && len(info.RepoDigests) > 0 {
+		return info.RepoDigests[0]
+	}
+	return ""
+}
+
+// gitHead mengembalikan SHA HEAD repository git yang memuat dir.
+func gitHead(dir string) string {
+	repo, err := openRepository(dir)
+	if err != nil {
+		return ""
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return ""
+	}
+	return head.Hash().String()
+}
+
+// revisionConfigName membentuk nama swarm config untuk revisi. Nama object
+// swarm dibatasi 64 karakter, jadi nama service yang panjang diganti hash.
+func revisionConfigName(service string, revision int) string {
+	name := fmt.Sprintf("neon-history-%s-%d", service, revision)
+	if len(name) <= 64 {
+		return name
+	}
+	sum := sha256.Sum256([]byte(service))
+	return fmt.Sprintf("neon-history-%s-%d", hex.EncodeToString(sum[:])[:16], revision)
+}
@@ -0,0 +1,28 @@
+package docker
+
+// Label yang ditulis neon pada service swarm.
+const (
+	// LabelStackNamespace sama dengan label yang dipakai `docker stack deploy`
+	// sehingga stack neon juga terlihat oleh `docker stack ls`.
+	LabelStackNamespace = "com.docker.stack.namespace"
+	// LabelRevision menyimpan revisi sumber (SHA git atau hash context) yang
+	// terakhir di-deploy.
+	LabelRevision = "neon.revision"
+	// LabelConfigHash menyimpan hash spec service neon.yaml tanpa image dan
+	// revisi untuk mendeteksi perubahan yang hanya menyangkut konfigurasi.
+	LabelConfigHash = "neon.config.hash"
+	// LabelProjectService menyimpan nama service di neon.yaml.
+	LabelProjectService = "neon.project.service"
+
+	// Label bundle yang di-install lewat `neon bundle`.
+	LabelBundleRelease = "neon.bundle.release"
+	LabelBundleName    = "neon.bundle.name"
+	LabelBundleVersion = "neon.bundle.version"
+	// LabelBundleValues menyimpan values hasil merge dalam bentuk JSON,
+	// tanpa secret plaintext (lihat bundle.Values.Redacted).
+	LabelBundleValues = "neon.bundle.values"
+
+	// Label swarm config yang menyimpan revisi service.
+	LabelHistoryService  = "neon.history.service"
+	LabelHistoryRevision = "neon.history.revision"
+)
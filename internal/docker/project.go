@@ -0,0 +1,318 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/errdefs"
+	"github.com/zakirkun/neon/internal/config/project"
+)
+
+// projectTarget adalah satu service neon.yaml yang akan di-deploy.
+type projectTarget struct {
+	name    string
+	service string
+	image   string
+	config  project.ServiceConfig
+	// revision adalah revisi sumber image. build bernilai false jika sumber
+	// service tidak berubah dan hanya konfigurasinya yang berbeda, sehingga
+	// image yang sedang berjalan dipakai ulang.
+	revision string
+	build    bool
+}
+
+// deployProject mem-build service neon.yaml yang berubah sejak deploy
+// terakhir secara paralel, lalu men-deploy-nya sebagai satu stack. Service
+// yang sumbernya tidak berubah tetap di-deploy ulang jika spec hasil
+// render (replicas, ports, environment, dan seterusnya) berbeda.
+func (d *Deployer) deployProject(ctx context.Context, runner *stageRunner, src *buildSource, proj *project.Config, opts DeployOptions) error {
+	stack := proj.Stack
+	if stack == "" || opts.Stack != "" {
+		stack = opts.Stack
+	}
+	if stack == "" {
+		stack = d.config.Swarm.Stack
+	}
+	if stack == "" {
+		stack = opts.Service
+	}
+	networkName := stack + "_default"
+
+	var targets, builds []projectTarget
+	for _, name := range proj.ServiceNames() {
+		svc := proj.Services[name]
+		target := projectTarget{
+			name:     name,
+			service:  stack + "_" + name,
+			image:    d.imageRef(stack+"-"+name, src.Revision),
+			config:   svc,
+			revision: src.Revision,
+			build:    true,
+		}
+
+		if running := d.runningService(ctx, target.service); running != nil {
+			prev := running.Spec.Labels[LabelRevision]
+			unchanged := prev == src.Revision
+			if changed, ok := src.changedSince(prev); !unchanged && ok && !svc.Affected(changed) {
+				unchanged = true
+			}
+			if unchanged {
+				spec, err := d.projectServiceSpec(stack, networkName, target, opts)
+				if err != nil {
+					return err
+				}
+				if running.Spec.Labels[LabelConfigHash] == spec.Labels[LabelConfigHash] {
+					fmt.Fprintf(d.out, "    %s tidak berubah sejak %s, dilewati\n", name, shortRevision(prev))
+					continue
+				}
+				target.build = false
+				target.revision = prev
+				if cs := running.Spec.TaskTemplate.ContainerSpec; cs != nil {
+					target.image = cs.Image
+				}
+				fmt.Fprintf(d.out, "    %s: hanya konfigurasi yang berubah, image dari %s dipakai ulang\n", name, shortRevision(prev))
+			}
+		}
+		targets = append(targets, target)
+		if target.build {
+			builds = append(builds, target)
+		}
+	}
+
+	if len(targets) == 0 {
+		fmt.Fprintln(d.out, "    Semua service sudah up to date")
+		return nil
+	}
+
+	// 2. Build semua image secara paralel
+	err := runner.run("build", func() error {
+		return d.forEachTarget(builds, func(dd *Deployer, t projectTarget) error {
+			ctxSrc := &buildSource{
+				Dir:        filepath.Join(src.Dir, filepath.FromSlash(t.config.Path)),
+				Dockerfile: t.config.Dockerfile,
+				Revision:   src.Revision,
+			}
+			_, err := dd.buildImage(ctx, ctxSrc, t.image)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// 3. Push semua image
+	err = runner.run("push", func() error {
+		return d.forEachTarget(builds, func(dd *Deployer, t projectTarget) error {
+			return dd.pushImage(ctx, t.image)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// 4. Deploy sebagai satu stack
+	return runner.run("deploy", func() error {
+		if err := d.ensureStackNetwork(ctx, stack, networkName); err != nil {
+			return err
+		}
+
+		for _, t := range targets {
+			spec, err := d.projectServiceSpec(stack, networkName, t, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(d.out, "    Deploying service: %s\n", t.service)
+			if err := d.applyService(ctx, spec); err != nil {
+				return fmt.Errorf("gagal deploy service %s: %v", t.service, err)
+			}
+		}
+		return nil
+	})
+}
+
+// forEachTarget menjalankan fn untuk setiap target secara paralel. Output
+// setiap target diberi prefix nama service.
+func (d *Deployer) forEachTarget(targets []projectTarget, fn func(*Deployer, projectTarget) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+
+	out := &syncWriter{w: d.out}
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t projectTarget) {
+			defer wg.Done()
+
+			pw := &prefixWriter{w: out, prefix: "    [" + t.name + "] "}
+			err := fn(d.withOutput(pw), t)
+			pw.Flush()
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", t.name, err))
+				mu.Unlock()
+			}
+		}(t)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// runningService mengembalikan service yang sedang berjalan, atau nil jika
+// belum ada.
+func (d *Deployer) runningService(ctx context.Context, serviceName string) *swarm.Service {
+	service, _, err := d.client.ServiceInspectWithRaw(ctx, serviceName, types.ServiceInspectOptions{})
+	if err != nil {
+		return nil
+	}
+	return &service
+}
+
+func (d *Deployer) ensureStackNetwork(ctx context.Context, stack, name string) error {
+	_, err := d.client.NetworkInspect(ctx, name, network.InspectOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errdefs.IsNotFound(err) {
+		return fmt.Errorf("gagal memeriksa network %s: %v", name, err)
+	}
+
+	_, err = d.client.NetworkCreate(ctx, name, network.CreateOptions{
+		Driver:     "overlay",
+		Attachable: true,
+		Labels:     map[string]string{LabelStackNamespace: stack},
+	})
+	if err != nil {
+		return fmt.Errorf("gagal membuat network %s: %v", name, err)
+	}
+	return nil
+}
+
+// projectServiceSpec me-render spec service project. Label
+// LabelConfigHash berisi hash spec tanpa image dan revisi, sehingga
+// perubahan konfigurasi dapat dideteksi tanpa membandingkan sumber.
+func (d *Deployer) projectServiceSpec(stack, networkName string, t projectTarget, opts DeployOptions) (*swarm.ServiceSpec, error) {
+	replicas := d.replicasFor(opts, t.name, t.config.Replicas)
+
+	ports := make([]swarm.PortConfig, 0, len(t.config.Ports))
+	for _, portStr := range t.config.Ports {
+		port, err := parsePortConfig(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %v", t.name, err)
+		}
+		ports = append(ports, port)
+	}
+
+	labels := map[string]string{
+		LabelStackNamespace: stack,
+		LabelProjectService: t.name,
+	}
+
+	spec := &swarm.ServiceSpec{
+		Annotations: swarm.Annotations{
+			Name:   t.service,
+			Labels: labels,
+		},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{
+				Image:  t.image,
+				Env:    project.MergeEnv(t.config.Environment, opts.Environment),
+				Labels: map[string]string{LabelStackNamespace: stack},
+			},
+			Networks: []swarm.NetworkAttachmentConfig{
+				{Target: networkName, Aliases: []string{t.name}},
+			},
+		},
+		Mode: swarm.ServiceMode{
+			Replicated: &swarm.ReplicatedService{
+				Replicas: &replicas,
+			},
+		},
+		UpdateConfig: &swarm.UpdateConfig{
+			Delay:         parseDuration(d.config.Deploy.UpdateDelay),
+			FailureAction: d.config.Deploy.FailureAction,
+		},
+		EndpointSpec: &swarm.EndpointSpec{
+			Ports: ports,
+		},
+	}
+
+	// Hash dihitung sebelum image dan revisi diisi
+	image := spec.TaskTemplate.ContainerSpec.Image
+	spec.TaskTemplate.ContainerSpec.Image = ""
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	spec.TaskTemplate.ContainerSpec.Image = image
+	labels[LabelRevision] = t.revision
+	labels[LabelConfigHash] = hex.EncodeToString(sum[:])[:16]
+	return spec, nil
+}
+
+func shortRevision(rev string) string {
+	if len(rev) > 12 {
+		return rev[:12]
+	}
+	return rev
+}
+
+// syncWriter menserialisasi Write dari beberapa goroutine.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// prefixWriter menambahkan prefix di awal setiap baris output.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf.Write(data)
+	for {
+		line, err := p.buf.ReadBytes('\n')
+		if err != nil {
+			// Baris belum lengkap, simpan untuk Write berikutnya
+			p.buf.Reset()
+			p.buf.Write(line)
+			return len(data), nil
+		}
+		if _, err := p.w.Write(append([]byte(p.prefix), line...)); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush menulis sisa baris yang belum diakhiri newline.
+func (p *prefixWriter) Flush() {
+	if p.buf.Len() > 0 {
+		p.w.Write(append(append([]byte(p.prefix), p.buf.Bytes()...), '\n'))
+		p.buf.Reset()
+	}
+}
@@ -0,0 +1,152 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/zakirkun/neon/internal/config/compose"
+	"github.com/zakirkun/neon/internal/config/deploy"
+)
+
+const (
+	// LabelSecret menandai secret swarm yang dibuat neon dengan nama secret
+	// di file konfigurasi.
+	LabelSecret = "neon.secret"
+	// LabelSecretSource menyimpan URI sumber secret (bukan isinya).
+	LabelSecretSource = "neon.secret.source"
+)
+
+// resolveSpec mengembalikan salinan spec dengan referensi secret di
+// environment diganti nilainya. spec asli tetap berisi URI.
+func (d *Deployer) resolveSpec(ctx context.Context, spec *swarm.ServiceSpec) (*swarm.ServiceSpec, error) {
+	cs := spec.TaskTemplate.ContainerSpec
+	if cs == nil || len(cs.Env) == 0 {
+		return spec, nil
+	}
+
+	env, err := d.secrets.ResolveEnv(ctx, cs.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := *spec
+	container := *cs
+	container.Env = env
+	resolved.TaskTemplate.ContainerSpec = &container
+	return &resolved, nil
+}
+
+// deploySecrets membuat secret swarm untuk service di deploy.yaml.
+func (d *Deployer) deploySecrets(ctx context.Context, list []deploy.SecretConfig) ([]*swarm.SecretReference, error) {
+	refs := make([]*swarm.SecretReference, 0, len(list))
+	for _, s := range list {
+		ref, err := d.swarmSecret(ctx, s.Name, s.Source, s.Target)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// composeSecrets membuat secret swarm untuk secret yang dipakai service
+// compose, berdasarkan definisi secrets tingkat atas.
+func (d *Deployer) composeSecrets(ctx context.Context, names []string, defs map[string]compose.Secret) ([]*swarm.SecretReference, error) {
+	refs := make([]*swarm.SecretReference, 0, len(names))
+	for _, name := range names {
+		def, ok := defs[name]
+		if !ok {
+			return nil, fmt.Errorf("secret %q tidak didefinisikan", name)
+		}
+
+		swarmName := name
+		if def.Name != "" {
+			swarmName = def.Name
+		}
+		ref, err := d.swarmSecret(ctx, swarmName, def.URI(), name)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// swarmSecret mengembalikan referensi ke secret swarm. Untuk source
+// kosong, secret bernama name harus sudah ada. Selain itu isi secret
+// diambil dari source dan disimpan sebagai <name>_<hash isi>; secret swarm
+// tidak dapat diubah, jadi isi baru menghasilkan secret baru dan service
+// di-update ke secret tersebut.
+func (d *Deployer) swarmSecret(ctx context.Context, name, source, target string) (*swarm.SecretReference, error) {
+	if target == "" {
+		target = name
+	}
+
+	secretName := name
+	var data []byte
+	if source != "" {
+		value, err := d.secrets.Resolve(ctx, source)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %v", name, err)
+		}
+		data = []byte(value)
+		sum := sha256.Sum256(data)
+		secretName = fmt.Sprintf("%s_%s", name, hex.EncodeToString(sum[:])[:12])
+	}
+
+	existing, err := d.client.SecretList(ctx, types.SecretListOptions{
+		Filters: filters.NewArgs(filters.Arg("name", secretName)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca secret %s: %v", secretName, err)
+	}
+
+	var id string
+	for _, s := range existing {
+		// Filter name mencocokkan prefix
+		if s.Spec.Name == secretName {
+			id = s.ID
+			break
+		}
+	}
+
+	if id == "" {
+		if source == "" {
+			return nil, fmt.Errorf("secret %s tidak ada di swarm", name)
+		}
+		resp, err := d.client.SecretCreate(ctx, swarm.SecretSpec{
+			Annotations: swarm.Annotations{
+				Name: secretName,
+				Labels: map[string]string{
+					LabelSecret:       name,
+					LabelSecretSource: source,
+				},
+			},
+			Data: data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("gagal membuat secret %s: %v", secretName, err)
+		}
+		id = resp.ID
+		fmt.Fprintf(d.out, "    secret %s dibuat dari %s\n", secretName, source)
+		if d.tx != nil {
+			d.tx.secrets = append(d.tx.secrets, txSecret{id: id, name: secretName})
+		}
+	}
+
+	return &swarm.SecretReference{
+		File: &swarm.SecretReferenceFileTarget{
+			Name: target,
+			UID:  "0",
+			GID:  "0",
+			Mode: 0444,
+		},
+		SecretID:   id,
+		SecretName: secretName,
+	}, nil
+}
@@ -0,0 +1,307 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"github.com/zakirkun/neon/internal/logger"
+	"github.com/zakirkun/neon/internal/secrets"
+)
+
+// ServiceChanges adalah perubahan spec service yang dapat dilakukan lewat
+// flag `neon service update`. Field kosong berarti tidak diubah.
+type ServiceChanges struct {
+	Image string
+	// EnvAdd berisi KEY=VALUE; nilai boleh berupa referensi secret.
+	EnvAdd []string
+	EnvRm  []string
+	// LabelAdd berisi KEY=VALUE untuk label service.
+	LabelAdd []string
+	LabelRm  []string
+
+	LimitCPUs         string
+	LimitMemory       string
+	ReservationCPUs   string
+	ReservationMemory string
+	PublishAdd        []string
+	PublishRm         []string
+	Force             bool
+}
+
+// Apply menerapkan perubahan pada spec, memakai parser yang sama dengan
+// file konfigurasi.
+func (c *ServiceChanges) Apply(spec *swarm.ServiceSpec) error {
+	cs := spec.TaskTemplate.ContainerSpec
+	if cs == nil {
+		return fmt.Errorf("service %s bukan service container", spec.Name)
+	}
+
+	if c.Image != "" {
+		cs.Image = c.Image
+	}
+
+	env, err := updateEnv(cs.Env, c.EnvAdd, c.EnvRm)
+	if err != nil {
+		return err
+	}
+	cs.Env = env
+
+	if len(c.LabelAdd) > 0 || len(c.LabelRm) > 0 {
+		if spec.Labels == nil {
+			spec.Labels = make(map[string]string)
+		}
+		for _, key := range c.LabelRm {
+			delete(spec.Labels, key)
+		}
+		for _, kv := range c.LabelAdd {
+			key, value, _ := strings.Cut(kv, "=")
+			if key == "" {
+				return fmt.Errorf("label %q tidak valid (gunakan KEY=VALUE)", kv)
+			}
+			spec.Labels[key] = value
+		}
+	}
+
+	if err := c.applyResources(&spec.TaskTemplate); err != nil {
+		return err
+	}
+
+	if len(c.PublishAdd) > 0 || len(c.PublishRm) > 0 {
+		if spec.EndpointSpec == nil {
+			spec.EndpointSpec = &swarm.EndpointSpec{}
+		}
+		ports := spec.EndpointSpec.Ports[:0:0]
+		for _, p := range spec.EndpointSpec.Ports {
+			if !containsPort(c.PublishRm, p) {
+				ports = append(ports, p)
+			}
+		}
+		for _, s := range c.PublishAdd {
+			port, err := parsePortConfig(s)
+			if err != nil {
+				return err
+			}
+			ports = append(ports, port)
+		}
+		spec.EndpointSpec.Ports = ports
+	}
+
+	if c.Force {
+		spec.TaskTemplate.ForceUpdate++
+	}
+	return nil
+}
+
+func (c *ServiceChanges) applyResources(task *swarm.TaskSpec) error {
+	if c.LimitCPUs == "" && c.LimitMemory == "" && c.ReservationCPUs == "" && c.ReservationMemory == "" {
+		return nil
+	}
+	if task.Resources == nil {
+		task.Resources = &swarm.ResourceRequirements{}
+	}
+	if task.Resources.Limits == nil {
+		task.Resources.Limits = &swarm.Limit{}
+	}
+	if task.Resources.Reservations == nil {
+		task.Resources.Reservations = &swarm.Resources{}
+	}
+
+	for _, f := range []struct {
+		flag, value string
+		dst         *int64
+		parse       func(string) (int64, error)
+	}{
+		{"--limit-cpu", c.LimitCPUs, &task.Resources.Limits.NanoCPUs, validate.ParseCPUs},
+		{"--limit-memory", c.LimitMemory, &task.Resources.Limits.MemoryBytes, validate.ParseMemory},
+		{"--reserve-cpu", c.ReservationCPUs, &task.Resources.Reservations.NanoCPUs, validate.ParseCPUs},
+		{"--reserve-memory", c.ReservationMemory, &task.Resources.Reservations.MemoryBytes, validate.ParseMemory},
+	} {
+		if f.value == "" {
+			continue
+		}
+		n, err := f.parse(f.value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", f.flag, err)
+		}
+		*f.dst = n
+	}
+	return nil
+}
+
+// UpdateService menerapkan changes pada service yang sudah ada. Spec hasil
+// perubahan melewati policy dan resolusi secret yang sama dengan deploy.
+func (d *Deployer) UpdateService(ctx context.Context, name string, changes *ServiceChanges) error {
+	return d.ModifyService(ctx, name, changes.Apply)
+}
+
+// ModifyService mengubah spec service yang sudah ada dengan fn. Seperti
+// UpdateService, spec hasil perubahan melewati policy, secret di-resolve,
+// dan revisinya dicatat di riwayat.
+func (d *Deployer) ModifyService(ctx context.Context, name string, fn func(*swarm.ServiceSpec) error) error {
+	existing, _, err := d.client.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+
+	spec, err := d.unresolvedSpec(ctx, &existing)
+	if err != nil {
+		return err
+	}
+	if err := fn(&spec); err != nil {
+		return err
+	}
+	if err := d.checkPolicy(&spec); err != nil {
+		return err
+	}
+	resolved, err := d.resolveSpec(ctx, &spec)
+	if err != nil {
+		return fmt.Errorf("service %s: %v", spec.Name, err)
+	}
+
+	resp, err := d.client.ServiceUpdate(ctx, existing.ID, existing.Version, *resolved, types.ServiceUpdateOptions{})
+	if err != nil {
+		return err
+	}
+	for _, w := range resp.Warnings {
+		logger.Warnf("Service %s: %s", spec.Name, w)
+	}
+	d.recordRevision(ctx, &spec)
+	return nil
+}
+
+// ScaleService mengubah jumlah replika service setelah spec hasil
+// perubahan lolos policy.
+func (d *Deployer) ScaleService(ctx context.Context, name string, replicas uint64) error {
+	existing, _, err := d.client.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if existing.Spec.Mode.Replicated == nil {
+		return fmt.Errorf("service %s berjalan dalam mode global dan tidak dapat di-scale", existing.Spec.Name)
+	}
+
+	spec, err := d.unresolvedSpec(ctx, &existing)
+	if err != nil {
+		return err
+	}
+	spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: &replicas}
+	if err := d.checkPolicy(&spec); err != nil {
+		return err
+	}
+
+	// Spec yang berjalan sudah ter-resolve, jadi hanya replikanya yang diubah
+	live := existing.Spec
+	live.Mode.Replicated = &swarm.ReplicatedService{Replicas: &replicas}
+	resp, err := d.client.ServiceUpdate(ctx, existing.ID, existing.Version, live, types.ServiceUpdateOptions{})
+	if err != nil {
+		return err
+	}
+	for _, w := range resp.Warnings {
+		logger.Warnf("Service %s: %s", existing.Spec.Name, w)
+	}
+	return nil
+}
+
+// unresolvedSpec mengembalikan salinan spec service dengan referensi secret
+// dari revisi terakhir. Spec swarm berisi nilai secret yang sudah
+// di-resolve; nilai tersebut tidak boleh ikut tersimpan di riwayat atau
+// dievaluasi policy sebagai plaintext.
+func (d *Deployer) unresolvedSpec(ctx context.Context, svc *swarm.Service) (swarm.ServiceSpec, error) {
+	spec := svc.Spec
+	cs := spec.TaskTemplate.ContainerSpec
+	if cs == nil {
+		return spec, nil
+	}
+	history, err := d.History(ctx, spec.Name)
+	if err != nil {
+		return spec, fmt.Errorf("gagal membaca riwayat service %s: %v", spec.Name, err)
+	}
+	if len(history) > 0 {
+		container := *cs
+		container.Env = unresolvedEnv(cs.Env, history[len(history)-1].Spec.TaskTemplate.ContainerSpec)
+		spec.TaskTemplate.ContainerSpec = &container
+	}
+	return spec, nil
+}
+
+// unresolvedEnv mengganti nilai env dengan referensi secret dari spec
+// revisi untuk key yang sama. Nilai lain dibiarkan apa adanya.
+func unresolvedEnv(env []string, recorded *swarm.ContainerSpec) []string {
+	if recorded == nil {
+		return env
+	}
+	refs := make(map[string]string)
+	for _, kv := range recorded.Env {
+		key, value, _ := strings.Cut(kv, "=")
+		if secrets.IsRef(value) {
+			refs[key] = kv
+		}
+	}
+
+	result := make([]string, len(env))
+	for i, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		if ref, ok := refs[key]; ok {
+			kv = ref
+		}
+		result[i] = kv
+	}
+	return result
+}
+
+// updateEnv menghapus key di rm lalu menambah atau mengganti nilai dari add.
+func updateEnv(env, add, rm []string) ([]string, error) {
+	if len(add) == 0 && len(rm) == 0 {
+		return env, nil
+	}
+
+	drop := make(map[string]bool)
+	for _, key := range rm {
+		drop[key] = true
+	}
+	values := make(map[string]string)
+	var order []string
+	for _, kv := range add {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("environment %q tidak valid (gunakan KEY=VALUE)", kv)
+		}
+		if _, seen := values[key]; !seen {
+			order = append(order, key)
+		}
+		values[key] = value
+		drop[key] = true
+	}
+
+	result := make([]string, 0, len(env)+len(order))
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		if !drop[key] {
+			result = append(result, kv)
+		}
+	}
+	for _, key := range order {
+		result = append(result, key+"="+values[key])
+	}
+	return result, nil
+}
+
+// containsPort melaporkan apakah port cocok dengan salah satu entri list
+// (PUBLISHED atau PUBLISHED:TARGET).
+func containsPort(list []string, p swarm.PortConfig) bool {
+	for _, s := range list {
+		published, target, ok := strings.Cut(s, ":")
+		if published != fmt.Sprint(p.PublishedPort) {
+			continue
+		}
+		if ok && target != fmt.Sprint(p.TargetPort) {
+			continue
+		}
+		return true
+	}
+	return false
+}
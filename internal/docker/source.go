@@ -0,0 +1,372 @@
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+	"github.com/zakirkun/neon/internal/docker/builder"
+)
+
+// buildSource adalah build context untuk ImageBuild beserta metadata
+// revisinya. Context berupa direktori (Dir) atau arsip tar (Archive).
+type buildSource struct {
+	Dir        string
+	Archive    string
+	Dockerfile string
+	BuildArgs  map[string]string
+	// Name adalah nama default service/image untuk sumber ini.
+	Name string
+	// Revision adalah SHA commit git atau hash isi build context.
+	Revision string
+	// GitDir adalah repository git asal Revision; kosong jika Revision bukan
+	// commit git.
+	GitDir  string
+	cleanup func()
+}
+
+func (s *buildSource) Cleanup() {
+	if s.cleanup != nil {
+		s.cleanup()
+	}
+}
+
+// Context membuka build context sebagai stream tar. Arsip dikirim apa
+// adanya; daemon Docker sendiri yang menangani kompresi gzip/bzip2/xz.
+func (s *buildSource) Context() (io.ReadCloser, error) {
+	if s.Archive != "" {
+		return os.Open(s.Archive)
+	}
+
+	excludes, err := readDockerignore(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	return archive.TarWithOptions(s.Dir, &archive.TarOptions{ExcludePatterns: excludes})
+}
+
+// generatedDockerfileName adalah nama file tempat Dockerfile hasil generate
+// disisipkan ke dalam build context.
+const generatedDockerfileName = ".neon.Dockerfile"
+
+type generatedDockerfile struct {
+	Language builder.Language
+	Content  []byte
+}
+
+// generateDockerfile menghasilkan Dockerfile dari deteksi bahasa jika
+// direktori build context tidak memiliki Dockerfile. Mengembalikan nil jika
+// Dockerfile sudah ada, Dockerfile diatur eksplisit, atau sumber berupa arsip.
+func (s *buildSource) generateDockerfile() (*generatedDockerfile, error) {
+	if s.Dir == "" || s.Dockerfile != "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, "Dockerfile")); err == nil {
+		return nil, nil
+	}
+
+	project, err := builder.Detect(s.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("Dockerfile tidak ditemukan: %w", err)
+	}
+	content, err := project.Dockerfile()
+	if err != nil {
+		return nil, err
+	}
+	return &generatedDockerfile{Language: project.Language, Content: content}, nil
+}
+
+// withFile menambahkan file ke stream tar build context.
+func withFile(buildCtx io.ReadCloser, name string, content []byte) io.ReadCloser {
+	return archive.ReplaceFileTarWrapper(buildCtx, map[string]archive.TarModifierFunc{
+		name: func(_ string, _ *tar.Header, _ io.Reader) (*tar.Header, []byte, error) {
+			return &tar.Header{
+				Name:     name,
+				Mode:     0644,
+				Size:     int64(len(content)),
+				ModTime:  time.Now(),
+				Typeflag: tar.TypeReg,
+			}, content, nil
+		},
+	})
+}
+
+// resolveSource menyiapkan build context sesuai sumber di opts.
+func (d *Deployer) resolveSource(ctx context.Context, opts DeployOptions) (*buildSource, error) {
+	switch {
+	case opts.RepoURL != "":
+		return d.checkoutSource(ctx, opts)
+	case opts.Path != "":
+		return localSource(opts.Path)
+	case opts.ContextFile != "":
+		return archiveSource(opts.ContextFile)
+	default:
+		return nil, fmt.Errorf("sumber deploy tidak ditentukan (--repo, --path, atau --archive)")
+	}
+}
+
+// localSource memakai direktori lokal sebagai build context. Revisi diambil
+// dari HEAD git jika direktori berada dalam working copy yang bersih;
+// selain itu dari hash isi direktori.
+func localSource(path string) (*buildSource, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca direktori %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s bukan direktori", path)
+	}
+
+	src := &buildSource{Dir: dir, Name: nameFromRepo(dir)}
+
+	if rev, ok := cleanGitRevision(dir); ok {
+		src.Revision = rev
+		src.GitDir = dir
+		return src, nil
+	}
+
+	excludes, err := readDockerignore(dir)
+	if err != nil {
+		return nil, err
+	}
+	src.Revision, err = hashDir(dir, excludes)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghitung hash build context: %w", err)
+	}
+	return src, nil
+}
+
+// archiveSource memakai arsip tar sebagai build context, dengan revisi dari
+// hash isi arsip.
+func archiveSource(path string) (*buildSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka arsip build context: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("gagal menghitung hash arsip: %w", err)
+	}
+
+	name := filepath.Base(path)
+	if ext := archiveExt(name); ext != "" {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	return &buildSource{
+		Archive:  path,
+		Name:     nameFromRepo(name),
+		Revision: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// archiveExts adalah ekstensi arsip build context yang dikenali.
+var archiveExts = []string{".tar.gz", ".tgz", ".tar.bz2", ".tar.xz", ".tar"}
+
+// IsArchive melaporkan apakah name berakhiran ekstensi arsip build context.
+func IsArchive(name string) bool {
+	return archiveExt(name) != ""
+}
+
+func archiveExt(name string) string {
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// changedSince mengembalikan daftar file yang berubah antara commit from dan
+// Revision. ok bernilai false jika perubahan tidak dapat ditentukan, misalnya
+// sumber tanpa git atau commit from tidak ada di history (clone dangkal).
+func (s *buildSource) changedSince(from string) (changed []string, ok bool) {
+	if s.GitDir == "" || from == "" {
+		return nil, false
+	}
+
+	repo, err := openRepository(s.GitDir)
+	if err != nil {
+		return nil, false
+	}
+
+	fromTree, err := commitTree(repo, from)
+	if err != nil {
+		return nil, false
+	}
+	toTree, err := commitTree(repo, s.Revision)
+	if err != nil {
+		return nil, false
+	}
+
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, c := range changes {
+		if c.From.Name != "" {
+			changed = append(changed, c.From.Name)
+		}
+		if c.To.Name != "" && c.To.Name != c.From.Name {
+			changed = append(changed, c.To.Name)
+		}
+	}
+	return changed, true
+}
+
+// openRepository membuka repository git biasa maupun bare. Untuk
+// repository biasa, dir boleh berupa subdirektori working copy.
+func openRepository(dir string) (*git.Repository, error) {
+	repo, err := git.PlainOpen(dir)
+	if err == git.ErrRepositoryNotExists {
+		return git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	}
+	return repo, err
+}
+
+func commitTree(repo *git.Repository, rev string) (*object.Tree, error) {
+	commit, err := repo.CommitObject(plumbing.NewHash(rev))
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// cleanGitRevision mengembalikan SHA HEAD jika dir berada dalam repository
+// git tanpa perubahan yang belum di-commit.
+func cleanGitRevision(dir string) (string, bool) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", false
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", false
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", false
+	}
+	status, err := wt.Status()
+	if err != nil || !status.IsClean() {
+		return "", false
+	}
+
+	return head.Hash().String(), true
+}
+
+func readDockerignore(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	patterns, err := ignorefile.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca .dockerignore: %w", err)
+	}
+	return patterns, nil
+}
+
+// hashDir menghitung sha256 dari path, mode, dan isi setiap file di dir yang
+// tidak dikecualikan .dockerignore, dalam urutan yang stabil.
+func hashDir(dir string, excludes []string) (string, error) {
+	pm, err := patternmatcher.New(excludes)
+	if err != nil {
+		return "", err
+	}
+
+	var files []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		skip, err := pm.MatchesOrParentMatches(rel)
+		if err != nil {
+			return err
+		}
+		if skip {
+			if d.IsDir() && !pm.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, rel := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		info, err := os.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00", rel, info.Mode())
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return "", err
+			}
+			io.WriteString(h, target)
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,173 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/zakirkun/neon/internal/config"
+)
+
+// bareRepo adalah fixture repository bare lokal beserta working copy untuk
+// membuat commit baru, sehingga pipeline git dapat diuji tanpa jaringan.
+type bareRepo struct {
+	t    *testing.T
+	url  string
+	work *git.Repository
+	dir  string
+}
+
+func newBareRepo(t *testing.T) *bareRepo {
+	t.Helper()
+	root := t.TempDir()
+
+	url := filepath.Join(root, "app.git")
+	if _, err := git.PlainInit(url, true); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(root, "work")
+	work, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := work.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{url}}); err != nil {
+		t.Fatal(err)
+	}
+	return &bareRepo{t: t, url: url, work: work, dir: dir}
+}
+
+// commit menulis files, membuat commit di branch main, lalu mem-push-nya ke
+// repository bare. Mengembalikan SHA commit.
+func (r *bareRepo) commit(files map[string]string) string {
+	r.t.Helper()
+	wt, err := r.work.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(r.dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+
+	hash, err := wt.Commit("update", &git.CommitOptions{
+		Author: &object.Signature{Name: "neon", Email: "neon@example.com", When: time.Now()},
+	})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	main := plumbing.NewBranchReferenceName("main")
+	if err := r.work.Storer.SetReference(plumbing.NewHashReference(main, hash)); err != nil {
+		r.t.Fatal(err)
+	}
+	err = r.work.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{"refs/heads/main:refs/heads/main"},
+	})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return hash.String()
+}
+
+func newTestDeployer(t *testing.T, cacheDisabled bool) *Deployer {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	cfg := &config.Config{}
+	cfg.Cache.Dir = filepath.Join(home, "cache")
+	cfg.Cache.Disabled = cacheDisabled
+	return NewDeployer(nil, cfg).withOutput(io.Discard)
+}
+
+func TestCheckoutSourceFromBareRepo(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		noCache bool
+	}{
+		{name: "clone", noCache: true},
+		{name: "cache", noCache: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newBareRepo(t)
+			first := repo.commit(map[string]string{
+				"Dockerfile":  "FROM scratch\n",
+				"api/main.go": "package main\n",
+				"web/app.js":  "console.log(1)\n",
+			})
+
+			d := newTestDeployer(t, tc.noCache)
+			opts := DeployOptions{RepoURL: repo.url, Branch: "main"}
+
+			src, err := d.resolveSource(context.Background(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if src.Revision != first {
+				t.Errorf("revisi = %s, ingin %s", src.Revision, first)
+			}
+			if src.Name != "app" {
+				t.Errorf("nama = %q, ingin app", src.Name)
+			}
+			data, err := os.ReadFile(filepath.Join(src.Dir, "api", "main.go"))
+			if err != nil || string(data) != "package main\n" {
+				t.Errorf("api/main.go = %q, %v", data, err)
+			}
+			// Cleanup melepas lock mirror; tanpa itu checkout berikutnya
+			// akan menunggu
+			src.Cleanup()
+
+			// Commit berikutnya: revisi baru dan file yang berubah terdeteksi
+			second := repo.commit(map[string]string{"web/app.js": "console.log(2)\n"})
+			next, err := d.resolveSource(context.Background(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer next.Cleanup()
+
+			if next.Revision != second {
+				t.Errorf("revisi = %s, ingin %s", next.Revision, second)
+			}
+			// Clone dangkal tidak memiliki commit sebelumnya
+			changed, ok := next.changedSince(first)
+			if tc.noCache {
+				if ok {
+					t.Errorf("changedSince pada clone dangkal = %v, ingin tidak diketahui", changed)
+				}
+				return
+			}
+			if !ok || strings.Join(changed, ",") != "web/app.js" {
+				t.Errorf("changedSince = %v (ok=%v), ingin [web/app.js]", changed, ok)
+			}
+		})
+	}
+}
+
+func TestCheckoutSourceUnknownBranch(t *testing.T) {
+	repo := newBareRepo(t)
+	repo.commit(map[string]string{"Dockerfile": "FROM scratch\n"})
+
+	d := newTestDeployer(t, false)
+	_, err := d.resolveSource(context.Background(), DeployOptions{RepoURL: repo.url, Branch: "release"})
+	if err == nil || !strings.Contains(err.Error(), "branch release tidak ditemukan") {
+		t.Fatalf("error = %v, ingin branch release tidak ditemukan", err)
+	}
+}
@@ -0,0 +1,400 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/kevinburke/ssh_config"
+	"github.com/skeema/knownhosts"
+	"github.com/zakirkun/neon/internal/logger"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// defaultRemoteSocket adalah socket Docker di host remote jika URL ssh://
+// tidak menyebut path.
+const defaultRemoteSocket = "/var/run/docker.sock"
+
+// sshDialers menyimpan dialer per host agar semua client dalam satu proses
+// memakai satu koneksi SSH yang sama.
+var (
+	sshDialersMu sync.Mutex
+	sshDialers   = make(map[string]*sshDialer)
+)
+
+// newSSHClient membuat client Docker untuk host ssh://[user@]host[:port][/socket].
+// Setiap koneksi HTTP ke API adalah channel baru di atas satu koneksi SSH.
+func newSSHClient(host string) (*Client, error) {
+	sshDialersMu.Lock()
+	dialer, ok := sshDialers[host]
+	if !ok {
+		target, err := parseSSHHost(host)
+		if err != nil {
+			sshDialersMu.Unlock()
+			return nil, err
+		}
+		dialer = &sshDialer{target: target}
+		sshDialers[host] = dialer
+	}
+	sshDialersMu.Unlock()
+
+	cli, err := client.NewClientWithOpts(
+		// Host HTTP hanya formalitas; koneksi dibuat oleh dialer
+		client.WithHost("http://docker.example.com"),
+		client.WithDialContext(dialer.DialContext),
+		client.WithVersion("1.45"),
+		client.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client for %s: %v", host, err)
+	}
+
+	return &Client{
+		Client: cli,
+	}, nil
+}
+
+// sshTarget adalah tujuan koneksi hasil gabungan URL dan ~/.ssh/config.
+type sshTarget struct {
+	alias  string
+	user   string
+	host   string
+	port   string
+	socket string
+	keys   []string
+}
+
+func (t *sshTarget) addr() string {
+	return net.JoinHostPort(t.host, t.port)
+}
+
+// parseSSHHost membaca ssh://[user@]host[:port][/socket]. Nilai yang tidak
+// ada di URL diambil dari ~/.ssh/config (HostName, User, Port,
+// IdentityFile), sama seperti perintah ssh.
+func parseSSHHost(raw string) (*sshTarget, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+		return nil, fmt.Errorf("host ssh tidak valid: %s (gunakan ssh://[user@]host[:port])", raw)
+	}
+
+	t := &sshTarget{
+		alias:  u.Hostname(),
+		user:   u.User.Username(),
+		host:   u.Hostname(),
+		port:   u.Port(),
+		socket: u.Path,
+	}
+
+	if h := ssh_config.Get(t.alias, "HostName"); h != "" {
+		t.host = h
+	}
+	if t.user == "" {
+		t.user = ssh_config.Get(t.alias, "User")
+	}
+	if t.user == "" {
+		if cur, err := user.Current(); err == nil {
+			t.user = cur.Username
+		}
+	}
+	if t.port == "" {
+		t.port = ssh_config.Get(t.alias, "Port")
+	}
+	if t.port == "" {
+		t.port = "22"
+	}
+	if t.socket == "" {
+		t.socket = defaultRemoteSocket
+	}
+
+	home, _ := os.UserHomeDir()
+	for _, f := range ssh_config.GetAll(t.alias, "IdentityFile") {
+		if strings.HasPrefix(f, "~/") {
+			f = filepath.Join(home, f[2:])
+		}
+		t.keys = append(t.keys, f)
+	}
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		t.keys = append(t.keys, filepath.Join(home, ".ssh", name))
+	}
+	return t, nil
+}
+
+// sshDialer membuka koneksi ke socket Docker remote. Channel
+// direct-streamlocal dipakai lebih dulu; jika server menolaknya, neon
+// menjalankan `docker system dial-stdio` di host remote.
+type sshDialer struct {
+	target *sshTarget
+
+	mu     sync.Mutex
+	client *ssh.Client
+	stdio  bool
+}
+
+func (d *sshDialer) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	for attempt := 0; ; attempt++ {
+		c, err := d.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := d.open(c)
+		if err == nil {
+			return conn, nil
+		}
+
+		// Koneksi SSH yang terputus dibuka ulang sekali
+		if attempt == 0 && !alive(c) {
+			d.reset(c)
+			continue
+		}
+		return nil, fmt.Errorf("ssh %s: gagal membuka koneksi ke Docker: %v", d.target.alias, err)
+	}
+}
+
+func (d *sshDialer) open(c *ssh.Client) (net.Conn, error) {
+	d.mu.Lock()
+	stdio := d.stdio
+	d.mu.Unlock()
+
+	if !stdio {
+		conn, err := c.Dial("unix", d.target.socket)
+		if err == nil {
+			return noDeadlineConn{conn}, nil
+		}
+		var openErr *ssh.OpenChannelError
+		if !errors.As(err, &openErr) {
+			return nil, err
+		}
+
+		logger.Warnf("ssh %s: forwarding socket ditolak (%v), memakai docker system dial-stdio", d.target.alias, err)
+		d.mu.Lock()
+		d.stdio = true
+		d.mu.Unlock()
+	}
+
+	return dialStdio(c)
+}
+
+// connect mengembalikan koneksi SSH bersama, membukanya jika belum ada.
+func (d *sshDialer) connect(ctx context.Context) (*ssh.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client != nil {
+		return d.client, nil
+	}
+
+	config, err := d.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	addr := d.target.addr()
+	dialer := net.Dialer{Timeout: 15 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %v", d.target.alias, err)
+	}
+
+	// d.mu dipegang selama handshake, jadi handshake dibatasi Timeout dan
+	// ctx agar server yang diam tidak menahan dialer lain tanpa batas
+	conn.SetDeadline(time.Now().Add(config.Timeout))
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if !stop() && err == nil {
+		sshConn.Close()
+		err = ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("ssh %s: handshake dibatalkan: %v", d.target.alias, ctxErr)
+		}
+		return nil, d.describe(err)
+	}
+	conn.SetDeadline(time.Time{})
+
+	d.client = ssh.NewClient(sshConn, chans, reqs)
+	return d.client, nil
+}
+
+func (d *sshDialer) reset(c *ssh.Client) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client == c {
+		d.client.Close()
+		d.client = nil
+	}
+}
+
+func (d *sshDialer) clientConfig() (*ssh.ClientConfig, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, f := range []string{filepath.Join(home, ".ssh", "known_hosts"), "/etc/ssh/ssh_known_hosts"} {
+		if _, err := os.Stat(f); err == nil {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("ssh %s: known_hosts tidak ditemukan; sambungkan sekali dengan `ssh -p %s %s@%s` untuk memverifikasi host key",
+			d.target.alias, d.target.port, d.target.user, d.target.host)
+	}
+	db, err := knownhosts.NewDB(files...)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca known_hosts: %v", err)
+	}
+
+	signers := d.signers()
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("ssh %s: tidak ada key SSH; jalankan ssh-agent atau tambahkan key ke ~/.ssh (key dengan passphrase harus dimuat ke ssh-agent)", d.target.alias)
+	}
+
+	return &ssh.ClientConfig{
+		User:              d.target.user,
+		Auth:              []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback:   db.HostKeyCallback(),
+		HostKeyAlgorithms: db.HostKeyAlgorithms(d.target.addr()),
+		Timeout:           15 * time.Second,
+	}, nil
+}
+
+// signers mengumpulkan key dari ssh-agent lalu dari file identity. Key
+// dengan passphrase dilewati.
+func (d *sshDialer) signers() []ssh.Signer {
+	var signers []ssh.Signer
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			if s, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(signers, s...)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range d.target.keys {
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		s, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			var missing *ssh.PassphraseMissingError
+			if !errors.As(err, &missing) {
+				logger.Warnf("ssh: key %s dilewati: %v", f, err)
+			}
+			continue
+		}
+		signers = append(signers, s)
+	}
+	return signers
+}
+
+// describe mengubah error handshake menjadi pesan yang menjelaskan
+// tindakan yang perlu diambil.
+func (d *sshDialer) describe(err error) error {
+	t := d.target
+	switch {
+	case knownhosts.IsHostKeyChanged(err):
+		return fmt.Errorf("ssh %s: HOST KEY BERUBAH untuk %s. Host mungkin di-reinstall, atau ada serangan man-in-the-middle. "+
+			"Verifikasi fingerprint host lalu hapus entry lama dengan `ssh-keygen -R %s`", t.alias, t.addr(), knownhosts.Normalize(t.addr()))
+	case knownhosts.IsHostUnknown(err):
+		return fmt.Errorf("ssh %s: host %s belum ada di known_hosts; verifikasi host key dengan `ssh -p %s %s@%s` lalu coba lagi",
+			t.alias, t.addr(), t.port, t.user, t.host)
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		return fmt.Errorf("ssh %s: autentikasi sebagai %s ditolak; pastikan public key terdaftar di authorized_keys host", t.alias, t.user)
+	default:
+		return fmt.Errorf("ssh %s: %v", t.alias, err)
+	}
+}
+
+// alive memeriksa apakah koneksi SSH masih hidup.
+func alive(c *ssh.Client) bool {
+	_, _, err := c.SendRequest("keepalive@openssh.com", true, nil)
+	return err == nil
+}
+
+// noDeadlineConn mengabaikan deadline yang tidak didukung channel SSH.
+type noDeadlineConn struct {
+	net.Conn
+}
+
+func (noDeadlineConn) SetDeadline(time.Time) error      { return nil }
+func (noDeadlineConn) SetReadDeadline(time.Time) error  { return nil }
+func (noDeadlineConn) SetWriteDeadline(time.Time) error { return nil }
+
+// stdioConn adalah net.Conn di atas stdin/stdout `docker system dial-stdio`.
+type stdioConn struct {
+	session *ssh.Session
+	stdin   io.WriteCloser
+	stdout  io.Reader
+}
+
+func dialStdio(c *ssh.Client) (net.Conn, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	if err := session.Start("docker system dial-stdio"); err != nil {
+		session.Close()
+		return nil, err
+	}
+	return &stdioConn{session: session, stdin: stdin, stdout: stdout}, nil
+}
+
+func (c *stdioConn) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *stdioConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+func (c *stdioConn) Close() error {
+	c.stdin.Close()
+	return c.session.Close()
+}
+
+// CloseWrite dipakai client Docker untuk half-close pada koneksi hijack.
+func (c *stdioConn) CloseWrite() error {
+	return c.stdin.Close()
+}
+
+func (c *stdioConn) LocalAddr() net.Addr              { return stdioAddr{} }
+func (c *stdioConn) RemoteAddr() net.Addr             { return stdioAddr{} }
+func (c *stdioConn) SetDeadline(time.Time) error      { return nil }
+func (c *stdioConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *stdioConn) SetWriteDeadline(time.Time) error { return nil }
+
+type stdioAddr struct{}
+
+func (stdioAddr) Network() string { return "ssh" }
+func (stdioAddr) String() string  { return "docker system dial-stdio" }
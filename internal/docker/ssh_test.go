@@ -0,0 +1,373 @@
+package docker
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skeema/knownhosts"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseSSHHost(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		raw     string
+		user    string
+		host    string
+		port    string
+		socket  string
+		wantErr bool
+	}{
+		{raw: "ssh://deploy@neon-test.invalid", user: "deploy", host: "neon-test.invalid", port: "22", socket: defaultRemoteSocket},
+		{raw: "ssh://deploy@neon-test.invalid:2222", user: "deploy", host: "neon-test.invalid", port: "2222", socket: defaultRemoteSocket},
+		{raw: "ssh://root@10.0.0.5:22/run/user/1000/docker.sock", user: "root", host: "10.0.0.5", port: "22", socket: "/run/user/1000/docker.sock"},
+		{raw: "ssh://ops@[fd00::1]:2200", user: "ops", host: "fd00::1", port: "2200", socket: defaultRemoteSocket},
+		{raw: "tcp://neon-test.invalid:2376", wantErr: true},
+		{raw: "ssh://", wantErr: true},
+		{raw: "neon-test.invalid", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			target, err := parseSSHHost(tt.raw)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "host ssh tidak valid") {
+					t.Fatalf("error = %v, ingin host ssh tidak valid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target.user != tt.user || target.host != tt.host || target.port != tt.port || target.socket != tt.socket {
+				t.Errorf("target = %s@%s:%s%s, ingin %s@%s:%s%s",
+					target.user, target.host, target.port, target.socket, tt.user, tt.host, tt.port, tt.socket)
+			}
+			if len(target.keys) == 0 || !strings.HasSuffix(target.keys[len(target.keys)-1], filepath.Join(".ssh", "id_rsa")) {
+				t.Errorf("keys = %v, ingin diakhiri ~/.ssh/id_rsa", target.keys)
+			}
+		})
+	}
+}
+
+// sshServer adalah server SSH di dalam proses yang meneruskan channel
+// direct-streamlocal ke echo, pengganti socket Docker di host remote.
+type sshServer struct {
+	addr    string
+	hostKey ssh.Signer
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	channels int
+}
+
+func newSSHServer(t *testing.T, clientKey ssh.PublicKey) *sshServer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	s := &sshServer{addr: l.Addr().String(), hostKey: hostKey}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go s.serve(conn, config)
+		}
+	}()
+	t.Cleanup(s.drop)
+	return s
+}
+
+func (s *sshServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for ch := range chans {
+		if ch.ChannelType() != "direct-streamlocal@openssh.com" {
+			ch.Reject(ssh.UnknownChannelType, "tidak didukung")
+			continue
+		}
+		channel, requests, err := ch.Accept()
+		if err != nil {
+			continue
+		}
+		s.mu.Lock()
+		s.channels++
+		s.mu.Unlock()
+		go ssh.DiscardRequests(requests)
+		go func() {
+			io.Copy(channel, channel)
+			channel.Close()
+		}()
+	}
+}
+
+// drop memutus semua koneksi SSH dari sisi server.
+func (s *sshServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+}
+
+func (s *sshServer) stats() (conns, channels int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns), s.channels
+}
+
+// sshHome menyiapkan HOME sementara dengan key client di ~/.ssh/id_ed25519
+// dan known_hosts kosong, lalu mengembalikan public key client.
+func sshHome(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "id_ed25519"), pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer.PublicKey()
+}
+
+func newTestDialer(t *testing.T, addr string) *sshDialer {
+	t.Helper()
+	target, err := parseSSHHost("ssh://neon@" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sshDialer{target: target}
+}
+
+func echo(t *testing.T, conn net.Conn, msg string) {
+	t.Helper()
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != msg {
+		t.Fatalf("echo = %q, ingin %q", buf, msg)
+	}
+}
+
+func TestSSHDialerReusesConnection(t *testing.T) {
+	clientKey := sshHome(t)
+	srv := newSSHServer(t, clientKey)
+	rewriteKnownHosts(t, srv.addr, srv.hostKey.PublicKey())
+
+	d := newTestDialer(t, srv.addr)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		conn, err := d.DialContext(ctx, "tcp", "docker.example.com:80")
+		if err != nil {
+			t.Fatal(err)
+		}
+		echo(t, conn, "ping")
+		conn.Close()
+	}
+	if conns, channels := srv.stats(); conns != 1 || channels != 3 {
+		t.Errorf("server menerima %d koneksi dan %d channel, ingin 1 dan 3", conns, channels)
+	}
+
+	// Koneksi yang diputus server dibuka ulang pada dial berikutnya
+	srv.drop()
+	conn, err := d.DialContext(ctx, "tcp", "docker.example.com:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo(t, conn, "pong")
+	conn.Close()
+	if conns, _ := srv.stats(); conns != 2 {
+		t.Errorf("server menerima %d koneksi setelah reconnect, ingin 2", conns)
+	}
+}
+
+func TestNewSSHClientSharesDialer(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	host := "ssh://neon@neon-test.invalid:2222"
+	t.Cleanup(func() {
+		sshDialersMu.Lock()
+		delete(sshDialers, host)
+		sshDialersMu.Unlock()
+	})
+
+	dialer := func() *sshDialer {
+		if _, err := newSSHClient(host); err != nil {
+			t.Fatal(err)
+		}
+		sshDialersMu.Lock()
+		defer sshDialersMu.Unlock()
+		return sshDialers[host]
+	}
+	first := dialer()
+	if first == nil || first.target.port != "2222" {
+		t.Fatalf("dialer untuk %s tidak disimpan", host)
+	}
+	if second := dialer(); second != first {
+		t.Error("client kedua membuat dialer baru, ingin memakai dialer yang sama")
+	}
+
+	if _, err := newSSHClient("ssh://"); err == nil {
+		t.Fatal("newSSHClient menerima host tidak valid")
+	}
+}
+
+func TestSSHDialerHostKeyMismatch(t *testing.T) {
+	clientKey := sshHome(t)
+	srv := newSSHServer(t, clientKey)
+
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	otherKey, _ := ssh.NewSignerFromKey(other)
+	rewriteKnownHosts(t, srv.addr, otherKey.PublicKey())
+
+	_, err := newTestDialer(t, srv.addr).DialContext(context.Background(), "tcp", "")
+	if err == nil {
+		t.Fatal("host key yang berubah diterima")
+	}
+	for _, want := range []string{"HOST KEY BERUBAH untuk " + srv.addr, "ssh-keygen -R " + knownhosts.Normalize(srv.addr)} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, ingin mengandung %q", err, want)
+		}
+	}
+}
+
+func TestSSHDialerUnknownHost(t *testing.T) {
+	clientKey := sshHome(t)
+	srv := newSSHServer(t, clientKey)
+
+	// known_hosts hanya berisi host lain
+	rewriteKnownHosts(t, "neon-test.invalid:22", srv.hostKey.PublicKey())
+
+	_, err := newTestDialer(t, srv.addr).DialContext(context.Background(), "tcp", "")
+	if err == nil {
+		t.Fatal("host yang tidak dikenal diterima")
+	}
+	_, port, _ := net.SplitHostPort(srv.addr)
+	for _, want := range []string{"host " + srv.addr + " belum ada di known_hosts", "ssh -p " + port + " neon@127.0.0.1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, ingin mengandung %q", err, want)
+		}
+	}
+}
+
+func TestSSHDialerHandshakeHonorsContext(t *testing.T) {
+	sshHome(t)
+
+	// Server yang menerima koneksi TCP tetapi tidak pernah membalas handshake
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, c := range conns {
+				c.Close()
+			}
+		}()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	d := newTestDialer(t, l.Addr().String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = d.DialContext(ctx, "tcp", "")
+	if err == nil || !strings.Contains(err.Error(), "handshake dibatalkan") {
+		t.Fatalf("error = %v, ingin handshake dibatalkan", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("handshake berhenti setelah %s, ingin mengikuti ctx", elapsed)
+	}
+
+	// Lock dialer sudah dilepas sehingga dial berikutnya tidak tertahan
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel2)
+	if _, err := d.DialContext(ctx2, "tcp", ""); err == nil {
+		t.Fatal("dial ke server yang diam berhasil")
+	}
+}
+
+// rewriteKnownHosts mengganti isi ~/.ssh/known_hosts dengan satu entry.
+func rewriteKnownHosts(t *testing.T, addr string, key ssh.PublicKey) {
+	t.Helper()
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, key) + "\n"
+	path := filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts")
+	if err := os.WriteFile(path, []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,290 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-units"
+)
+
+// NodeCapacity adalah kapasitas node dan total reservasi task yang
+// ditempatkan di node tersebut.
+type NodeCapacity struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	State          string `json:"state"`
+	Availability   string `json:"availability"`
+	Schedulable    bool   `json:"schedulable"`
+	NanoCPUs       int64  `json:"nano_cpus"`
+	MemoryBytes    int64  `json:"memory_bytes"`
+	ReservedCPUs   int64  `json:"reserved_nano_cpus"`
+	ReservedMemory int64  `json:"reserved_memory_bytes"`
+	Tasks          int    `json:"tasks"`
+
+	node *swarm.Node
+	// services menghitung task per service untuk max replicas per node.
+	services map[string]uint64
+}
+
+// FreeCPUs adalah sisa CPU (nano CPU) yang belum direservasi.
+func (n *NodeCapacity) FreeCPUs() int64 { return n.NanoCPUs - n.ReservedCPUs }
+
+// FreeMemory adalah sisa memori yang belum direservasi.
+func (n *NodeCapacity) FreeMemory() int64 { return n.MemoryBytes - n.ReservedMemory }
+
+// PendingTask adalah task yang belum mendapat node, beserta alasannya.
+type PendingTask struct {
+	Task        string `json:"task"`
+	Service     string `json:"service"`
+	NanoCPUs    int64  `json:"nano_cpus"`
+	MemoryBytes int64  `json:"memory_bytes"`
+	Reason      string `json:"reason"`
+	// Fits menandai task yang sebenarnya muat di salah satu node, sehingga
+	// tertahan karena hal lain (lihat Error).
+	Fits  bool   `json:"fits"`
+	Error string `json:"error,omitempty"`
+}
+
+// CapacityReport adalah reservasi CPU dan memori per node serta task yang
+// tertahan di pending.
+type CapacityReport struct {
+	Nodes   []NodeCapacity `json:"nodes"`
+	Pending []PendingTask  `json:"pending"`
+}
+
+// Capacity menjumlahkan reservasi task yang sudah ditempatkan per node dan
+// menjelaskan mengapa task pending tidak dapat ditempatkan.
+func (m *Manager) Capacity(ctx context.Context) (*CapacityReport, error) {
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	services, err := m.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(services))
+	for _, s := range services {
+		names[s.ID] = s.Spec.Name
+	}
+
+	report := &CapacityReport{Nodes: nodeCapacities(nodes, tasks)}
+	nodeNames := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		nodeNames[n.ID] = n.Description.Hostname
+	}
+
+	for i := range tasks {
+		t := &tasks[i]
+		if t.DesiredState != swarm.TaskStateRunning || t.NodeID != "" {
+			continue
+		}
+		cpu, mem := reservations(&t.Spec)
+		p := PendingTask{
+			Task:        taskName(names[t.ServiceID], t, nodeNames),
+			Service:     names[t.ServiceID],
+			NanoCPUs:    cpu,
+			MemoryBytes: mem,
+			Error:       t.Status.Err,
+		}
+		var node *NodeCapacity
+		node, p.Reason = placeTask(report.Nodes, &t.Spec, t.ServiceID)
+		p.Fits = node != nil
+		if p.Fits {
+			p.Reason = "muat di " + node.Name
+		}
+		report.Pending = append(report.Pending, p)
+	}
+	sort.Slice(report.Pending, func(i, j int) bool { return report.Pending[i].Task < report.Pending[j].Task })
+	return report, nil
+}
+
+// ScalePlan adalah hasil simulasi scale service.
+type ScalePlan struct {
+	Service  string `json:"service"`
+	Current  uint64 `json:"current"`
+	Replicas uint64 `json:"replicas"`
+	// Placements adalah jumlah task tambahan per node.
+	Placements map[string]int `json:"placements,omitempty"`
+	// Unplaced adalah jumlah task tambahan yang tidak mendapat node.
+	Unplaced int    `json:"unplaced"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Fits melaporkan apakah semua replika dapat ditempatkan.
+func (p *ScalePlan) Fits() bool { return p.Unplaced == 0 }
+
+// PlanScale mensimulasikan scale service ke replicas tanpa mengubah
+// cluster. Task tambahan ditempatkan seperti scheduler swarm: ke node yang
+// memenuhi constraint, max replicas per node, dan memiliki sisa reservasi,
+// dengan menyebar ke node yang paling sedikit menjalankan task service.
+func (m *Manager) PlanScale(ctx context.Context, name string, replicas uint64) (*ScalePlan, error) {
+	svc, err := m.InspectService(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if svc.Spec.Mode.Replicated == nil {
+		return nil, fmt.Errorf("service %s berjalan dalam mode global dan tidak dapat di-scale", svc.Spec.Name)
+	}
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ScalePlan{Service: svc.Spec.Name, Current: getReplicaCount(svc), Replicas: replicas}
+	capacity := nodeCapacities(nodes, tasks)
+
+	// Task yang sudah punya node tidak perlu ditempatkan ulang
+	var placed uint64
+	for _, t := range tasks {
+		if t.ServiceID == svc.ID && t.DesiredState == swarm.TaskStateRunning && t.NodeID != "" {
+			placed++
+		}
+	}
+	for placed < replicas {
+		node, reason := placeTask(capacity, &svc.Spec.TaskTemplate, svc.ID)
+		if node == nil {
+			plan.Unplaced = int(replicas - placed)
+			plan.Reason = reason
+			break
+		}
+		if plan.Placements == nil {
+			plan.Placements = make(map[string]int)
+		}
+		plan.Placements[node.Name]++
+		reserve(node, &svc.Spec.TaskTemplate, svc.ID)
+		placed++
+	}
+	return plan, nil
+}
+
+// nodeCapacities menghitung reservasi task yang sudah ditempatkan per node,
+// diurutkan berdasarkan hostname.
+func nodeCapacities(nodes []swarm.Node, tasks []swarm.Task) []NodeCapacity {
+	result := make([]NodeCapacity, len(nodes))
+	index := make(map[string]int, len(nodes))
+	for i := range nodes {
+		n := &nodes[i]
+		result[i] = NodeCapacity{
+			ID:           n.ID,
+			Name:         n.Description.Hostname,
+			State:        string(n.Status.State),
+			Availability: string(n.Spec.Availability),
+			Schedulable:  Schedulable(n, nil),
+			NanoCPUs:     n.Description.Resources.NanoCPUs,
+			MemoryBytes:  n.Description.Resources.MemoryBytes,
+			node:         n,
+			services:     make(map[string]uint64),
+		}
+		index[n.ID] = i
+	}
+	for i := range tasks {
+		t := &tasks[i]
+		j, ok := index[t.NodeID]
+		if !ok || t.DesiredState != swarm.TaskStateRunning {
+			continue
+		}
+		reserve(&result[j], &t.Spec, t.ServiceID)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func reserve(n *NodeCapacity, spec *swarm.TaskSpec, serviceID string) {
+	cpu, mem := reservations(spec)
+	n.ReservedCPUs += cpu
+	n.ReservedMemory += mem
+	n.Tasks++
+	n.services[serviceID]++
+}
+
+func reservations(spec *swarm.TaskSpec) (nanoCPUs, memory int64) {
+	if r := spec.Resources; r != nil && r.Reservations != nil {
+		return r.Reservations.NanoCPUs, r.Reservations.MemoryBytes
+	}
+	return 0, 0
+}
+
+// placeTask memilih node untuk task dengan spec, atau mengembalikan alasan
+// jika tidak ada node yang cocok. Alasan menyebut hambatan terdekat:
+// node tidak aktif, constraint, max replicas per node, lalu reservasi.
+func placeTask(nodes []NodeCapacity, spec *swarm.TaskSpec, serviceID string) (*NodeCapacity, string) {
+	cpu, mem := reservations(spec)
+	var (
+		constraints []string
+		maxPerNode  uint64
+	)
+	if p := spec.Placement; p != nil {
+		constraints, maxPerNode = p.Constraints, p.MaxReplicas
+	}
+
+	var (
+		best                      *NodeCapacity
+		active, matched, belowMax int
+		unmet                     *Constraint
+		maxFreeCPU, maxFreeMemory int64
+	)
+	for i := range nodes {
+		n := &nodes[i]
+		if !n.Schedulable {
+			continue
+		}
+		active++
+		if c := MatchConstraints(n.node, constraints); c != nil {
+			unmet = c
+			continue
+		}
+		matched++
+		if maxPerNode > 0 && n.services[serviceID] >= maxPerNode {
+			continue
+		}
+		belowMax++
+		maxFreeCPU = max(maxFreeCPU, n.FreeCPUs())
+		maxFreeMemory = max(maxFreeMemory, n.FreeMemory())
+		if n.FreeCPUs() < cpu || n.FreeMemory() < mem {
+			continue
+		}
+		// Sebarkan seperti scheduler swarm: paling sedikit task service,
+		// lalu paling sedikit task total
+		if best == nil || n.services[serviceID] < best.services[serviceID] ||
+			(n.services[serviceID] == best.services[serviceID] && n.Tasks < best.Tasks) {
+			best = n
+		}
+	}
+	if best != nil {
+		return best, ""
+	}
+
+	switch {
+	case active == 0:
+		return nil, "tidak ada node yang ready dan active"
+	case matched == 0:
+		return nil, fmt.Sprintf("constraint %s tidak dipenuhi node mana pun", unmet)
+	case belowMax == 0:
+		return nil, fmt.Sprintf("batas max replicas per node (%d) tercapai di semua %d node yang cocok", maxPerNode, matched)
+	}
+	var short []string
+	if mem > maxFreeMemory {
+		short = append(short, fmt.Sprintf("memori: butuh %s, sisa terbesar %s",
+			units.BytesSize(float64(mem)), units.BytesSize(float64(max(maxFreeMemory, 0)))))
+	}
+	if cpu > maxFreeCPU {
+		short = append(short, fmt.Sprintf("CPU: butuh %g, sisa terbesar %g", float64(cpu)/1e9, float64(max(maxFreeCPU, 0))/1e9))
+	}
+	if len(short) == 0 {
+		short = append(short, "tidak ada node dengan sisa CPU dan memori yang cukup sekaligus")
+	}
+	return nil, "reservasi tidak muat di node mana pun (" + strings.Join(short, "; ") + ")"
+}
@@ -0,0 +1,104 @@
+package swarm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// Constraint adalah satu placement constraint service, misalnya
+// "node.labels.zone==eu-1" atau "node.role!=manager".
+type Constraint struct {
+	Key   string
+	Equal bool
+	Value string
+}
+
+func (c Constraint) String() string {
+	op := "!="
+	if c.Equal {
+		op = "=="
+	}
+	return c.Key + op + c.Value
+}
+
+// ParseConstraint membaca constraint dengan operator == atau !=.
+func ParseConstraint(s string) (Constraint, error) {
+	for _, op := range []string{"==", "!="} {
+		if key, value, ok := strings.Cut(s, op); ok {
+			return Constraint{
+				Key:   strings.TrimSpace(key),
+				Equal: op == "==",
+				Value: strings.TrimSpace(value),
+			}, nil
+		}
+	}
+	return Constraint{}, fmt.Errorf("constraint %q tidak valid (gunakan == atau !=)", s)
+}
+
+// Match melaporkan apakah node memenuhi constraint. Perbandingan nilai
+// tidak membedakan huruf besar/kecil, sama seperti scheduler swarm.
+func (c Constraint) Match(node *swarm.Node) bool {
+	actual, ok := nodeAttribute(node, c.Key)
+	if !ok {
+		// Label yang tidak ada hanya memenuhi constraint !=
+		return !c.Equal
+	}
+	return strings.EqualFold(actual, c.Value) == c.Equal
+}
+
+func nodeAttribute(node *swarm.Node, key string) (string, bool) {
+	switch strings.ToLower(key) {
+	case "node.id":
+		return node.ID, true
+	case "node.hostname":
+		return node.Description.Hostname, true
+	case "node.role":
+		return string(node.Spec.Role), true
+	case "node.platform.os":
+		return node.Description.Platform.OS, true
+	case "node.platform.arch":
+		return node.Description.Platform.Architecture, true
+	}
+	if label, ok := strings.CutPrefix(key, "node.labels."); ok {
+		v, ok := node.Spec.Labels[label]
+		return v, ok
+	}
+	if label, ok := strings.CutPrefix(key, "engine.labels."); ok {
+		v, ok := node.Description.Engine.Labels[label]
+		return v, ok
+	}
+	return "", false
+}
+
+// MatchConstraints mengembalikan constraint pertama yang tidak dipenuhi
+// node, atau nil jika semua dipenuhi. Constraint yang tidak valid
+// diabaikan.
+func MatchConstraints(node *swarm.Node, constraints []string) *Constraint {
+	for _, s := range constraints {
+		c, err := ParseConstraint(s)
+		if err != nil {
+			continue
+		}
+		if !c.Match(node) {
+			return &c
+		}
+	}
+	return nil
+}
+
+// Schedulable melaporkan apakah task dapat ditempatkan di node: node
+// Ready, availability active, dan memenuhi constraint.
+func Schedulable(node *swarm.Node, constraints []string) bool {
+	return node.Status.State == swarm.NodeStateReady &&
+		node.Spec.Availability == swarm.NodeAvailabilityActive &&
+		MatchConstraints(node, constraints) == nil
+}
+
+func serviceConstraints(svc *swarm.Service) []string {
+	if p := svc.Spec.TaskTemplate.Placement; p != nil {
+		return p.Constraints
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package swarm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// LogOptions memilih rentang log service. Since dan Until menerima format
+// yang sama dengan `docker service logs` (durasi relatif atau timestamp).
+type LogOptions struct {
+	Since  string
+	Until  string
+	Tail   string
+	Follow bool
+}
+
+// LogLine adalah satu baris log dari satu task.
+type LogLine struct {
+	Time    time.Time `json:"time"`
+	Service string    `json:"service"`
+	// Task adalah nama task: service.slot untuk service replicated,
+	// service.<node> untuk service global.
+	Task    string `json:"task"`
+	TaskID  string `json:"task_id"`
+	Node    string `json:"node"`
+	Stream  string `json:"stream"`
+	Message string `json:"message"`
+}
+
+// ServiceLogs membaca log semua task service dan memanggil fn untuk setiap
+// baris. Stream stdout dan stderr dipisahkan dari header multiplexing
+// Docker. fn dapat dipanggil dari beberapa goroutine sekaligus saat
+// ServiceLogs dijalankan paralel untuk beberapa service.
+func (m *Manager) ServiceLogs(ctx context.Context, name string, opts LogOptions, fn func(LogLine)) error {
+	svc, err := m.InspectService(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	rc, err := m.client.ServiceLogs(ctx, svc.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      opts.Since,
+		Until:      opts.Until,
+		Tail:       opts.Tail,
+		Follow:     opts.Follow,
+		Timestamps: true,
+		Details:    true,
+	})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	names := &taskNames{m: m, service: svc.Spec.Name, tasks: make(map[string]string)}
+	emit := func(stream string, line []byte) {
+		l := names.parse(ctx, string(line))
+		l.Stream = stream
+		fn(l)
+	}
+	stdout := &lineWriter{stream: "stdout", emit: emit}
+	stderr := &lineWriter{stream: "stderr", emit: emit}
+
+	// Service dengan TTY mengirim stream mentah tanpa header
+	if cs := svc.Spec.TaskTemplate.ContainerSpec; cs != nil && cs.TTY {
+		_, err = io.Copy(stdout, rc)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, rc)
+	}
+	stdout.Flush()
+	stderr.Flush()
+
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// taskNames menerjemahkan ID task dan node pada detail log menjadi nama.
+type taskNames struct {
+	m       *Manager
+	service string
+
+	mu    sync.Mutex
+	tasks map[string]string
+	nodes map[string]string
+}
+
+// parse membaca baris "<timestamp> <detail> <pesan>". Detail berisi
+// key=value dipisah koma dengan ID node, service, dan task.
+func (n *taskNames) parse(ctx context.Context, line string) LogLine {
+	l := LogLine{Service: n.service, Message: line}
+
+	ts, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return l
+	}
+	if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		l.Time = t
+		l.Message = rest
+	}
+
+	details, msg, _ := strings.Cut(l.Message, " ")
+	if !strings.Contains(details, "com.docker.swarm.") {
+		return l
+	}
+	var nodeID string
+	for _, kv := range strings.Split(details, ",") {
+		key, value, _ := strings.Cut(kv, "=")
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		switch key {
+		case "com.docker.swarm.task.id":
+			l.TaskID = value
+		case "com.docker.swarm.node.id":
+			nodeID = value
+		}
+	}
+	if l.TaskID == "" && nodeID == "" {
+		return l
+	}
+	l.Message = msg
+	l.Node = n.node(ctx, nodeID)
+	l.Task = n.task(ctx, l.TaskID, l.Node)
+	return l
+}
+
+func (n *taskNames) task(ctx context.Context, id, node string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if name, ok := n.tasks[id]; ok {
+		return name
+	}
+	name := n.service + "." + node
+	if t, _, err := n.m.client.TaskInspectWithRaw(ctx, id); err == nil && t.Slot > 0 {
+		name = fmt.Sprintf("%s.%d", n.service, t.Slot)
+	}
+	n.tasks[id] = name
+	return name
+}
+
+func (n *taskNames) node(ctx context.Context, id string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if name, ok := n.nodes[id]; ok {
+		return name
+	}
+	// Node baru bisa bergabung selama --follow; muat ulang daftar node
+	if names, err := n.m.NodeNames(ctx); err == nil {
+		n.nodes = names
+	}
+	name, ok := n.nodes[id]
+	if !ok {
+		if n.nodes == nil {
+			n.nodes = make(map[string]string)
+		}
+		name = id
+		if len(name) > 12 {
+			name = name[:12]
+		}
+		n.nodes[id] = name
+	}
+	return name
+}
+
+// lineWriter memotong output menjadi baris dan memanggil emit per baris.
+type lineWriter struct {
+	stream string
+	emit   func(stream string, line []byte)
+	buf    bytes.Buffer
+}
+
+func (w *lineWriter) Write(data []byte) (int, error) {
+	w.buf.Write(data)
+	for {
+		line, err := w.buf.ReadBytes('\n')
+		if err != nil {
+			// Baris belum lengkap, simpan untuk Write berikutnya
+			w.buf.Reset()
+			w.buf.Write(line)
+			return len(data), nil
+		}
+		w.emit(w.stream, bytes.TrimRight(line, "\r\n"))
+	}
+}
+
+// Flush mengirim sisa baris yang belum diakhiri newline.
+func (w *lineWriter) Flush() {
+	if w.buf.Len() > 0 {
+		w.emit(w.stream, w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
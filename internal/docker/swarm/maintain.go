@@ -0,0 +1,196 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-units"
+)
+
+// pollInterval adalah jeda antar pemeriksaan saat menunggu perubahan state
+// cluster.
+const pollInterval = 2 * time.Second
+
+// Pending mengembalikan nama service replicated yang jumlah task running-nya
+// di luar node exclude masih kurang dari jumlah replika. Task dengan
+// healthcheck baru berstatus running setelah sehat. exclude kosong berarti
+// semua node dihitung.
+func (m *Manager) Pending(ctx context.Context, exclude string) ([]string, error) {
+	services, err := m.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	running := make(map[string]uint64)
+	for _, t := range tasks {
+		if t.NodeID == exclude && exclude != "" {
+			continue
+		}
+		if t.DesiredState == swarm.TaskStateRunning && t.Status.State == swarm.TaskStateRunning {
+			running[t.ServiceID]++
+		}
+	}
+
+	var pending []string
+	for i := range services {
+		svc := &services[i]
+		replicas := getReplicaCount(svc)
+		if svc.Spec.Mode.Replicated == nil || replicas == 0 {
+			continue
+		}
+		if running[svc.ID] < replicas {
+			pending = append(pending, fmt.Sprintf("%s (%d/%d)", svc.Spec.Name, running[svc.ID], replicas))
+		}
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
+
+// WaitConverged menunggu sampai semua service replicated berjalan penuh di
+// luar node exclude, atau timeout.
+func (m *Manager) WaitConverged(ctx context.Context, exclude string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		pending, err := m.Pending(ctx, exclude)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout setelah %s; service belum berjalan penuh: %s", timeout, strings.Join(pending, ", "))
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// WaitNodeReady menunggu sampai node berstatus Ready, atau timeout. Error
+// API selama menunggu diabaikan karena manager bisa sedang memilih leader
+// baru.
+func (m *Manager) WaitNodeReady(ctx context.Context, nodeID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	state := "tidak diketahui"
+	for {
+		node, err := m.InspectNode(ctx, nodeID)
+		if err == nil {
+			if node.Status.State == swarm.NodeStateReady {
+				return nil
+			}
+			state = string(node.Status.State)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout setelah %s; node %s masih %s", timeout, nodeID, state)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// CheckDrainCapacity memastikan reservasi CPU dan memori task di node
+// nodeID masih muat di node lain yang dapat dijadwalkan. Perhitungan
+// memakai total kapasitas, bukan penempatan per node, sehingga hanya
+// menangkap kekurangan kapasitas yang jelas.
+func (m *Manager) CheckDrainCapacity(ctx context.Context, nodeID string) error {
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return err
+	}
+
+	var freeCPU, freeMem, needCPU, needMem int64
+	available := make(map[string]bool)
+	for i := range nodes {
+		n := &nodes[i]
+		if n.ID == nodeID || !Schedulable(n, nil) {
+			continue
+		}
+		available[n.ID] = true
+		freeCPU += n.Description.Resources.NanoCPUs
+		freeMem += n.Description.Resources.MemoryBytes
+	}
+
+	for _, t := range tasks {
+		if t.DesiredState != swarm.TaskStateRunning {
+			continue
+		}
+		res := t.Spec.Resources
+		if res == nil || res.Reservations == nil {
+			continue
+		}
+		switch {
+		case t.NodeID == nodeID:
+			// Task global tidak dipindahkan
+			if t.Slot == 0 {
+				continue
+			}
+			needCPU += res.Reservations.NanoCPUs
+			needMem += res.Reservations.MemoryBytes
+		case available[t.NodeID]:
+			freeCPU -= res.Reservations.NanoCPUs
+			freeMem -= res.Reservations.MemoryBytes
+		}
+	}
+
+	if needCPU > freeCPU {
+		return fmt.Errorf("reservasi CPU task di node ini (%g) melebihi sisa kapasitas node lain (%g)", float64(needCPU)/1e9, float64(freeCPU)/1e9)
+	}
+	if needMem > freeMem {
+		return fmt.Errorf("reservasi memori task di node ini (%s) melebihi sisa kapasitas node lain (%s)",
+			units.BytesSize(float64(needMem)), units.BytesSize(float64(freeMem)))
+	}
+	return nil
+}
+
+// Rebalance memaksa update service replicated dengan lebih dari satu
+// replika agar scheduler menyebarkan ulang task-nya, termasuk ke node yang
+// baru aktif kembali. Mengembalikan nama service yang di-update.
+func (m *Manager) Rebalance(ctx context.Context) ([]string, error) {
+	services, err := m.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []string
+	for i := range services {
+		svc := &services[i]
+		if svc.Spec.Mode.Replicated == nil || getReplicaCount(svc) < 2 {
+			continue
+		}
+		spec := svc.Spec
+		spec.TaskTemplate.ForceUpdate++
+		if _, err := m.client.ServiceUpdate(ctx, svc.ID, svc.Version, spec, types.ServiceUpdateOptions{}); err != nil {
+			return updated, fmt.Errorf("rebalance %s: %v", svc.Spec.Name, err)
+		}
+		updated = append(updated, svc.Spec.Name)
+	}
+	return updated, nil
+}
+
+// LocalNodeID mengembalikan ID node daemon yang sedang dipakai client.
+func (m *Manager) LocalNodeID(ctx context.Context) (string, error) {
+	info, err := m.client.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+	return info.Swarm.NodeID, nil
+}
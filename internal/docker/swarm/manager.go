@@ -3,6 +3,7 @@ package swarm
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
@@ -18,20 +19,66 @@ func NewManager(client *docker.Client) *Manager {
 	return &Manager{client: client}
 }
 
-func (m *Manager) InitSwarm(ctx context.Context, advertiseAddr string) (string, error) {
+// DefaultListenAddr adalah alamat listen manager jika tidak ditentukan.
+const DefaultListenAddr = "0.0.0.0:2377"
+
+// InitOptions adalah parameter pembuatan swarm baru. AdvertiseAddr dan
+// DataPathAddr boleh berupa IP, IP:port, atau nama interface; kosong
+// berarti dideteksi oleh Docker.
+type InitOptions struct {
+	AdvertiseAddr string
+	ListenAddr    string
+	DataPathAddr  string
+	DataPathPort  uint32
+	// DefaultAddrPool adalah daftar CIDR untuk subnet network overlay, dengan
+	// ukuran subnet SubnetSize (default 24).
+	DefaultAddrPool []string
+	SubnetSize      uint32
+	Autolock        bool
+	Availability    swarm.NodeAvailability
+}
+
+// InitSwarm membuat swarm baru dengan node ini sebagai manager pertama dan
+// mengembalikan ID node.
+func (m *Manager) InitSwarm(ctx context.Context, opts InitOptions) (string, error) {
+	if opts.ListenAddr == "" {
+		opts.ListenAddr = DefaultListenAddr
+	}
 	req := swarm.InitRequest{
-		ListenAddr:    "0.0.0.0:2377",
-		AdvertiseAddr: advertiseAddr,
+		ListenAddr:       opts.ListenAddr,
+		AdvertiseAddr:    opts.AdvertiseAddr,
+		DataPathAddr:     opts.DataPathAddr,
+		DataPathPort:     opts.DataPathPort,
+		DefaultAddrPool:  opts.DefaultAddrPool,
+		SubnetSize:       opts.SubnetSize,
+		Availability:     opts.Availability,
+		AutoLockManagers: opts.Autolock,
 	}
 	return m.client.SwarmInit(ctx, req)
 }
 
-func (m *Manager) JoinSwarm(ctx context.Context, remoteAddr, token string) error {
+// JoinOptions adalah parameter bergabung ke swarm. Peran node (worker atau
+// manager) ditentukan oleh token.
+type JoinOptions struct {
+	RemoteAddrs   []string
+	Token         string
+	AdvertiseAddr string
+	ListenAddr    string
+	DataPathAddr  string
+	Availability  swarm.NodeAvailability
+}
+
+func (m *Manager) JoinSwarm(ctx context.Context, opts JoinOptions) error {
+	if opts.ListenAddr == "" {
+		opts.ListenAddr = DefaultListenAddr
+	}
 	req := swarm.JoinRequest{
-		ListenAddr:    "0.0.0.0:2377",
-		AdvertiseAddr: "eth0",
-		RemoteAddrs:   []string{remoteAddr},
-		JoinToken:     token,
+		ListenAddr:    opts.ListenAddr,
+		AdvertiseAddr: opts.AdvertiseAddr,
+		DataPathAddr:  opts.DataPathAddr,
+		RemoteAddrs:   opts.RemoteAddrs,
+		JoinToken:     opts.Token,
+		Availability:  opts.Availability,
 	}
 	return m.client.SwarmJoin(ctx, req)
 }
@@ -40,6 +87,128 @@ func (m *Manager) LeaveSwarm(ctx context.Context, force bool) error {
 	return m.client.SwarmLeave(ctx, force)
 }
 
+// LeavePlan menjelaskan dampak node ini meninggalkan swarm.
+type LeavePlan struct {
+	NodeID  string
+	Manager bool
+	// Managers dan Reachable adalah jumlah manager dan manager yang
+	// reachable, termasuk node ini.
+	Managers  int
+	Reachable int
+	Services  int
+	Nodes     int
+}
+
+// LastManager melaporkan apakah node ini manager terakhir; state swarm
+// (service, secret, config) hilang jika node ini keluar.
+func (p *LeavePlan) LastManager() bool {
+	return p.Manager && p.Managers <= 1
+}
+
+// LosesQuorum melaporkan apakah manager yang tersisa tidak lagi mencapai
+// mayoritas setelah node ini keluar, sehingga cluster tidak dapat dikelola.
+// Manager yang keluar tanpa demote tetap dihitung sebagai anggota raft yang
+// unreachable.
+func (p *LeavePlan) LosesQuorum() bool {
+	if !p.Manager || p.LastManager() {
+		return false
+	}
+	return p.Reachable-1 < p.Managers/2+1
+}
+
+// PlanLeave memeriksa peran node ini sebelum keluar dari swarm.
+func (m *Manager) PlanLeave(ctx context.Context) (*LeavePlan, error) {
+	info, err := m.client.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.Swarm.LocalNodeState == swarm.LocalNodeStateInactive {
+		return nil, fmt.Errorf("node ini bukan bagian dari swarm")
+	}
+
+	plan := &LeavePlan{NodeID: info.Swarm.NodeID, Manager: info.Swarm.ControlAvailable}
+	if !plan.Manager {
+		return plan, nil
+	}
+
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan.Nodes = len(nodes)
+	for _, n := range nodes {
+		if n.ManagerStatus == nil {
+			continue
+		}
+		plan.Managers++
+		if n.ManagerStatus.Reachability == swarm.ReachabilityReachable {
+			plan.Reachable++
+		}
+	}
+
+	services, err := m.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan.Services = len(services)
+	return plan, nil
+}
+
+// JoinInfo berisi token dan alamat manager untuk bergabung ke swarm.
+type JoinInfo struct {
+	Tokens swarm.JoinTokens
+	// ManagerAddr adalah alamat manager yang dapat dipakai untuk join.
+	ManagerAddr string
+}
+
+// JoinInfo membaca token join dan alamat manager. Hanya dapat dijalankan
+// di manager.
+func (m *Manager) JoinInfo(ctx context.Context) (*JoinInfo, error) {
+	sw, err := m.client.SwarmInspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := m.client.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := ""
+	for _, rm := range info.Swarm.RemoteManagers {
+		if rm.NodeID == info.Swarm.NodeID {
+			addr = rm.Addr
+			break
+		}
+	}
+	if addr == "" && len(info.Swarm.RemoteManagers) > 0 {
+		addr = info.Swarm.RemoteManagers[0].Addr
+	}
+	return &JoinInfo{Tokens: sw.JoinTokens, ManagerAddr: addr}, nil
+}
+
+// RotateJoinToken mengganti token join worker dan/atau manager. Node yang
+// sudah bergabung tidak terpengaruh.
+func (m *Manager) RotateJoinToken(ctx context.Context, worker, manager bool) error {
+	sw, err := m.client.SwarmInspect(ctx)
+	if err != nil {
+		return err
+	}
+	return m.client.SwarmUpdate(ctx, sw.Version, sw.Spec, swarm.UpdateFlags{
+		RotateWorkerToken:  worker,
+		RotateManagerToken: manager,
+	})
+}
+
+// UnlockKey mengembalikan kunci untuk membuka manager setelah restart pada
+// swarm dengan autolock.
+func (m *Manager) UnlockKey(ctx context.Context) (string, error) {
+	resp, err := m.client.SwarmGetUnlockKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	return resp.UnlockKey, nil
+}
+
 func (m *Manager) ListNodes(ctx context.Context) ([]swarm.Node, error) {
 	return m.client.NodeList(ctx, types.NodeListOptions{})
 }
@@ -54,6 +223,9 @@ func (m *Manager) ScaleService(ctx context.Context, serviceID string, replicas u
 		return err
 	}
 
+	if service.Spec.Mode.Replicated == nil {
+		return fmt.Errorf("service %s berjalan dalam mode global dan tidak dapat di-scale", service.Spec.Name)
+	}
 	service.Spec.Mode.Replicated.Replicas = &replicas
 
 	_, err = m.client.ServiceUpdate(ctx, serviceID, service.Version, service.Spec, types.ServiceUpdateOptions{})
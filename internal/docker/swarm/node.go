@@ -0,0 +1,250 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// NodeInfo adalah node beserta jumlah task-nya.
+type NodeInfo struct {
+	swarm.Node
+	// Running adalah jumlah task yang sedang berjalan, Desired jumlah task
+	// yang seharusnya berjalan di node ini.
+	Running int
+	Desired int
+}
+
+// ListNodeInfo mengembalikan semua node dengan jumlah task, diurutkan:
+// manager dulu, lalu berdasarkan hostname.
+func (m *Manager) ListNodeInfo(ctx context.Context) ([]NodeInfo, error) {
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	running := make(map[string]int)
+	desired := make(map[string]int)
+	for _, t := range tasks {
+		if t.DesiredState == swarm.TaskStateRunning {
+			desired[t.NodeID]++
+		}
+		if t.Status.State == swarm.TaskStateRunning {
+			running[t.NodeID]++
+		}
+	}
+
+	result := make([]NodeInfo, len(nodes))
+	for i, n := range nodes {
+		result[i] = NodeInfo{Node: n, Running: running[n.ID], Desired: desired[n.ID]}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		mi, mj := result[i].ManagerStatus != nil, result[j].ManagerStatus != nil
+		if mi != mj {
+			return mi
+		}
+		return result[i].Description.Hostname < result[j].Description.Hostname
+	})
+	return result, nil
+}
+
+// InspectNode mencari node berdasarkan ID atau hostname.
+func (m *Manager) InspectNode(ctx context.Context, nodeID string) (*swarm.Node, error) {
+	node, _, err := m.client.NodeInspectWithRaw(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// NodeTasks mengembalikan task yang dijadwalkan berjalan di node.
+func (m *Manager) NodeTasks(ctx context.Context, nodeID string) ([]swarm.Task, error) {
+	return m.client.TaskList(ctx, types.TaskListOptions{
+		Filters: nodeFilter(nodeID),
+	})
+}
+
+// UpdateNode menerapkan fn pada spec node lalu menyimpannya.
+func (m *Manager) UpdateNode(ctx context.Context, nodeID string, fn func(*swarm.NodeSpec)) error {
+	node, err := m.InspectNode(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	fn(&node.Spec)
+	return m.client.NodeUpdate(ctx, node.ID, node.Version, node.Spec)
+}
+
+func (m *Manager) RemoveNode(ctx context.Context, nodeID string, force bool) error {
+	return m.client.NodeRemove(ctx, nodeID, types.NodeRemoveOptions{Force: force})
+}
+
+// Quorum adalah jumlah manager dan manager yang reachable.
+type Quorum struct {
+	Managers  int
+	Reachable int
+}
+
+// Tolerance adalah jumlah manager yang boleh gagal tanpa kehilangan quorum.
+func (q Quorum) Tolerance() int {
+	if q.Managers == 0 {
+		return 0
+	}
+	return (q.Managers - 1) / 2
+}
+
+// Healthy melaporkan apakah manager yang reachable masih mayoritas.
+func (q Quorum) Healthy() bool {
+	return q.Reachable >= q.Managers/2+1
+}
+
+// QuorumOf menghitung quorum dari daftar node.
+func QuorumOf(nodes []swarm.Node) Quorum {
+	var q Quorum
+	for _, n := range nodes {
+		if n.ManagerStatus == nil {
+			continue
+		}
+		q.Managers++
+		if n.ManagerStatus.Reachability == swarm.ReachabilityReachable {
+			q.Reachable++
+		}
+	}
+	return q
+}
+
+// Impact adalah service yang terdampak perubahan pada satu node.
+type Impact struct {
+	Service string
+	// Unschedulable berarti tidak ada node lain yang dapat menjalankan task
+	// service ini setelah perubahan.
+	Unschedulable bool
+	// Moved adalah jumlah task di node ini yang harus dipindahkan.
+	Moved int
+	// Reason adalah constraint yang membuat service tidak dapat dijadwalkan.
+	Reason string
+}
+
+func (i Impact) String() string {
+	if i.Unschedulable {
+		if i.Reason != "" {
+			return fmt.Sprintf("%s: tidak ada node yang memenuhi constraint %s; task tidak dapat dijadwalkan", i.Service, i.Reason)
+		}
+		return fmt.Sprintf("%s: tidak ada node lain yang dapat menjalankan task; task tidak dapat dijadwalkan", i.Service)
+	}
+	return fmt.Sprintf("%s: %d task dipindahkan ke node lain", i.Service, i.Moved)
+}
+
+// PlanNodeChange menghitung dampak perubahan node nodeID terhadap service
+// yang berjalan. change mengubah salinan node; nil berarti node dihapus.
+func (m *Manager) PlanNodeChange(ctx context.Context, nodeID string, change func(*swarm.Node)) ([]Impact, error) {
+	node, err := m.InspectNode(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	nodeID = node.ID
+
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	services, err := m.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := m.NodeTasks(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Node setelah perubahan; target nil berarti node dihapus
+	after := make([]swarm.Node, 0, len(nodes))
+	var target *swarm.Node
+	for _, n := range nodes {
+		if n.ID == nodeID {
+			if change == nil {
+				continue
+			}
+			changed := n
+			changed.Spec.Labels = copyLabels(n.Spec.Labels)
+			change(&changed)
+			target = &changed
+			after = append(after, changed)
+			continue
+		}
+		after = append(after, n)
+	}
+
+	onNode := make(map[string]int)
+	for _, t := range tasks {
+		if t.DesiredState == swarm.TaskStateRunning {
+			onNode[t.ServiceID]++
+		}
+	}
+
+	var impacts []Impact
+	for i := range services {
+		svc := &services[i]
+		if r := svc.Spec.Mode.Replicated; r != nil && r.Replicas != nil && *r.Replicas == 0 {
+			continue
+		}
+		constraints := serviceConstraints(svc)
+
+		// Task dipindahkan jika node dihapus, di-drain, atau tidak lagi
+		// memenuhi constraint; pause membiarkan task yang sudah berjalan
+		moved := 0
+		if n := onNode[svc.ID]; n > 0 && (target == nil ||
+			target.Spec.Availability == swarm.NodeAvailabilityDrain ||
+			MatchConstraints(target, constraints) != nil) {
+			moved = n
+		}
+
+		before := countSchedulable(nodes, constraints)
+		remaining := countSchedulable(after, constraints)
+		if before > 0 && remaining == 0 {
+			impact := Impact{Service: svc.Spec.Name, Unschedulable: true, Moved: moved}
+			if target != nil {
+				if c := MatchConstraints(target, constraints); c != nil {
+					impact.Reason = c.String()
+				}
+			}
+			impacts = append(impacts, impact)
+			continue
+		}
+		if moved > 0 {
+			impacts = append(impacts, Impact{Service: svc.Spec.Name, Moved: moved})
+		}
+	}
+
+	sort.Slice(impacts, func(i, j int) bool { return impacts[i].Service < impacts[j].Service })
+	return impacts, nil
+}
+
+func countSchedulable(nodes []swarm.Node, constraints []string) int {
+	count := 0
+	for i := range nodes {
+		if Schedulable(&nodes[i], constraints) {
+			count++
+		}
+	}
+	return count
+}
+
+func nodeFilter(nodeID string) filters.Args {
+	return filters.NewArgs(filters.Arg("node", nodeID))
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
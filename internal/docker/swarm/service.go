@@ -0,0 +1,113 @@
+package swarm
+
+import (
+	"context"
+	"sort"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// ServiceInfo adalah service beserta jumlah task-nya. Desired untuk
+// service global adalah jumlah task yang seharusnya berjalan.
+type ServiceInfo struct {
+	swarm.Service
+	Running uint64
+	Desired uint64
+}
+
+// Mode mengembalikan "replicated" atau "global".
+func (s *ServiceInfo) Mode() string {
+	if s.Spec.Mode.Global != nil {
+		return "global"
+	}
+	return "replicated"
+}
+
+// ListServiceInfo mengembalikan semua service dengan jumlah task,
+// diurutkan berdasarkan nama.
+func (m *Manager) ListServiceInfo(ctx context.Context) ([]ServiceInfo, error) {
+	services, err := m.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("desired-state", "running")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	running := make(map[string]uint64)
+	desired := make(map[string]uint64)
+	for _, t := range tasks {
+		desired[t.ServiceID]++
+		if t.Status.State == swarm.TaskStateRunning {
+			running[t.ServiceID]++
+		}
+	}
+
+	result := make([]ServiceInfo, len(services))
+	for i, svc := range services {
+		info := ServiceInfo{Service: svc, Running: running[svc.ID], Desired: desired[svc.ID]}
+		if svc.Spec.Mode.Replicated != nil {
+			info.Desired = getReplicaCount(&svc)
+		}
+		result[i] = info
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Spec.Name < result[j].Spec.Name })
+	return result, nil
+}
+
+// InspectService mencari service berdasarkan nama atau ID.
+func (m *Manager) InspectService(ctx context.Context, name string) (*swarm.Service, error) {
+	svc, _, err := m.client.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// ServiceTasks mengembalikan task service, termasuk task lama yang sudah
+// berhenti, diurutkan per slot lalu dari yang terbaru.
+func (m *Manager) ServiceTasks(ctx context.Context, name string) ([]swarm.Task, error) {
+	svc, err := m.InspectService(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("service", svc.ID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Slot != tasks[j].Slot {
+			return tasks[i].Slot < tasks[j].Slot
+		}
+		if tasks[i].NodeID != tasks[j].NodeID {
+			return tasks[i].NodeID < tasks[j].NodeID
+		}
+		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+	})
+	return tasks, nil
+}
+
+func (m *Manager) RemoveService(ctx context.Context, name string) error {
+	return m.client.ServiceRemove(ctx, name)
+}
+
+// NodeNames memetakan ID node ke hostname.
+func (m *Manager) NodeNames(ctx context.Context) (map[string]string, error) {
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		names[n.ID] = n.Description.Hostname
+	}
+	return names, nil
+}
@@ -0,0 +1,208 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/zakirkun/neon/internal/docker"
+)
+
+// maxStatusFailures adalah jumlah kegagalan task terbaru yang ditampilkan
+// per service.
+const maxStatusFailures = 3
+
+// ClusterStatus adalah ringkasan cluster dan service yang dikelompokkan per
+// stack.
+type ClusterStatus struct {
+	Time       time.Time     `json:"time"`
+	Nodes      int           `json:"nodes"`
+	NodesReady int           `json:"nodes_ready"`
+	Stacks     []StackStatus `json:"stacks"`
+}
+
+// StackStatus adalah service dengan label stack yang sama. Name kosong
+// untuk service di luar stack.
+type StackStatus struct {
+	Name     string          `json:"name"`
+	Services []ServiceStatus `json:"services"`
+}
+
+// ServiceStatus adalah keadaan satu service.
+type ServiceStatus struct {
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	Digest  string `json:"digest,omitempty"`
+	Mode    string `json:"mode"`
+	Running uint64 `json:"running"`
+	Desired uint64 `json:"desired"`
+	// UpdateState kosong jika service belum pernah di-update.
+	UpdateState   string `json:"update_state,omitempty"`
+	UpdateMessage string `json:"update_message,omitempty"`
+	// Revision adalah revisi neon terakhir, 0 jika service tidak memiliki
+	// riwayat.
+	Revision   int       `json:"revision,omitempty"`
+	DeployedAt time.Time `json:"deployed_at"`
+	// Healthy adalah task yang berjalan; Unhealthy adalah task yang gagal
+	// atau ditolak dalam jendela waktu kegagalan.
+	Healthy   int           `json:"healthy"`
+	Unhealthy int           `json:"unhealthy"`
+	Failures  []TaskFailure `json:"failures,omitempty"`
+	Ports     []string      `json:"ports,omitempty"`
+}
+
+// TaskFailure adalah task yang gagal atau ditolak.
+type TaskFailure struct {
+	Task     string    `json:"task"`
+	Node     string    `json:"node,omitempty"`
+	State    string    `json:"state"`
+	Error    string    `json:"error"`
+	ExitCode int       `json:"exit_code,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Status mengumpulkan keadaan semua service, atau hanya service di stack
+// jika stack tidak kosong. Kegagalan task yang lebih lama dari window tidak
+// dihitung.
+func (m *Manager) Status(ctx context.Context, stack string, window time.Duration) (*ClusterStatus, error) {
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	services, err := m.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := docker.LatestRevisions(ctx, m.client)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ClusterStatus{Time: time.Now().UTC(), Nodes: len(nodes)}
+	nodeNames := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		nodeNames[n.ID] = n.Description.Hostname
+		if n.Status.State == swarm.NodeStateReady {
+			status.NodesReady++
+		}
+	}
+
+	byService := make(map[string][]swarm.Task)
+	for _, t := range tasks {
+		byService[t.ServiceID] = append(byService[t.ServiceID], t)
+	}
+
+	stacks := make(map[string][]ServiceStatus)
+	for i := range services {
+		svc := &services[i]
+		name := svc.Spec.Labels[docker.LabelStackNamespace]
+		if stack != "" && name != stack {
+			continue
+		}
+		var rev *docker.Revision
+		if r, ok := revisions[svc.Spec.Name]; ok {
+			rev = &r
+		}
+		stacks[name] = append(stacks[name], serviceStatus(svc, byService[svc.ID], rev, nodeNames, status.Time.Add(-window)))
+	}
+	if stack != "" && len(stacks) == 0 {
+		return nil, fmt.Errorf("stack %s tidak ditemukan", stack)
+	}
+
+	for name, list := range stacks {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		status.Stacks = append(status.Stacks, StackStatus{Name: name, Services: list})
+	}
+	sort.Slice(status.Stacks, func(i, j int) bool { return status.Stacks[i].Name < status.Stacks[j].Name })
+	return status, nil
+}
+
+func serviceStatus(svc *swarm.Service, tasks []swarm.Task, rev *docker.Revision, nodeNames map[string]string, since time.Time) ServiceStatus {
+	s := ServiceStatus{
+		Name:       svc.Spec.Name,
+		Mode:       "replicated",
+		DeployedAt: svc.UpdatedAt,
+	}
+	if svc.Spec.Mode.Global != nil {
+		s.Mode = "global"
+	}
+	if cs := svc.Spec.TaskTemplate.ContainerSpec; cs != nil {
+		s.Image, s.Digest, _ = strings.Cut(cs.Image, "@")
+	}
+	if rev != nil {
+		s.Revision = rev.Revision
+		s.DeployedAt = rev.Time
+		if s.Digest == "" {
+			s.Digest = rev.Digest
+		}
+	}
+	if u := svc.UpdateStatus; u != nil {
+		s.UpdateState = string(u.State)
+		s.UpdateMessage = u.Message
+	}
+	for _, p := range svc.Endpoint.Ports {
+		if p.PublishedPort != 0 {
+			s.Ports = append(s.Ports, fmt.Sprintf("%d->%d/%s", p.PublishedPort, p.TargetPort, p.Protocol))
+		}
+	}
+
+	for _, t := range tasks {
+		if t.DesiredState == swarm.TaskStateRunning {
+			s.Desired++
+			if t.Status.State == swarm.TaskStateRunning {
+				s.Running++
+				s.Healthy++
+			}
+		}
+		if (t.Status.State == swarm.TaskStateFailed || t.Status.State == swarm.TaskStateRejected) && t.Status.Timestamp.After(since) {
+			s.Unhealthy++
+			s.Failures = append(s.Failures, taskFailure(svc, &t, nodeNames))
+		}
+	}
+	if svc.Spec.Mode.Replicated != nil {
+		s.Desired = getReplicaCount(svc)
+	}
+
+	sort.Slice(s.Failures, func(i, j int) bool { return s.Failures[i].Time.After(s.Failures[j].Time) })
+	if len(s.Failures) > maxStatusFailures {
+		s.Failures = s.Failures[:maxStatusFailures]
+	}
+	return s
+}
+
+func taskFailure(svc *swarm.Service, t *swarm.Task, nodeNames map[string]string) TaskFailure {
+	f := TaskFailure{
+		Task:  taskName(svc.Spec.Name, t, nodeNames),
+		Node:  nodeNames[t.NodeID],
+		State: string(t.Status.State),
+		Error: t.Status.Err,
+		Time:  t.Status.Timestamp,
+	}
+	if f.Error == "" {
+		f.Error = t.Status.Message
+	}
+	if cs := t.Status.ContainerStatus; cs != nil {
+		f.ExitCode = cs.ExitCode
+	}
+	return f
+}
+
+// taskName membentuk nama task seperti `docker service ps`: service.slot
+// untuk service replicated, service.<node> untuk service global.
+func taskName(service string, t *swarm.Task, nodeNames map[string]string) string {
+	if t.Slot > 0 {
+		return fmt.Sprintf("%s.%d", service, t.Slot)
+	}
+	if name, ok := nodeNames[t.NodeID]; ok {
+		return service + "." + name
+	}
+	return service + "." + t.NodeID
+}
@@ -0,0 +1,400 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-units"
+)
+
+// Penyebab kegagalan task, diurutkan dari yang paling mungkin menjadi akar
+// masalah. Exit code non-zero misalnya sering hanya gejala dari OOM atau
+// healthcheck.
+const (
+	CauseMissingSecret = "missing-secret"
+	CauseRegistryAuth  = "registry-auth"
+	CauseImageNotFound = "image-not-found"
+	CauseConstraint    = "constraint"
+	CauseResources     = "resources"
+	CausePort          = "port-conflict"
+	CauseOOM           = "oom"
+	CauseHealthcheck   = "healthcheck"
+	CauseExit          = "exit"
+	CauseOther         = "other"
+)
+
+var causePriority = []string{
+	CauseMissingSecret, CauseRegistryAuth, CauseImageNotFound, CauseConstraint,
+	CauseResources, CausePort, CauseOOM, CauseHealthcheck, CauseExit, CauseOther,
+}
+
+// maxHealthLogs adalah jumlah output healthcheck gagal yang disimpan per
+// task.
+const maxHealthLogs = 3
+
+// Diagnosis menjelaskan mengapa service tidak konvergen.
+type Diagnosis struct {
+	Service       string `json:"service"`
+	Running       uint64 `json:"running"`
+	Desired       uint64 `json:"desired"`
+	UpdateState   string `json:"update_state,omitempty"`
+	UpdateMessage string `json:"update_message,omitempty"`
+	// Findings dikelompokkan per penyebab; elemen pertama adalah akar
+	// masalah yang paling mungkin.
+	Findings []Finding `json:"findings"`
+	// Uninspected adalah jumlah task yang containernya tidak dapat
+	// diperiksa, biasanya karena berjalan di node lain.
+	Uninspected int `json:"uninspected,omitempty"`
+}
+
+// Finding adalah sekelompok task dengan penyebab kegagalan yang sama.
+type Finding struct {
+	Cause   string        `json:"cause"`
+	Summary string        `json:"summary"`
+	Fix     string        `json:"fix"`
+	Tasks   []TaskProblem `json:"tasks"`
+}
+
+// TaskProblem adalah task yang gagal, ditolak, atau tertahan.
+type TaskProblem struct {
+	Task      string    `json:"task"`
+	Node      string    `json:"node,omitempty"`
+	State     string    `json:"state"`
+	Error     string    `json:"error"`
+	ExitCode  int       `json:"exit_code,omitempty"`
+	OOMKilled bool      `json:"oom_killed,omitempty"`
+	Health    []string  `json:"health,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Why mengumpulkan task bermasalah dari service name, memeriksa container
+// yang dapat dijangkau untuk OOM dan log healthcheck, lalu mengelompokkan
+// per penyebab. Tanpa all, hanya task dari spec service saat ini yang
+// diperiksa.
+func (m *Manager) Why(ctx context.Context, name string, all bool) (*Diagnosis, error) {
+	svc, err := m.InspectService(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := m.ServiceTasks(ctx, svc.ID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		nodeNames[n.ID] = n.Description.Hostname
+	}
+
+	d := &Diagnosis{Service: svc.Spec.Name}
+	if u := svc.UpdateStatus; u != nil {
+		d.UpdateState = string(u.State)
+		d.UpdateMessage = u.Message
+	}
+
+	groups := make(map[string][]TaskProblem)
+	for i := range tasks {
+		t := &tasks[i]
+		if t.DesiredState == swarm.TaskStateRunning {
+			d.Desired++
+			if t.Status.State == swarm.TaskStateRunning {
+				d.Running++
+			}
+		}
+		if !problem(t) || (!all && !fromSpec(t, svc)) {
+			continue
+		}
+
+		p := TaskProblem{
+			Task:  taskName(svc.Spec.Name, t, nodeNames),
+			Node:  nodeNames[t.NodeID],
+			State: string(t.Status.State),
+			Error: t.Status.Err,
+			Time:  t.Status.Timestamp,
+		}
+		if p.Error == "" {
+			p.Error = t.Status.Message
+		}
+		if cs := t.Status.ContainerStatus; cs != nil {
+			p.ExitCode = cs.ExitCode
+			if cs.ContainerID != "" && !m.inspectContainer(ctx, cs.ContainerID, &p) {
+				d.Uninspected++
+			}
+		}
+		cause := classify(&p)
+		groups[cause] = append(groups[cause], p)
+	}
+	if svc.Spec.Mode.Replicated != nil {
+		d.Desired = getReplicaCount(svc)
+	}
+
+	for _, cause := range causePriority {
+		problems, ok := groups[cause]
+		if !ok {
+			continue
+		}
+		sort.Slice(problems, func(i, j int) bool { return problems[i].Time.After(problems[j].Time) })
+		f := Finding{Cause: cause, Tasks: problems}
+		f.Summary, f.Fix = m.advise(ctx, svc, nodes, cause, problems)
+		d.Findings = append(d.Findings, f)
+	}
+	return d, nil
+}
+
+// problem melaporkan apakah task gagal, ditolak, atau tertahan dengan
+// pesan error.
+func problem(t *swarm.Task) bool {
+	switch t.Status.State {
+	case swarm.TaskStateFailed, swarm.TaskStateRejected:
+		return true
+	case swarm.TaskStateRunning, swarm.TaskStateComplete:
+		return false
+	}
+	return t.Status.Err != "" || strings.Contains(t.Status.Message, "no suitable node")
+}
+
+// fromSpec melaporkan apakah task dibuat dari spec service saat ini.
+func fromSpec(t *swarm.Task, svc *swarm.Service) bool {
+	if t.Spec.ForceUpdate != svc.Spec.TaskTemplate.ForceUpdate {
+		return false
+	}
+	if t.Spec.ContainerSpec == nil || svc.Spec.TaskTemplate.ContainerSpec == nil {
+		return true
+	}
+	return t.Spec.ContainerSpec.Image == svc.Spec.TaskTemplate.ContainerSpec.Image
+}
+
+// inspectContainer mengisi status OOM dan output healthcheck gagal dari
+// container task. Container hanya dapat diperiksa jika berada di node yang
+// dihubungi neon dan belum dihapus.
+func (m *Manager) inspectContainer(ctx context.Context, id string, p *TaskProblem) bool {
+	info, err := m.client.ContainerInspect(ctx, id)
+	if err != nil || info.State == nil {
+		return false
+	}
+	p.OOMKilled = info.State.OOMKilled
+	if h := info.State.Health; h != nil {
+		for _, l := range h.Log {
+			if l.ExitCode != 0 {
+				p.Health = append(p.Health, strings.TrimSpace(l.Output))
+			}
+		}
+		if len(p.Health) > maxHealthLogs {
+			p.Health = p.Health[len(p.Health)-maxHealthLogs:]
+		}
+	}
+	return true
+}
+
+func classify(p *TaskProblem) string {
+	msg := strings.ToLower(p.Error)
+	switch {
+	case strings.Contains(msg, "secret") && (strings.Contains(msg, "not found") || strings.Contains(msg, "no such")):
+		return CauseMissingSecret
+	case strings.Contains(msg, "pull access denied"), strings.Contains(msg, "unauthorized"),
+		strings.Contains(msg, "authentication required"), strings.Contains(msg, "no basic auth credentials"),
+		strings.Contains(msg, "denied:"):
+		return CauseRegistryAuth
+	case strings.Contains(msg, "manifest unknown"), strings.Contains(msg, "no such image"),
+		strings.Contains(msg, "manifest for"), strings.Contains(msg, "repository does not exist"):
+		return CauseImageNotFound
+	case strings.Contains(msg, "scheduling constraints"), strings.Contains(msg, "max replicas per node"),
+		strings.Contains(msg, "unsupported platform"):
+		return CauseConstraint
+	case strings.Contains(msg, "insufficient resources"):
+		return CauseResources
+	case strings.Contains(msg, "port") && (strings.Contains(msg, "already") || strings.Contains(msg, "in use")):
+		return CausePort
+	case p.OOMKilled:
+		return CauseOOM
+	case strings.Contains(msg, "unhealthy"), len(p.Health) > 0:
+		return CauseHealthcheck
+	case strings.Contains(msg, "non-zero exit"), p.ExitCode != 0:
+		return CauseExit
+	}
+	return CauseOther
+}
+
+var secretName = regexp.MustCompile(`secret:? ?"?([A-Za-z0-9_.\-]+)"?`)
+
+// advise menjelaskan penyebab dan saran perbaikan yang konkret untuk
+// service.
+func (m *Manager) advise(ctx context.Context, svc *swarm.Service, nodes []swarm.Node, cause string, problems []TaskProblem) (summary, fix string) {
+	name := svc.Spec.Name
+	image := "-"
+	if cs := svc.Spec.TaskTemplate.ContainerSpec; cs != nil {
+		image, _, _ = strings.Cut(cs.Image, "@")
+	}
+	res := svc.Spec.TaskTemplate.Resources
+
+	switch cause {
+	case CauseMissingSecret:
+		missing := m.missingSecrets(ctx, svc)
+		if len(missing) == 0 {
+			if match := secretName.FindStringSubmatch(problems[0].Error); match != nil {
+				missing = []string{match[1]}
+			}
+		}
+		return fmt.Sprintf("Secret yang dipakai service tidak ditemukan: %s", orUnknown(missing)),
+			"Buat ulang secret tersebut (deploy ulang dari deploy.yaml atau compose file membuatnya otomatis) atau hapus referensinya dari service"
+
+	case CauseRegistryAuth:
+		return fmt.Sprintf("Node tidak diizinkan menarik image %s dari registry", image),
+			fmt.Sprintf("Jalankan `docker login %s` di setiap node yang menjalankan task, atau periksa kredensial dan hak akses repository", registryOf(image))
+
+	case CauseImageNotFound:
+		return fmt.Sprintf("Image %s tidak ditemukan di registry", image),
+			"Periksa nama dan tag image, dan pastikan image sudah di-push sebelum deploy"
+
+	case CauseConstraint:
+		unmet := unmetConstraints(nodes, serviceConstraints(svc))
+		if len(unmet) > 0 {
+			c := unmet[0]
+			fix := fmt.Sprintf("Tidak ada node aktif yang memenuhi %s; ubah constraint atau tambahkan label node", c)
+			if label, ok := strings.CutPrefix(c.Key, "node.labels."); ok && c.Equal {
+				fix = fmt.Sprintf("Tidak ada node aktif yang memenuhi %s; tambahkan label dengan `neon node label add <node> %s=%s` atau ubah constraint", c, label, c.Value)
+			}
+			return fmt.Sprintf("Placement constraint tidak dapat dipenuhi: %s", joinConstraints(unmet)), fix
+		}
+		return "Scheduler tidak menemukan node yang memenuhi placement service",
+			"Periksa constraint, max replicas per node, dan platform image terhadap node yang aktif (neon node ls)"
+
+	case CauseResources:
+		var cpu, mem int64
+		if res != nil && res.Reservations != nil {
+			cpu, mem = res.Reservations.NanoCPUs, res.Reservations.MemoryBytes
+		}
+		var maxCPU, maxMem int64
+		for i := range nodes {
+			if !Schedulable(&nodes[i], serviceConstraints(svc)) {
+				continue
+			}
+			maxCPU = max(maxCPU, nodes[i].Description.Resources.NanoCPUs)
+			maxMem = max(maxMem, nodes[i].Description.Resources.MemoryBytes)
+		}
+		summary = fmt.Sprintf("Reservasi per task (%g CPU, %s memori) tidak muat di node mana pun",
+			float64(cpu)/1e9, units.BytesSize(float64(mem)))
+		switch {
+		case mem > maxMem:
+			fix = fmt.Sprintf("Reservasi memori melebihi node terbesar (%s); turunkan dengan `neon service update %s --reserve-memory <nilai>`", units.BytesSize(float64(maxMem)), name)
+		case cpu > maxCPU:
+			fix = fmt.Sprintf("Reservasi CPU melebihi node terbesar (%g CPU); turunkan dengan `neon service update %s --reserve-cpu <nilai>`", float64(maxCPU)/1e9, name)
+		default:
+			fix = fmt.Sprintf("Sisa kapasitas node sudah terpakai task lain (lihat `neon capacity`); turunkan reservasi (`neon service update %s --reserve-memory/--reserve-cpu`), kurangi replika, atau tambah node", name)
+		}
+		return summary, fix
+
+	case CausePort:
+		return "Port yang dipublikasikan sudah dipakai di node",
+			fmt.Sprintf("Ganti published port (`neon service update %s --publish-rm <port> --publish-add <port-baru>:<target>`) atau hentikan proses yang memakai port tersebut", name)
+
+	case CauseOOM:
+		summary = "Container dihentikan kernel karena kehabisan memori (OOMKilled)"
+		if res != nil && res.Limits != nil && res.Limits.MemoryBytes > 0 {
+			limit := res.Limits.MemoryBytes
+			return summary, fmt.Sprintf("Limit memori saat ini %s; naikkan, misalnya `neon service update %s --limit-memory %s`",
+				units.BytesSize(float64(limit)), name, units.BytesSize(float64(limit*2)))
+		}
+		return summary, fmt.Sprintf("Service tidak memiliki limit memori sehingga memori node habis; atur `--reserve-memory` dan `--limit-memory` sesuai kebutuhan aplikasi, misalnya `neon service update %s --limit-memory 512M`", name)
+
+	case CauseHealthcheck:
+		summary = "Healthcheck gagal sehingga swarm mengganti container"
+		for _, p := range problems {
+			if len(p.Health) > 0 {
+				summary += fmt.Sprintf("; output terakhir: %q", p.Health[len(p.Health)-1])
+				break
+			}
+		}
+		return summary, "Periksa perintah healthcheck dan port yang dicek; jika aplikasi lambat start, perbesar start_period atau retries"
+
+	case CauseExit:
+		code := problems[0].ExitCode
+		summary = fmt.Sprintf("Container berhenti dengan exit code %d", code)
+		if code == 137 {
+			summary += " (SIGKILL, mungkin OOM di node lain atau stop_grace_period habis)"
+		}
+		return summary, fmt.Sprintf("Lihat log aplikasi dengan `neon service logs %s --tail 50` untuk penyebab crash", name)
+	}
+
+	return "Task gagal dengan error lain", fmt.Sprintf("Periksa detail task dengan `neon service ps %s`", name)
+}
+
+// missingSecrets mengembalikan secret yang direferensikan service tetapi
+// sudah tidak ada.
+func (m *Manager) missingSecrets(ctx context.Context, svc *swarm.Service) []string {
+	cs := svc.Spec.TaskTemplate.ContainerSpec
+	if cs == nil || len(cs.Secrets) == 0 {
+		return nil
+	}
+	secrets, err := m.client.SecretList(ctx, types.SecretListOptions{})
+	if err != nil {
+		return nil
+	}
+	exists := make(map[string]bool, len(secrets))
+	for _, s := range secrets {
+		exists[s.ID] = true
+	}
+
+	var missing []string
+	for _, ref := range cs.Secrets {
+		if !exists[ref.SecretID] {
+			missing = append(missing, ref.SecretName)
+		}
+	}
+	return missing
+}
+
+// unmetConstraints mengembalikan constraint yang tidak dipenuhi satu pun
+// node aktif.
+func unmetConstraints(nodes []swarm.Node, constraints []string) []Constraint {
+	var unmet []Constraint
+	for _, s := range constraints {
+		c, err := ParseConstraint(s)
+		if err != nil {
+			continue
+		}
+		matched := false
+		for i := range nodes {
+			if Schedulable(&nodes[i], nil) && c.Match(&nodes[i]) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unmet = append(unmet, c)
+		}
+	}
+	return unmet
+}
+
+func joinConstraints(list []Constraint) string {
+	parts := make([]string, len(list))
+	for i, c := range list {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// registryOf mengembalikan host registry image, atau docker.io.
+func registryOf(image string) string {
+	if host, _, ok := strings.Cut(image, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return host
+	}
+	return "docker.io"
+}
+
+func orUnknown(list []string) string {
+	if len(list) == 0 {
+		return "(tidak diketahui)"
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,168 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/errdefs"
+	"github.com/zakirkun/neon/internal/logger"
+)
+
+// Aksi rollback transaksi untuk satu service.
+const (
+	RollbackRestored  = "dikembalikan ke spec sebelumnya"
+	RollbackRemoved   = "dihapus (service baru)"
+	RollbackUnchanged = "tidak berubah"
+	RollbackSecret    = "dihapus (dibuat saat deploy)"
+)
+
+// Transaction mencatat spec service sebelum deploy stack sehingga semua
+// service yang sudah ter-update dapat dikembalikan jika satu service gagal.
+// Service dicatat dengan Track sesuai urutan deploy; Rollback berjalan
+// dengan urutan terbalik. Secret swarm yang dibuat Deployer selama transaksi
+// juga dicatat dan dihapus saat rollback.
+type Transaction struct {
+	d       *Deployer
+	entries []*txEntry
+	secrets []txSecret
+}
+
+type txSecret struct {
+	id   string
+	name string
+}
+
+type txEntry struct {
+	name string
+	// prev adalah service sebelum deploy, nil jika service belum ada.
+	prev *swarm.Service
+	// revision adalah revisi terakhir di riwayat sebelum deploy.
+	revision *Revision
+}
+
+// RollbackResult adalah hasil rollback satu service, atau satu secret swarm
+// jika Secret terisi.
+type RollbackResult struct {
+	Service string
+	Secret  string
+	Action  string
+	Err     error
+}
+
+// Begin memulai transaksi deploy stack. Selama transaksi aktif, secret
+// swarm yang dibuat d dicatat pada transaksi.
+func (d *Deployer) Begin() *Transaction {
+	t := &Transaction{d: d}
+	d.tx = t
+	return t
+}
+
+// Track mencatat keadaan service name sebelum di-deploy. Track harus
+// dipanggil sebelum service diubah.
+func (t *Transaction) Track(ctx context.Context, name string) error {
+	e := &txEntry{name: name}
+
+	svc, _, err := t.d.client.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	switch {
+	case err == nil:
+		e.prev = &svc
+	case !errdefs.IsNotFound(err):
+		return fmt.Errorf("gagal memeriksa service %s: %v", name, err)
+	}
+
+	if e.prev != nil {
+		history, err := t.d.History(ctx, name)
+		if err != nil {
+			return fmt.Errorf("gagal membaca riwayat service %s: %v", name, err)
+		}
+		if len(history) > 0 {
+			e.revision = &history[len(history)-1]
+		}
+	}
+
+	t.entries = append(t.entries, e)
+	return nil
+}
+
+// Wait menunggu semua service yang dicatat konvergen sebagai pemeriksaan
+// setelah deploy. Error pertama dikembalikan.
+func (t *Transaction) Wait(ctx context.Context, timeout time.Duration) error {
+	for _, e := range t.entries {
+		if err := t.d.WaitService(ctx, e.name, timeout); err != nil {
+			return fmt.Errorf("service %s: %v", e.name, err)
+		}
+	}
+	return nil
+}
+
+// Rollback mengembalikan setiap service yang dicatat ke keadaan sebelum
+// deploy, dimulai dari service yang terakhir di-deploy, dan menunggu setiap
+// service yang dikembalikan konvergen dalam timeout. Service baru dihapus,
+// lalu secret swarm yang dibuat selama deploy. Rollback tetap berlanjut
+// jika satu service gagal dikembalikan.
+func (t *Transaction) Rollback(ctx context.Context, timeout time.Duration) []RollbackResult {
+	if t.d.tx == t {
+		t.d.tx = nil
+	}
+
+	results := make([]RollbackResult, 0, len(t.entries)+len(t.secrets))
+	for i := len(t.entries) - 1; i >= 0; i-- {
+		e := t.entries[i]
+		action, err := t.rollback(ctx, e)
+		if err == nil && action == RollbackRestored {
+			if werr := t.d.WaitService(ctx, e.name, timeout); werr != nil {
+				err = fmt.Errorf("spec dikembalikan tetapi service tidak konvergen: %v", werr)
+			}
+		}
+		results = append(results, RollbackResult{Service: e.name, Action: action, Err: err})
+	}
+
+	// Secret baru dihapus setelah service yang memakainya dikembalikan;
+	// secret yang masih dipakai service lain ditolak oleh daemon
+	for _, s := range t.secrets {
+		err := t.d.client.SecretRemove(ctx, s.id)
+		if errdefs.IsNotFound(err) {
+			err = nil
+		}
+		results = append(results, RollbackResult{Secret: s.name, Action: RollbackSecret, Err: err})
+	}
+	return results
+}
+
+func (t *Transaction) rollback(ctx context.Context, e *txEntry) (string, error) {
+	current, _, err := t.d.client.ServiceInspectWithRaw(ctx, e.name, types.ServiceInspectOptions{})
+	if err != nil {
+		if errdefs.IsNotFound(err) && e.prev == nil {
+			return RollbackUnchanged, nil
+		}
+		return "", err
+	}
+
+	if e.prev == nil {
+		if err := t.d.client.ServiceRemove(ctx, current.ID); err != nil {
+			return "", err
+		}
+		return RollbackRemoved, nil
+	}
+	if current.ID == e.prev.ID && current.Version.Index == e.prev.Version.Index {
+		return RollbackUnchanged, nil
+	}
+
+	// Spec sebelumnya sudah berisi nilai secret yang ter-resolve, jadi
+	// diterapkan langsung tanpa policy dan resolusi ulang
+	resp, err := t.d.client.ServiceUpdate(ctx, current.ID, current.Version, e.prev.Spec, types.ServiceUpdateOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, w := range resp.Warnings {
+		logger.Warnf("Service %s: %s", e.name, w)
+	}
+	if e.revision != nil {
+		spec := e.revision.Spec
+		t.d.WithMessage(fmt.Sprintf("rollback transaksi ke revisi %d", e.revision.Revision)).recordRevision(ctx, &spec)
+	}
+	return RollbackRestored, nil
+}
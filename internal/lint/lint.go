@@ -0,0 +1,209 @@
+// Package lint memeriksa service terhadap praktik terbaik dan keamanan.
+// Service dapat berasal dari deploy.yaml, file compose, atau spec service
+// swarm yang sedang berjalan; ketiganya dinormalisasi menjadi Service
+// sebelum aturan dijalankan.
+//
+// Finding dapat diredam per service dengan komentar YAML
+// `# neon-lint-ignore [rule...]` di dalam blok service, untuk seluruh file
+// dengan `# neon-lint-ignore-file [rule...]`, atau dengan label
+// neon.lint.ignore=rule1,rule2 pada service swarm.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IgnoreLabel adalah label service berisi daftar rule yang diredam
+// (dipisah koma); "all" meredam semua rule.
+const IgnoreLabel = "neon.lint.ignore"
+
+// Severity adalah tingkat keparahan finding.
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity mengubah "error", "warning", atau "info" menjadi Severity.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "error":
+		return Error, nil
+	case "warning", "warn":
+		return Warning, nil
+	case "info":
+		return Info, nil
+	default:
+		return Info, fmt.Errorf("severity %q tidak dikenal (error, warning, info)", s)
+	}
+}
+
+// Position adalah lokasi di file sumber.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Port adalah port yang dipublikasikan service.
+type Port struct {
+	Published uint32
+	Target    uint32
+}
+
+// Service adalah pandangan seragam atas satu service.
+type Service struct {
+	Name string
+	// Source adalah path file, atau "swarm" untuk service yang berjalan.
+	Source string
+	Image  string
+	// Replicas nil untuk service mode global.
+	Replicas *uint64
+	Env      []string
+	Ports    []Port
+	Networks []string
+	// Mounts berisi sumber bind mount atau nama volume.
+	Mounts []string
+	CapAdd []string
+
+	CPULimit          bool
+	MemoryLimit       bool
+	CPUReservation    bool
+	MemoryReservation bool
+
+	// Healthcheck nil berarti tidak didefinisikan di spec.
+	Healthcheck      []string
+	RestartCondition string
+
+	// ignored berisi rule yang diredam; "all" meredam semuanya.
+	ignored map[string]bool
+	pos     Position
+	fields  map[string]Position
+}
+
+func (s *Service) ignore(rules ...string) {
+	if s.ignored == nil {
+		s.ignored = make(map[string]bool)
+	}
+	if len(rules) == 0 {
+		rules = []string{"all"}
+	}
+	for _, r := range rules {
+		s.ignored[r] = true
+	}
+}
+
+// position mengembalikan posisi field, naik ke induk terdekat yang
+// diketahui jika field itu sendiri tidak ada di file.
+func (s *Service) position(field string) Position {
+	for field != "" {
+		if p, ok := s.fields[field]; ok {
+			return p
+		}
+		if i := strings.LastIndexAny(field, ".["); i >= 0 {
+			field = field[:i]
+		} else {
+			field = ""
+		}
+	}
+	return s.pos
+}
+
+// Finding adalah satu pelanggaran rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Service  string   `json:"service"`
+	Message  string   `json:"message"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+}
+
+func (f Finding) String() string {
+	pos := f.File
+	if f.Line > 0 {
+		pos = fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+	}
+	return fmt.Sprintf("%s: %s [%s] %s: %s", pos, f.Severity, f.Rule, f.Service, f.Message)
+}
+
+// Options mengatur rule yang dijalankan.
+type Options struct {
+	// Disabled berisi ID rule yang tidak dijalankan.
+	Disabled map[string]bool
+	// Severities mengganti severity default per rule.
+	Severities map[string]Severity
+}
+
+// Run menjalankan semua rule terhadap services dan mengembalikan finding
+// yang tidak diredam, diurutkan berdasarkan lokasi.
+func Run(services []*Service, opts Options) []Finding {
+	var findings []Finding
+	for _, svc := range services {
+		for _, rule := range Rules {
+			if opts.Disabled[rule.ID] || svc.ignored["all"] || svc.ignored[rule.ID] {
+				continue
+			}
+
+			severity := rule.Severity
+			if s, ok := opts.Severities[rule.ID]; ok {
+				severity = s
+			}
+
+			for _, is := range rule.check(svc) {
+				f := Finding{
+					Rule:     rule.ID,
+					Severity: severity,
+					Service:  svc.Name,
+					Message:  is.msg,
+					File:     svc.Source,
+				}
+				if p := svc.position(is.field); p.Line > 0 {
+					f.Line, f.Column = p.Line, p.Column
+				}
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return findings
+}
+
+// RuleByID mengembalikan rule dengan ID tertentu.
+func RuleByID(id string) (*Rule, bool) {
+	for i := range Rules {
+		if Rules[i].ID == id {
+			return &Rules[i], true
+		}
+	}
+	return nil, false
+}
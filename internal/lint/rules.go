@@ -0,0 +1,255 @@
+package lint
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/zakirkun/neon/internal/config/crypt"
+	"github.com/zakirkun/neon/internal/secrets"
+)
+
+// Rule adalah satu pemeriksaan lint.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	check       func(*Service) []issue
+}
+
+// issue adalah hasil check; field menentukan lokasi yang dilaporkan.
+type issue struct {
+	field string
+	msg   string
+}
+
+// Rules berisi semua rule dalam urutan laporan.
+var Rules = []Rule{
+	{
+		ID:          "image-tag",
+		Severity:    Warning,
+		Description: "Image harus memakai tag yang tetap, bukan :latest atau tanpa tag",
+		check:       checkImageTag,
+	},
+	{
+		ID:          "resource-limits",
+		Severity:    Warning,
+		Description: "Service harus memiliki limit CPU dan memory",
+		check:       checkLimits,
+	},
+	{
+		ID:          "resource-reservations",
+		Severity:    Info,
+		Description: "Service sebaiknya memiliki reservation CPU dan memory agar penjadwalan dapat diprediksi",
+		check:       checkReservations,
+	},
+	{
+		ID:          "healthcheck",
+		Severity:    Warning,
+		Description: "Service harus memiliki healthcheck agar update dan restart mengetahui kondisi task",
+		check:       checkHealthcheck,
+	},
+	{
+		ID:          "plaintext-secret",
+		Severity:    Error,
+		Description: "Secret tidak boleh ditulis langsung di environment variable",
+		check:       checkSecrets,
+	},
+	{
+		ID:          "privileged",
+		Severity:    Error,
+		Description: "Service tidak boleh memiliki akses privileged (CAP_SYS_ADMIN, ALL, atau socket Docker)",
+		check:       checkPrivileged,
+	},
+	{
+		ID:          "host-network",
+		Severity:    Warning,
+		Description: "Service tidak boleh memakai network host",
+		check:       checkHostNetwork,
+	},
+	{
+		ID:          "public-internal-port",
+		Severity:    Warning,
+		Description: "Service internal (database, cache, queue) tidak boleh mempublikasikan port di semua interface",
+		check:       checkInternalPorts,
+	},
+	{
+		ID:          "single-replica-stateful",
+		Severity:    Info,
+		Description: "Workload stateful dengan satu replica tidak memiliki failover",
+		check:       checkStatefulReplicas,
+	},
+	{
+		ID:          "restart-none",
+		Severity:    Warning,
+		Description: "Restart policy none membuat task yang gagal tidak pernah dijalankan ulang",
+		check:       checkRestartNone,
+	},
+}
+
+func checkImageTag(s *Service) []issue {
+	if s.Image == "" {
+		return nil
+	}
+
+	ref, _, pinned := strings.Cut(s.Image, "@")
+	tag := ""
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		tag = ref[i+1:]
+	}
+
+	switch {
+	case tag == "latest":
+		return []issue{{"image", fmt.Sprintf("image %s memakai tag latest; gunakan versi atau revisi yang tetap", s.Image)}}
+	case tag == "" && !pinned:
+		return []issue{{"image", fmt.Sprintf("image %s tidak memiliki tag (sama dengan :latest)", s.Image)}}
+	}
+	return nil
+}
+
+func checkLimits(s *Service) []issue {
+	var missing []string
+	if !s.CPULimit {
+		missing = append(missing, "cpus")
+	}
+	if !s.MemoryLimit {
+		missing = append(missing, "memory")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []issue{{"deploy.resources.limits", "tidak ada limit " + strings.Join(missing, " dan ")}}
+}
+
+func checkReservations(s *Service) []issue {
+	if s.CPUReservation || s.MemoryReservation {
+		return nil
+	}
+	return []issue{{"deploy.resources.reservations", "tidak ada reservation CPU atau memory"}}
+}
+
+func checkHealthcheck(s *Service) []issue {
+	if s.Healthcheck == nil {
+		if s.Source == sourceSwarm {
+			return []issue{{"healthcheck", "spec service tidak memiliki healthcheck (kecuali image mendefinisikan HEALTHCHECK)"}}
+		}
+		return []issue{{"healthcheck", "tidak ada healthcheck (kecuali image mendefinisikan HEALTHCHECK)"}}
+	}
+	if len(s.Healthcheck) > 0 && strings.EqualFold(s.Healthcheck[0], "NONE") {
+		return []issue{{"healthcheck", "healthcheck dinonaktifkan"}}
+	}
+	return nil
+}
+
+var secretKeyPattern = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PRIVATE_?KEY|ACCESS_?KEY|CREDENTIALS?)`)
+
+// isReference melaporkan apakah nilai merujuk ke sumber lain atau sudah
+// dienkripsi, bukan secret yang ditulis langsung.
+func isReference(value string) bool {
+	return value == "" ||
+		crypt.IsEncrypted(value) ||
+		secrets.IsRef(value) ||
+		strings.HasPrefix(value, "${") ||
+		strings.HasPrefix(value, "/run/secrets/")
+}
+
+func checkSecrets(s *Service) []issue {
+	var issues []issue
+	for i, kv := range s.Env {
+		key, value, _ := strings.Cut(kv, "=")
+		if strings.HasSuffix(strings.ToUpper(key), "_FILE") || !secretKeyPattern.MatchString(key) {
+			continue
+		}
+		if isReference(value) {
+			continue
+		}
+		issues = append(issues, issue{
+			fmt.Sprintf("environment[%d]", i),
+			fmt.Sprintf("%s berisi secret dalam plaintext; gunakan Docker secret (%s_FILE) atau referensi", key, key),
+		})
+	}
+	return issues
+}
+
+func checkPrivileged(s *Service) []issue {
+	var issues []issue
+	for _, c := range s.CapAdd {
+		c = strings.TrimPrefix(strings.ToUpper(c), "CAP_")
+		if c == "ALL" || c == "SYS_ADMIN" {
+			issues = append(issues, issue{"cap_add", fmt.Sprintf("menambahkan capability %s", c)})
+		}
+	}
+	for i, m := range s.Mounts {
+		if strings.HasSuffix(m, "docker.sock") {
+			issues = append(issues, issue{fmt.Sprintf("volumes[%d]", i), fmt.Sprintf("me-mount %s (setara akses root ke host)", m)})
+		}
+	}
+	return issues
+}
+
+func checkHostNetwork(s *Service) []issue {
+	for i, n := range s.Networks {
+		if n == "host" {
+			return []issue{{fmt.Sprintf("networks[%d]", i), "memakai network host; port dan isolasi network tidak dikelola swarm"}}
+		}
+	}
+	return nil
+}
+
+// internalImages adalah image yang biasanya hanya diakses dari dalam
+// cluster dan menyimpan state.
+var internalImages = []string{
+	"postgres", "mysql", "mariadb", "mongo", "redis", "memcached", "rabbitmq",
+	"elasticsearch", "opensearch", "kafka", "zookeeper", "etcd", "cassandra",
+	"clickhouse", "minio", "nats", "influxdb", "couchdb", "neo4j", "valkey",
+}
+
+// isInternal menebak dari nama image apakah service adalah datastore.
+func isInternal(s *Service) bool {
+	ref, _, _ := strings.Cut(s.Image, "@")
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	base := path.Base(ref)
+	for _, name := range internalImages {
+		if base == name || strings.HasPrefix(base, name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func checkInternalPorts(s *Service) []issue {
+	if !isInternal(s) {
+		return nil
+	}
+	var issues []issue
+	for i, p := range s.Ports {
+		if p.Published == 0 {
+			continue
+		}
+		issues = append(issues, issue{
+			fmt.Sprintf("ports[%d]", i),
+			fmt.Sprintf("port %d dipublikasikan di 0.0.0.0 lewat ingress; akses service internal lewat overlay network", p.Published),
+		})
+	}
+	return issues
+}
+
+func checkStatefulReplicas(s *Service) []issue {
+	if s.Replicas == nil || *s.Replicas != 1 {
+		return nil
+	}
+	if !isInternal(s) && len(s.Mounts) == 0 {
+		return nil
+	}
+	return []issue{{"replicas", "workload stateful berjalan dengan 1 replica; siapkan replikasi atau backup volume"}}
+}
+
+func checkRestartNone(s *Service) []issue {
+	if s.RestartCondition != "none" {
+		return nil
+	}
+	return []issue{{"deploy.restart_policy.condition", "restart policy none: task yang gagal tidak akan dijalankan ulang"}}
+}
@@ -0,0 +1,290 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/zakirkun/neon/internal/config/compose"
+	"github.com/zakirkun/neon/internal/config/deploy"
+	"github.com/zakirkun/neon/internal/config/validate"
+	"gopkg.in/yaml.v3"
+)
+
+const sourceSwarm = "swarm"
+
+var (
+	ignorePattern     = regexp.MustCompile(`neon-lint-ignore\b([^\n]*)`)
+	ignoreFilePattern = regexp.MustCompile(`#\s*neon-lint-ignore-file\b([^\n]*)`)
+)
+
+// FromDeploy menormalisasi service dari deploy.yaml.
+func FromDeploy(svc deploy.ServiceConfig, source string) *Service {
+	s := &Service{
+		Name:              svc.Name,
+		Source:            source,
+		Image:             svc.Image,
+		Env:               svc.Environment,
+		Networks:          svc.Networks,
+		CPULimit:          svc.Deploy.Resources.Limits.CPUs != "",
+		MemoryLimit:       svc.Deploy.Resources.Limits.Memory != "",
+		CPUReservation:    svc.Deploy.Resources.Reservations.CPUs != "",
+		MemoryReservation: svc.Deploy.Resources.Reservations.Memory != "",
+		RestartCondition:  svc.Deploy.RestartPolicy.Condition,
+	}
+
+	replicas := svc.Replicas
+	if replicas == 0 {
+		replicas = 1
+	}
+	s.Replicas = &replicas
+
+	for _, p := range svc.Ports {
+		s.Ports = append(s.Ports, Port{Published: p.Published, Target: p.Target})
+	}
+	if svc.Healthcheck != nil {
+		s.Healthcheck = append([]string{}, svc.Healthcheck.Test...)
+	}
+	if v, ok := svc.Labels[IgnoreLabel]; ok {
+		s.ignore(splitRules(v)...)
+	}
+	return s
+}
+
+// FromCompose menormalisasi service dari file compose. Environment
+// diurutkan berdasarkan key.
+func FromCompose(name string, svc compose.Service, source string) *Service {
+	s := &Service{
+		Name:              name,
+		Source:            source,
+		Image:             svc.Image,
+		Networks:          svc.Networks,
+		CPULimit:          svc.Deploy.Resources.Limits.CPUs != "",
+		MemoryLimit:       svc.Deploy.Resources.Limits.Memory != "",
+		CPUReservation:    svc.Deploy.Resources.Reservations.CPUs != "",
+		MemoryReservation: svc.Deploy.Resources.Reservations.Memory != "",
+		RestartCondition:  svc.Deploy.Restart.Condition,
+	}
+
+	replicas := uint64(svc.Deploy.Replicas)
+	if replicas == 0 {
+		replicas = 1
+	}
+	s.Replicas = &replicas
+
+	for _, key := range envKeys(svc.Environment) {
+		s.Env = append(s.Env, key+"="+svc.Environment[key])
+	}
+	for _, p := range svc.Ports {
+		published, target, _ := strings.Cut(p, ":")
+		pub, _ := strconv.ParseUint(published, 10, 32)
+		tgt, _ := strconv.ParseUint(target, 10, 32)
+		s.Ports = append(s.Ports, Port{Published: uint32(pub), Target: uint32(tgt)})
+	}
+	for _, v := range svc.Volumes {
+		src, _, _ := strings.Cut(v, ":")
+		s.Mounts = append(s.Mounts, src)
+	}
+	if svc.Healthcheck != nil {
+		s.Healthcheck = append([]string{}, svc.Healthcheck.Test...)
+		if svc.Healthcheck.Disable {
+			s.Healthcheck = []string{"NONE"}
+		}
+	}
+	if v, ok := svc.Deploy.Labels[IgnoreLabel]; ok {
+		s.ignore(splitRules(v)...)
+	}
+	return s
+}
+
+// FromSwarm menormalisasi service swarm yang sedang berjalan. networks
+// memetakan ID network ke namanya; boleh nil.
+func FromSwarm(svc swarm.Service, networks map[string]string) *Service {
+	spec := svc.Spec
+	s := &Service{
+		Name:   spec.Name,
+		Source: sourceSwarm,
+	}
+
+	if cs := spec.TaskTemplate.ContainerSpec; cs != nil {
+		s.Image = cs.Image
+		s.Env = cs.Env
+		s.CapAdd = cs.CapabilityAdd
+		for _, m := range cs.Mounts {
+			s.Mounts = append(s.Mounts, m.Source)
+		}
+		if cs.Healthcheck != nil {
+			s.Healthcheck = append([]string{}, cs.Healthcheck.Test...)
+		}
+	}
+
+	if r := spec.TaskTemplate.Resources; r != nil {
+		if r.Limits != nil {
+			s.CPULimit = r.Limits.NanoCPUs > 0
+			s.MemoryLimit = r.Limits.MemoryBytes > 0
+		}
+		if r.Reservations != nil {
+			s.CPUReservation = r.Reservations.NanoCPUs > 0
+			s.MemoryReservation = r.Reservations.MemoryBytes > 0
+		}
+	}
+	if rp := spec.TaskTemplate.RestartPolicy; rp != nil {
+		s.RestartCondition = string(rp.Condition)
+	}
+	if spec.Mode.Replicated != nil {
+		s.Replicas = spec.Mode.Replicated.Replicas
+	}
+
+	for _, n := range spec.TaskTemplate.Networks {
+		name := n.Target
+		if networks[n.Target] != "" {
+			name = networks[n.Target]
+		}
+		s.Networks = append(s.Networks, name)
+	}
+	if spec.EndpointSpec != nil {
+		for _, p := range spec.EndpointSpec.Ports {
+			s.Ports = append(s.Ports, Port{Published: p.PublishedPort, Target: p.TargetPort})
+		}
+	}
+	if v, ok := spec.Labels[IgnoreLabel]; ok {
+		s.ignore(splitRules(v)...)
+	}
+	return s
+}
+
+// LoadFile membaca deploy.yaml atau file compose (dibedakan dari bentuk
+// key services), memvalidasinya, lalu mengembalikan service beserta posisi
+// field dan peredaman dari komentar.
+func LoadFile(path string) ([]*Service, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		// Biarkan validasi melaporkan error dengan posisinya
+		var cfg deploy.Config
+		return nil, validate.DecodeRaw(path, data, &cfg)
+	}
+	services := mappingValue(doc.Content[0], "services")
+	if services == nil {
+		return nil, fmt.Errorf("%s: tidak ada key services", path)
+	}
+
+	var result []*Service
+	if services.Kind == yaml.SequenceNode {
+		var cfg deploy.Config
+		if err := validate.DecodeRaw(path, data, &cfg); err != nil {
+			return nil, err
+		}
+		for i, svc := range cfg.Services {
+			s := FromDeploy(svc, path)
+			annotate(s, services.Content[i])
+			result = append(result, s)
+		}
+	} else {
+		var cfg compose.Config
+		if err := validate.DecodeRaw(path, data, &cfg); err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(services.Content); i += 2 {
+			name := services.Content[i].Value
+			s := FromCompose(name, cfg.Services[name], path)
+			annotate(s, services.Content[i+1])
+			composeAliases(s, cfg.Services[name])
+			result = append(result, s)
+		}
+	}
+
+	for _, m := range ignoreFilePattern.FindAllStringSubmatch(string(data), -1) {
+		for _, s := range result {
+			s.ignore(splitRules(m[1])...)
+		}
+	}
+	return result, nil
+}
+
+// annotate mencatat posisi service dan field-nya, serta membaca komentar
+// neon-lint-ignore di dalam blok service.
+func annotate(s *Service, node *yaml.Node) {
+	s.pos = Position{node.Line, node.Column}
+	s.fields = make(map[string]Position)
+
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		for _, c := range []string{n.HeadComment, n.LineComment, n.FootComment} {
+			for _, m := range ignorePattern.FindAllStringSubmatch(c, -1) {
+				if !strings.HasPrefix(m[0], "neon-lint-ignore-file") {
+					s.ignore(splitRules(m[1])...)
+				}
+			}
+		}
+
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, val := n.Content[i], n.Content[i+1]
+				p := key.Value
+				if path != "" {
+					p = path + "." + key.Value
+				}
+				s.fields[p] = Position{key.Line, key.Column}
+				walk(key, p)
+				walk(val, p)
+			}
+		case yaml.SequenceNode:
+			for i, item := range n.Content {
+				p := fmt.Sprintf("%s[%d]", path, i)
+				s.fields[p] = Position{item.Line, item.Column}
+				walk(item, p)
+			}
+		}
+	}
+	walk(node, "")
+}
+
+// composeAliases memetakan field compose ke nama field yang dipakai rule.
+func composeAliases(s *Service, svc compose.Service) {
+	if p, ok := s.fields["deploy.replicas"]; ok {
+		s.fields["replicas"] = p
+	}
+	for i, key := range envKeys(svc.Environment) {
+		if p, ok := s.fields["environment."+key]; ok {
+			s.fields[fmt.Sprintf("environment[%d]", i)] = p
+		}
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func envKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitRules memecah daftar rule yang dipisah koma atau spasi.
+func splitRules(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
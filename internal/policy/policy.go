@@ -0,0 +1,160 @@
+// Package policy mengevaluasi ServiceSpec final sebelum service dibuat atau
+// diperbarui. Setiap Policy menghasilkan Decision allow, warn, atau deny
+// beserta alasannya; satu deny cukup untuk membatalkan deploy.
+//
+// Policy bawaan adalah aturan deklaratif dari file policy (lihat Load),
+// tetapi Engine menerima implementasi Policy apa pun.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// Action adalah hasil evaluasi policy.
+type Action int
+
+const (
+	Allow Action = iota
+	Warn
+	Deny
+)
+
+func (a Action) String() string {
+	switch a {
+	case Deny:
+		return "deny"
+	case Warn:
+		return "warn"
+	default:
+		return "allow"
+	}
+}
+
+// Input adalah konteks yang dievaluasi policy.
+type Input struct {
+	Spec *swarm.ServiceSpec
+	// Environment adalah environment deploy (misalnya dari neon.yaml --env
+	// atau context aktif); kosong jika tidak diketahui. Rule yang dibatasi
+	// ke environment tertentu tetap berlaku jika environment tidak
+	// diketahui.
+	Environment string
+	// User adalah pengguna yang menjalankan deploy.
+	User string
+}
+
+// Decision adalah keputusan satu policy untuk satu service.
+type Decision struct {
+	Action Action
+	Policy string
+	Reason string
+}
+
+func (d Decision) String() string {
+	return fmt.Sprintf("%s [%s]: %s", d.Action, d.Policy, d.Reason)
+}
+
+// Policy mengevaluasi Input. Decision allow tidak perlu dikembalikan.
+type Policy interface {
+	Evaluate(in *Input) []Decision
+}
+
+// Engine menjalankan sekumpulan Policy.
+type Engine struct {
+	policies []Policy
+}
+
+func NewEngine(policies ...Policy) *Engine {
+	return &Engine{policies: policies}
+}
+
+// Register menambahkan policy ke engine.
+func (e *Engine) Register(p Policy) {
+	e.policies = append(e.policies, p)
+}
+
+// Result adalah gabungan keputusan semua policy.
+type Result struct {
+	Decisions []Decision
+}
+
+// Action mengembalikan keputusan paling ketat.
+func (r *Result) Action() Action {
+	action := Allow
+	for _, d := range r.Decisions {
+		if d.Action > action {
+			action = d.Action
+		}
+	}
+	return action
+}
+
+// Warnings mengembalikan keputusan warn.
+func (r *Result) Warnings() []Decision {
+	return r.filter(Warn)
+}
+
+// Err mengembalikan DeniedError jika ada keputusan deny.
+func (r *Result) Err(service string) error {
+	denied := r.filter(Deny)
+	if len(denied) == 0 {
+		return nil
+	}
+	return &DeniedError{Service: service, Decisions: denied}
+}
+
+func (r *Result) filter(a Action) []Decision {
+	var out []Decision
+	for _, d := range r.Decisions {
+		if d.Action == a {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Evaluate menjalankan semua policy terhadap in.
+func (e *Engine) Evaluate(in *Input) *Result {
+	result := &Result{}
+	if e == nil {
+		return result
+	}
+	for _, p := range e.policies {
+		for _, d := range p.Evaluate(in) {
+			if d.Action != Allow {
+				result.Decisions = append(result.Decisions, d)
+			}
+		}
+	}
+	return result
+}
+
+// DeniedError dikembalikan jika deploy ditolak policy.
+type DeniedError struct {
+	Service   string
+	Decisions []Decision
+}
+
+func (e *DeniedError) Error() string {
+	reasons := make([]string, len(e.Decisions))
+	for i, d := range e.Decisions {
+		reasons[i] = fmt.Sprintf("[%s] %s", d.Policy, d.Reason)
+	}
+	return fmt.Sprintf("deploy service %s ditolak policy: %s", e.Service, strings.Join(reasons, "; "))
+}
+
+// CurrentUser mengembalikan identitas pengguna untuk policy: $NEON_USER
+// jika diisi, selain itu nama pengguna sistem operasi.
+func CurrentUser() string {
+	if u := os.Getenv("NEON_USER"); u != "" {
+		return u
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
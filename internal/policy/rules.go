@@ -0,0 +1,254 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/zakirkun/neon/internal/config/validate"
+)
+
+// DefaultFile adalah lokasi file policy jika config tidak menentukan.
+func DefaultFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".neon", "policy.yaml"), nil
+}
+
+// File adalah isi file policy.
+type File struct {
+	// Teams memetakan nama tim ke anggotanya, dipakai oleh require.teams.
+	Teams map[string][]string `yaml:"teams"`
+	Rules []Rule              `yaml:"rules"`
+}
+
+// Rule adalah policy deklaratif: jika service cocok dengan Match dan salah
+// satu syarat Require tidak terpenuhi, Action dijalankan.
+type Rule struct {
+	Name string `yaml:"name" validate:"required"`
+	// Action adalah "deny" (default) atau "warn".
+	Action  string  `yaml:"action" validate:"oneof=deny warn"`
+	Message string  `yaml:"message"`
+	Match   Match   `yaml:"match"`
+	Require Require `yaml:"require"`
+
+	teams map[string][]string
+}
+
+// Match membatasi service yang dievaluasi rule. Field kosong cocok dengan
+// semua service.
+type Match struct {
+	// Services berisi pola glob nama service, misalnya "payments-*".
+	Services     []string          `yaml:"services"`
+	Environments []string          `yaml:"environments"`
+	Labels       map[string]string `yaml:"labels"`
+}
+
+// Require adalah syarat yang harus dipenuhi service yang cocok.
+type Require struct {
+	// Registries adalah host registry yang diizinkan, misalnya
+	// "registry.corp". Image tanpa host dianggap dari docker.io.
+	Registries  []string `yaml:"registries"`
+	MemoryLimit bool     `yaml:"memory_limit"`
+	CPULimit    bool     `yaml:"cpu_limit"`
+	MaxMemory   string   `yaml:"max_memory" validate:"memory"`
+	MaxReplicas *uint64  `yaml:"max_replicas"`
+	MinReplicas *uint64  `yaml:"min_replicas"`
+	Healthcheck bool     `yaml:"healthcheck"`
+	Labels      []string `yaml:"labels"`
+	NoLatestTag bool     `yaml:"no_latest_tag"`
+	Users       []string `yaml:"users"`
+	Teams       []string `yaml:"teams"`
+}
+
+// Check memastikan tim yang dirujuk rule didefinisikan.
+func (f File) Check() []validate.FieldError {
+	var errs []validate.FieldError
+	for _, r := range f.Rules {
+		for _, t := range r.Require.Teams {
+			if _, ok := f.Teams[t]; !ok {
+				errs = append(errs, validate.FieldError{Key: "rules", Msg: fmt.Sprintf("rule %s: tim %q tidak didefinisikan di teams", r.Name, t)})
+			}
+		}
+		for _, p := range r.Match.Services {
+			if _, err := path.Match(p, ""); err != nil {
+				errs = append(errs, validate.FieldError{Key: "rules", Msg: fmt.Sprintf("rule %s: pola service %q tidak valid", r.Name, p)})
+			}
+		}
+	}
+	return errs
+}
+
+// Load membaca file policy dan mengembalikan Engine berisi rule-nya.
+func Load(path string) (*Engine, error) {
+	var f File
+	if err := validate.DecodeFile(path, &f); err != nil {
+		return nil, err
+	}
+
+	engine := NewEngine()
+	for i := range f.Rules {
+		r := f.Rules[i]
+		r.teams = f.Teams
+		engine.Register(&r)
+	}
+	return engine, nil
+}
+
+// Evaluate mengimplementasikan Policy.
+func (r *Rule) Evaluate(in *Input) []Decision {
+	if !r.matches(in) {
+		return nil
+	}
+
+	action := Deny
+	if r.Action == "warn" {
+		action = Warn
+	}
+
+	var decisions []Decision
+	for _, reason := range r.violations(in) {
+		if r.Message != "" {
+			reason = r.Message + " (" + reason + ")"
+		}
+		decisions = append(decisions, Decision{Action: action, Policy: r.Name, Reason: reason})
+	}
+	return decisions
+}
+
+func (r *Rule) matches(in *Input) bool {
+	m := r.Match
+	if len(m.Services) > 0 {
+		matched := false
+		for _, p := range m.Services {
+			if ok, _ := path.Match(p, in.Spec.Name); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	// Environment yang tidak diketahui tidak boleh meloloskan deploy dari
+	// rule yang dibatasi environment
+	if len(m.Environments) > 0 && in.Environment != "" && !contains(m.Environments, in.Environment) {
+		return false
+	}
+	for k, v := range m.Labels {
+		if in.Spec.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// violations mengembalikan alasan untuk setiap syarat yang tidak terpenuhi.
+func (r *Rule) violations(in *Input) []string {
+	req := r.Require
+	spec := in.Spec
+	var out []string
+
+	var image string
+	var cs = spec.TaskTemplate.ContainerSpec
+	if cs != nil {
+		image = cs.Image
+	}
+	var limits *swarm.Limit
+	if res := spec.TaskTemplate.Resources; res != nil {
+		limits = res.Limits
+	}
+
+	if len(req.Registries) > 0 {
+		if host := registryHost(image); !contains(req.Registries, host) {
+			out = append(out, fmt.Sprintf("image %s berasal dari %s, yang diizinkan: %s", image, host, strings.Join(req.Registries, ", ")))
+		}
+	}
+	if req.NoLatestTag && isLatest(image) {
+		out = append(out, fmt.Sprintf("image %s memakai tag latest", image))
+	}
+	if req.MemoryLimit && (limits == nil || limits.MemoryBytes == 0) {
+		out = append(out, "limit memory wajib diisi")
+	}
+	if req.CPULimit && (limits == nil || limits.NanoCPUs == 0) {
+		out = append(out, "limit CPU wajib diisi")
+	}
+	if req.MaxMemory != "" && limits != nil && limits.MemoryBytes > 0 {
+		max, _ := validate.ParseMemory(req.MaxMemory)
+		if limits.MemoryBytes > max {
+			out = append(out, fmt.Sprintf("limit memory %d bytes melebihi batas %s", limits.MemoryBytes, req.MaxMemory))
+		}
+	}
+	if spec.Mode.Replicated != nil && spec.Mode.Replicated.Replicas != nil {
+		replicas := *spec.Mode.Replicated.Replicas
+		if req.MaxReplicas != nil && replicas > *req.MaxReplicas {
+			out = append(out, fmt.Sprintf("%d replicas melebihi batas %d", replicas, *req.MaxReplicas))
+		}
+		if req.MinReplicas != nil && replicas < *req.MinReplicas {
+			out = append(out, fmt.Sprintf("%d replicas kurang dari minimal %d", replicas, *req.MinReplicas))
+		}
+	}
+	if req.Healthcheck && (cs == nil || cs.Healthcheck == nil || len(cs.Healthcheck.Test) == 0 || cs.Healthcheck.Test[0] == "NONE") {
+		out = append(out, "healthcheck wajib didefinisikan")
+	}
+	for _, l := range req.Labels {
+		if _, ok := spec.Labels[l]; !ok {
+			out = append(out, fmt.Sprintf("label %s wajib diisi", l))
+		}
+	}
+	if len(req.Users) > 0 || len(req.Teams) > 0 {
+		if !r.userAllowed(in.User) {
+			out = append(out, fmt.Sprintf("pengguna %q tidak diizinkan men-deploy service ini", in.User))
+		}
+	}
+	return out
+}
+
+func (r *Rule) userAllowed(u string) bool {
+	if u == "" {
+		return false
+	}
+	if contains(r.Require.Users, u) {
+		return true
+	}
+	for _, t := range r.Require.Teams {
+		if contains(r.teams[t], u) {
+			return true
+		}
+	}
+	return false
+}
+
+// registryHost mengembalikan host registry dari referensi image.
+func registryHost(image string) string {
+	first, _, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first
+	}
+	return "docker.io"
+}
+
+// isLatest melaporkan apakah image memakai tag latest, termasuk image
+// tanpa tag dan tanpa digest.
+func isLatest(image string) bool {
+	ref, _, pinned := strings.Cut(image, "@")
+	i := strings.LastIndex(ref, ":")
+	if i <= strings.LastIndex(ref, "/") {
+		return !pinned
+	}
+	return ref[i+1:] == "latest"
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
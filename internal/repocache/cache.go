@@ -0,0 +1,342 @@
+// Package repocache menyimpan mirror bare dari repository git di disk
+// (default ~/.neon/cache/repos) sehingga deploy berikutnya dari repository
+// yang sama cukup melakukan fetch incremental.
+package repocache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/zakirkun/neon/internal/logger"
+)
+
+// DefaultMaxSize adalah batas ukuran cache jika tidak dikonfigurasi (5 GiB).
+const DefaultMaxSize int64 = 5 << 30
+
+const (
+	mirrorDirName = "mirror.git"
+	lockFileName  = "lock"
+	stampFileName = "last-used"
+	urlFileName   = "url"
+)
+
+type Cache struct {
+	dir     string
+	maxSize int64
+}
+
+// Worktree adalah hasil checkout satu revisi ke direktori sementara.
+type Worktree struct {
+	Dir      string
+	Revision string
+	// GitDir adalah lokasi mirror, untuk membaca history (misalnya git diff).
+	// Mirror tetap dikunci sampai Remove dipanggil, sehingga GitDir tidak
+	// di-fetch ulang atau di-evict proses lain selama worktree dipakai.
+	GitDir string
+	lock   *fileLock
+}
+
+// Remove menghapus direktori worktree dan melepas lock mirror.
+func (w *Worktree) Remove() error {
+	if w.lock != nil {
+		w.lock.Release()
+		w.lock = nil
+	}
+	return os.RemoveAll(w.Dir)
+}
+
+func New(dir string, maxSize int64) *Cache {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &Cache{dir: dir, maxSize: maxSize}
+}
+
+// DefaultDir mengembalikan lokasi cache default, ~/.neon/cache/repos.
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".neon", "cache", "repos"), nil
+}
+
+// Checkout memperbarui mirror untuk repoURL lalu mengekspor isi branch ke
+// direktori worktree baru. Akses ke mirror yang sama dari beberapa proses
+// diserialisasi dengan file lock yang dipegang sampai Worktree.Remove.
+// auth boleh nil untuk repository publik.
+func (c *Cache) Checkout(ctx context.Context, repoURL, branch string, auth transport.AuthMethod, progress io.Writer) (*Worktree, error) {
+	entryDir := c.entryDir(repoURL)
+	if err := os.MkdirAll(entryDir, 0755); err != nil {
+		return nil, fmt.Errorf("gagal membuat direktori cache: %w", err)
+	}
+
+	lock, err := acquireLock(ctx, filepath.Join(entryDir, lockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengunci cache repository: %w", err)
+	}
+
+	wt, err := c.checkoutLocked(ctx, entryDir, repoURL, branch, auth, progress)
+	if err != nil {
+		lock.Release()
+		return nil, err
+	}
+	wt.lock = lock
+
+	if err := c.Evict(repoURL); err != nil {
+		logger.Warnf("Gagal membersihkan cache repository: %v", err)
+	}
+	return wt, nil
+}
+
+func (c *Cache) checkoutLocked(ctx context.Context, entryDir, repoURL, branch string, auth transport.AuthMethod, progress io.Writer) (*Worktree, error) {
+	repo, err := c.syncMirror(ctx, entryDir, repoURL, auth, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return nil, fmt.Errorf("branch %s tidak ditemukan: %w", branch, err)
+	}
+
+	dir, err := os.MkdirTemp("", "neon-worktree-*")
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat direktori worktree: %w", err)
+	}
+
+	if err := exportCommit(repo, ref.Hash(), dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("gagal checkout %s: %w", ref.Hash(), err)
+	}
+
+	now := time.Now()
+	stamp := filepath.Join(entryDir, stampFileName)
+	if err := os.WriteFile(stamp, nil, 0644); err == nil {
+		os.Chtimes(stamp, now, now)
+	}
+
+	return &Worktree{
+		Dir:      dir,
+		Revision: ref.Hash().String(),
+		GitDir:   filepath.Join(entryDir, mirrorDirName),
+	}, nil
+}
+
+// syncMirror membuat mirror baru atau melakukan fetch incremental pada
+// mirror yang sudah ada. Mirror yang rusak akan di-clone ulang.
+func (c *Cache) syncMirror(ctx context.Context, entryDir, repoURL string, auth transport.AuthMethod, progress io.Writer) (*git.Repository, error) {
+	mirrorDir := filepath.Join(entryDir, mirrorDirName)
+
+	repo, err := git.PlainOpen(mirrorDir)
+	if err == nil {
+		err = repo.FetchContext(ctx, &git.FetchOptions{
+			Auth:     auth,
+			Progress: progress,
+			Force:    true,
+			Prune:    true,
+		})
+		if err == nil || errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return repo, nil
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("gagal melakukan git fetch: %w", err)
+		}
+		logger.Warnf("Fetch mirror %s gagal, clone ulang: %v", repoURL, err)
+	} else if !errors.Is(err, git.ErrRepositoryNotExists) {
+		logger.Warnf("Mirror %s tidak valid, clone ulang: %v", repoURL, err)
+	}
+
+	if err := os.RemoveAll(mirrorDir); err != nil {
+		return nil, fmt.Errorf("gagal menghapus mirror lama: %w", err)
+	}
+
+	// Mirror dibuat dengan init + fetch semua ref, bukan clone, karena
+	// clone gagal jika HEAD repository asal menunjuk branch yang tidak ada
+	// (misalnya repository bare dengan HEAD master tetapi hanya berisi main)
+	repo, err = git.PlainInit(mirrorDir, true)
+	if err == nil {
+		_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+			Name:   git.DefaultRemoteName,
+			URLs:   []string{repoURL},
+			Mirror: true,
+			Fetch:  []gitconfig.RefSpec{"+refs/*:refs/*"},
+		})
+	}
+	if err == nil {
+		err = repo.FetchContext(ctx, &git.FetchOptions{
+			Auth:     auth,
+			Progress: progress,
+			Force:    true,
+		})
+	}
+	if err != nil {
+		os.RemoveAll(mirrorDir)
+		return nil, fmt.Errorf("gagal melakukan git clone: %w", err)
+	}
+
+	os.WriteFile(filepath.Join(entryDir, urlFileName), []byte(repoURL+"\n"), 0644)
+	return repo, nil
+}
+
+// exportCommit menulis seluruh file dari tree commit ke dir.
+func exportCommit(repo *git.Repository, hash plumbing.Hash, dir string) error {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	return tree.Files().ForEach(func(f *object.File) error {
+		path := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		if f.Mode == filemode.Symlink {
+			target, err := f.Contents()
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, path)
+		}
+
+		perm := os.FileMode(0644)
+		if f.Mode == filemode.Executable {
+			perm = 0755
+		}
+
+		r, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, r); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+type entry struct {
+	dir      string
+	size     int64
+	lastUsed time.Time
+}
+
+// Evict menghapus mirror yang paling lama tidak dipakai sampai total ukuran
+// cache berada di bawah batas. Mirror untuk keepURL dan mirror yang sedang
+// dikunci proses lain tidak pernah dihapus. Direktori entry dan file lock-nya
+// dibiarkan agar lock tetap berada pada inode yang sama.
+func (c *Cache) Evict(keepURL string) error {
+	dirs, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	keep := c.entryDir(keepURL)
+	var (
+		entries []entry
+		total   int64
+	)
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		e := entry{dir: filepath.Join(c.dir, d.Name())}
+		if _, err := os.Stat(filepath.Join(e.dir, mirrorDirName)); err != nil {
+			// Entry yang sudah di-evict hanya berisi file lock
+			continue
+		}
+		e.size = dirSize(e.dir)
+		if info, err := os.Stat(filepath.Join(e.dir, stampFileName)); err == nil {
+			e.lastUsed = info.ModTime()
+		}
+		total += e.size
+		if e.dir != keep {
+			entries = append(entries, e)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUsed.Before(entries[j].lastUsed)
+	})
+
+	for _, e := range entries {
+		if total <= c.maxSize {
+			break
+		}
+
+		lock, ok := tryLock(filepath.Join(e.dir, lockFileName))
+		if !ok {
+			continue
+		}
+		// File lock tidak pernah dihapus: proses lain yang sedang menunggu
+		// lock pada inode yang sama akan kehilangan eksklusivitasnya jika
+		// file dibuat ulang. Hanya isi entry yang dihapus selama lock dipegang.
+		err := os.RemoveAll(filepath.Join(e.dir, mirrorDirName))
+		if err == nil {
+			os.Remove(filepath.Join(e.dir, stampFileName))
+			os.Remove(filepath.Join(e.dir, urlFileName))
+		}
+		lock.Release()
+		if err != nil {
+			return err
+		}
+
+		logger.Infof("Cache repository %s dihapus (%d bytes)", e.dir, e.size)
+		total -= e.size
+	}
+
+	if total > c.maxSize {
+		logger.Warnf("Ukuran cache repository (%d bytes) melebihi batas %d bytes", total, c.maxSize)
+	}
+	return nil
+}
+
+func (c *Cache) entryDir(repoURL string) string {
+	sum := sha256.Sum256([]byte(repoURL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:8]))
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+
+package repocache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+type fileLock struct {
+	f *os.File
+}
+
+// acquireLock menunggu sampai lock eksklusif pada path didapat atau ctx
+// dibatalkan.
+func acquireLock(ctx context.Context, path string) (*fileLock, error) {
+	for {
+		lock, err := lockFile(path)
+		if err == nil {
+			return lock, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func tryLock(path string) (*fileLock, bool) {
+	lock, err := lockFile(path)
+	return lock, err == nil
+}
+
+func lockFile(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileLock{f: f}, nil
+}
+
+func (l *fileLock) Release() {
+	syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+	l.f.Close()
+}
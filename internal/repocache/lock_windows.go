@@ -0,0 +1,57 @@
+//go:build windows
+
+package repocache
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// staleLockAge adalah umur lock file yang dianggap ditinggalkan oleh proses
+// yang sudah mati.
+const staleLockAge = 30 * time.Minute
+
+type fileLock struct {
+	path string
+}
+
+func acquireLock(ctx context.Context, path string) (*fileLock, error) {
+	for {
+		lock, err := lockFile(path)
+		if err == nil {
+			return lock, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func tryLock(path string) (*fileLock, bool) {
+	lock, err := lockFile(path)
+	return lock, err == nil
+}
+
+func lockFile(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return &fileLock{path: path}, nil
+}
+
+func (l *fileLock) Release() {
+	os.Remove(l.path)
+}
@@ -0,0 +1,232 @@
+// Package secrets mengambil nilai secret dari sumber eksternal saat deploy.
+// Nilai environment dan sumber Docker secret boleh berupa URI:
+//
+//	vault://kv/app#db_password   field db_password dari Vault KV v2 kv/app
+//	file://./secrets/db.txt      isi file (#key untuk file YAML/JSON)
+//	env://DB_PASSWORD            variabel lingkungan proses neon
+//	exec://pass show db          stdout perintah shell
+//
+// Resolver menyimpan hasil selama satu proses deploy sehingga secret yang
+// sama hanya diambil sekali.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/neon/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Ref adalah referensi secret hasil parse URI.
+type Ref struct {
+	Scheme string
+	// Path adalah bagian setelah scheme, tanpa fragment.
+	Path string
+	// Key adalah fragment (#key), kosong jika tidak ada.
+	Key string
+	Raw string
+}
+
+func (r *Ref) String() string {
+	return r.Raw
+}
+
+// Provider mengambil nilai secret untuk satu scheme.
+type Provider interface {
+	Resolve(ctx context.Context, ref *Ref) (string, error)
+}
+
+// ProviderFunc mengubah fungsi biasa menjadi Provider.
+type ProviderFunc func(ctx context.Context, ref *Ref) (string, error)
+
+func (f ProviderFunc) Resolve(ctx context.Context, ref *Ref) (string, error) {
+	return f(ctx, ref)
+}
+
+// schemes adalah scheme bawaan; dipakai IsRef tanpa membuat Resolver.
+var schemes = []string{"vault", "file", "env", "exec"}
+
+// Parse membaca URI secret. ok bernilai false jika s bukan referensi secret.
+func Parse(s string) (ref *Ref, ok bool) {
+	scheme, rest, found := strings.Cut(s, "://")
+	if !found || !contains(schemes, scheme) {
+		return nil, false
+	}
+
+	ref = &Ref{Scheme: scheme, Raw: s}
+	// Perintah exec boleh berisi '#', jadi fragment tidak dipisah
+	if scheme == "exec" {
+		ref.Path = rest
+		return ref, true
+	}
+	ref.Path, ref.Key, _ = strings.Cut(rest, "#")
+	return ref, true
+}
+
+// IsRef melaporkan apakah s adalah referensi ke secret eksternal.
+func IsRef(s string) bool {
+	_, ok := Parse(s)
+	return ok
+}
+
+// Resolver mengambil secret melalui provider per scheme dan menyimpan
+// hasilnya.
+type Resolver struct {
+	providers map[string]Provider
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewResolver membuat Resolver dengan provider bawaan. Provider vault
+// dibuat dari bagian secrets.vault di konfigurasi dan variabel VAULT_*.
+func NewResolver(cfg *config.Config) *Resolver {
+	r := &Resolver{
+		providers: make(map[string]Provider),
+		cache:     make(map[string]string),
+	}
+	r.Register("env", ProviderFunc(resolveEnv))
+	r.Register("file", ProviderFunc(resolveFile))
+	r.Register("exec", ProviderFunc(resolveExec))
+	r.Register("vault", NewVault(cfg.Secrets.Vault))
+	return r
+}
+
+// Register memasang provider untuk scheme, menggantikan provider lama.
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.providers[scheme] = p
+}
+
+// Resolve mengembalikan nilai secret jika value adalah referensi, atau
+// value apa adanya jika bukan.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok := Parse(value)
+	if !ok {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	cached, ok := r.cache[value]
+	r.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	p, ok := r.providers[ref.Scheme]
+	if !ok {
+		return "", fmt.Errorf("provider secret %s tidak tersedia", ref.Scheme)
+	}
+	secret, err := p.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", ref, err)
+	}
+
+	r.mu.Lock()
+	r.cache[value] = secret
+	r.mu.Unlock()
+	return secret, nil
+}
+
+// ResolveEnv mengganti nilai referensi pada daftar KEY=VALUE. env tidak
+// diubah; hasilnya slice baru.
+func (r *Resolver) ResolveEnv(ctx context.Context, env []string) ([]string, error) {
+	resolved := make([]string, len(env))
+	for i, kv := range env {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !IsRef(value) {
+			resolved[i] = kv
+			continue
+		}
+		secret, err := r.Resolve(ctx, value)
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %v", key, err)
+		}
+		resolved[i] = key + "=" + secret
+	}
+	return resolved, nil
+}
+
+func resolveEnv(_ context.Context, ref *Ref) (string, error) {
+	value, ok := os.LookupEnv(ref.Path)
+	if !ok {
+		return "", fmt.Errorf("variabel %s tidak di-set", ref.Path)
+	}
+	return value, nil
+}
+
+// resolveFile membaca file://path. Path relatif dihitung dari direktori
+// kerja; file:///abs/path untuk path absolut.
+func resolveFile(_ context.Context, ref *Ref) (string, error) {
+	path := ref.Path
+	if u, err := url.PathUnescape(path); err == nil {
+		path = u
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if ref.Key == "" {
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return "", fmt.Errorf("file bukan YAML/JSON mapping: %v", err)
+	}
+	return field(values, ref.Key)
+}
+
+// resolveExec menjalankan perintah dengan sh -c dan memakai stdout-nya.
+func resolveExec(ctx context.Context, ref *Ref) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", ref.Path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// field mengambil values[key] sebagai string.
+func field(values map[string]interface{}, key string) (string, error) {
+	v, ok := values[key]
+	if !ok {
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return "", fmt.Errorf("key %q tidak ada (tersedia: %s)", key, strings.Join(keys, ", "))
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	case map[string]interface{}, []interface{}:
+		return "", fmt.Errorf("key %q bukan nilai tunggal", key)
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in     string
+		ok     bool
+		scheme string
+		path   string
+		key    string
+	}{
+		{in: "vault://kv/app#db_password", ok: true, scheme: "vault", path: "kv/app", key: "db_password"},
+		{in: "vault://kv/app?version=2#db_password", ok: true, scheme: "vault", path: "kv/app?version=2", key: "db_password"},
+		{in: "vault://kv/app", ok: true, scheme: "vault", path: "kv/app"},
+		{in: "file://./secrets/db.txt", ok: true, scheme: "file", path: "./secrets/db.txt"},
+		{in: "file:///etc/app/secrets.yaml#db", ok: true, scheme: "file", path: "/etc/app/secrets.yaml", key: "db"},
+		{in: "env://DB_PASSWORD", ok: true, scheme: "env", path: "DB_PASSWORD"},
+		{in: "exec://pass show db#prod", ok: true, scheme: "exec", path: "pass show db#prod"},
+		{in: "https://example.com/x#y"},
+		{in: "vault:kv/app"},
+		{in: "s3cret"},
+		{in: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			ref, ok := Parse(tt.in)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, ingin %v", ok, tt.ok)
+			}
+			if IsRef(tt.in) != tt.ok {
+				t.Errorf("IsRef = %v, ingin %v", !tt.ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if ref.Scheme != tt.scheme || ref.Path != tt.path || ref.Key != tt.key {
+				t.Errorf("Parse = {%q %q %q}, ingin {%q %q %q}", ref.Scheme, ref.Path, ref.Key, tt.scheme, tt.path, tt.key)
+			}
+			if ref.String() != tt.in {
+				t.Errorf("String = %q, ingin %q", ref.String(), tt.in)
+			}
+		})
+	}
+}
+
+func TestField(t *testing.T) {
+	values := map[string]interface{}{
+		"password": "s3cret",
+		"port":     5432,
+		"ratio":    0.5,
+		"enabled":  true,
+		"empty":    nil,
+		"nested":   map[string]interface{}{"a": "b"},
+		"list":     []interface{}{"a"},
+	}
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr string
+	}{
+		{key: "password", want: "s3cret"},
+		{key: "port", want: "5432"},
+		{key: "ratio", want: "0.5"},
+		{key: "enabled", want: "true"},
+		{key: "empty", want: ""},
+		{key: "nested", wantErr: `key "nested" bukan nilai tunggal`},
+		{key: "list", wantErr: `key "list" bukan nilai tunggal`},
+		{key: "missing", wantErr: `key "missing" tidak ada (tersedia: empty, enabled, list, nested, password, port, ratio)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := field(values, tt.key)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, ingin %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("field = %q, ingin %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zakirkun/neon/internal/config"
+)
+
+// Vault mengambil secret dari engine KV v2 HashiCorp Vault. Referensi
+// vault://<mount>/<path>#<field> dibaca dari /v1/<mount>/data/<path>;
+// ?version=N memilih versi tertentu. Tanpa #field, secret harus berisi
+// tepat satu field.
+type Vault struct {
+	cfg    config.VaultConfig
+	client *http.Client
+
+	mu    sync.Mutex
+	token string
+	// data menyimpan isi secret per path agar beberapa field dari path yang
+	// sama hanya membutuhkan satu request.
+	data map[string]map[string]interface{}
+}
+
+// NewVault membuat provider Vault. Nilai kosong di cfg diambil dari
+// VAULT_ADDR, VAULT_TOKEN (atau ~/.vault-token), VAULT_NAMESPACE,
+// VAULT_ROLE_ID, dan VAULT_SECRET_ID.
+func NewVault(cfg config.VaultConfig) *Vault {
+	setDefault(&cfg.Address, os.Getenv("VAULT_ADDR"))
+	setDefault(&cfg.Token, os.Getenv("VAULT_TOKEN"))
+	setDefault(&cfg.Namespace, os.Getenv("VAULT_NAMESPACE"))
+	setDefault(&cfg.AppRole.RoleID, os.Getenv("VAULT_ROLE_ID"))
+	setDefault(&cfg.AppRole.SecretID, os.Getenv("VAULT_SECRET_ID"))
+	setDefault(&cfg.AppRole.Mount, "approle")
+
+	return &Vault{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		data:   make(map[string]map[string]interface{}),
+	}
+}
+
+func (v *Vault) Resolve(ctx context.Context, ref *Ref) (string, error) {
+	if v.cfg.Address == "" {
+		return "", fmt.Errorf("alamat Vault tidak dikonfigurasi (secrets.vault.address atau VAULT_ADDR)")
+	}
+
+	path, query, _ := strings.Cut(ref.Path, "?")
+	mount, secretPath, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || secretPath == "" {
+		return "", fmt.Errorf("format referensi vault://<mount>/<path>#<field>")
+	}
+
+	endpoint := fmt.Sprintf("/v1/%s/data/%s", mount, secretPath)
+	if query != "" {
+		endpoint += "?" + query
+	}
+
+	values, err := v.read(ctx, endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.Key == "" {
+		if len(values) != 1 {
+			return "", fmt.Errorf("secret memiliki %d field; pilih salah satu dengan #field", len(values))
+		}
+		for k := range values {
+			return field(values, k)
+		}
+	}
+	return field(values, ref.Key)
+}
+
+func (v *Vault) read(ctx context.Context, endpoint string) (map[string]interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if values, ok := v.data[endpoint]; ok {
+		return values, nil
+	}
+
+	token, err := v.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodGet, endpoint, token, nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Data == nil {
+		return nil, fmt.Errorf("secret tidak ditemukan atau sudah dihapus")
+	}
+
+	v.data[endpoint] = resp.Data.Data
+	return resp.Data.Data, nil
+}
+
+// login mengembalikan token Vault: token statis, hasil login AppRole, atau
+// isi ~/.vault-token. Dipanggil dengan v.mu terkunci.
+func (v *Vault) login(ctx context.Context) (string, error) {
+	if v.token != "" {
+		return v.token, nil
+	}
+
+	switch {
+	case v.cfg.Token != "":
+		v.token = v.cfg.Token
+	case v.cfg.AppRole.RoleID != "":
+		body := map[string]string{
+			"role_id":   v.cfg.AppRole.RoleID,
+			"secret_id": v.cfg.AppRole.SecretID,
+		}
+		var resp struct {
+			Auth struct {
+				ClientToken string `json:"client_token"`
+			} `json:"auth"`
+		}
+		endpoint := fmt.Sprintf("/v1/auth/%s/login", strings.Trim(v.cfg.AppRole.Mount, "/"))
+		if err := v.do(ctx, http.MethodPost, endpoint, "", body, &resp); err != nil {
+			return "", fmt.Errorf("login AppRole gagal: %v", err)
+		}
+		if resp.Auth.ClientToken == "" {
+			return "", fmt.Errorf("login AppRole gagal: respons tanpa token")
+		}
+		v.token = resp.Auth.ClientToken
+	default:
+		homeDir, _ := os.UserHomeDir()
+		data, err := os.ReadFile(filepath.Join(homeDir, ".vault-token"))
+		if err != nil {
+			return "", fmt.Errorf("token Vault tidak ditemukan (secrets.vault.token, VAULT_TOKEN, AppRole, atau ~/.vault-token)")
+		}
+		v.token = strings.TrimSpace(string(data))
+	}
+	return v.token, nil
+}
+
+func (v *Vault) do(ctx context.Context, method, endpoint, token string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(v.cfg.Address, "/")+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&vaultErr)
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("secret tidak ditemukan (%s)", endpoint)
+		case http.StatusForbidden:
+			return fmt.Errorf("akses ditolak Vault (%s); periksa token dan policy", endpoint)
+		}
+		if len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("vault %d: %s", resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("vault %d (%s)", resp.StatusCode, endpoint)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func setDefault(dst *string, v string) {
+	if *dst == "" {
+		*dst = v
+	}
+}
@@ -0,0 +1,215 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zakirkun/neon/internal/config"
+)
+
+// fakeVault meniru endpoint KV v2 dan login AppRole yang dipakai Vault.
+type fakeVault struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	logins   int
+	requests map[string]int
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	t.Helper()
+	for _, name := range []string{"VAULT_ADDR", "VAULT_TOKEN", "VAULT_NAMESPACE", "VAULT_ROLE_ID", "VAULT_SECRET_ID"} {
+		t.Setenv(name, "")
+	}
+
+	f := &fakeVault{requests: make(map[string]int)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeVault) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Method == http.MethodPost && r.URL.Path == "/v1/auth/approle/login" {
+		f.logins++
+		var body struct {
+			RoleID   string `json:"role_id"`
+			SecretID string `json:"secret_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.RoleID != "role" || body.SecretID != "secret" {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"auth": map[string]string{"client_token": "approle-token"}})
+		return
+	}
+
+	f.requests[r.URL.RequestURI()]++
+	switch token := r.Header.Get("X-Vault-Token"); {
+	case token != "root" && token != "approle-token":
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	case r.URL.Path == "/v1/kv/data/denied":
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	var data map[string]interface{}
+	switch r.URL.Path {
+	case "/v1/kv/data/app":
+		data = map[string]interface{}{"db_password": "s3cret", "port": 5432, "tls": map[string]interface{}{"ca": "..."}}
+		if r.URL.Query().Get("version") == "1" {
+			data = map[string]interface{}{"db_password": "old"}
+		}
+	case "/v1/kv/data/single":
+		data = map[string]interface{}{"token": "abc"}
+	case "/v1/kv/data/deleted":
+		// Versi terakhir yang dihapus mengembalikan data null
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"data": data}})
+}
+
+func (f *fakeVault) count(uri string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[uri]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func resolveRef(t *testing.T, v *Vault, uri string) (string, error) {
+	t.Helper()
+	ref, ok := Parse(uri)
+	if !ok {
+		t.Fatalf("Parse(%q) gagal", uri)
+	}
+	return v.Resolve(context.Background(), ref)
+}
+
+func TestVaultResolve(t *testing.T) {
+	f := newFakeVault(t)
+	v := NewVault(config.VaultConfig{Address: f.URL + "/", Token: "root"})
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "vault://kv/app#db_password", want: "s3cret"},
+		{ref: "vault://kv/app#port", want: "5432"},
+		{ref: "vault://kv/app?version=1#db_password", want: "old"},
+		{ref: "vault://kv/single", want: "abc"},
+		{ref: "vault://kv/app", wantErr: "secret memiliki 3 field"},
+		{ref: "vault://kv/app#tls", wantErr: `key "tls" bukan nilai tunggal`},
+		{ref: "vault://kv/app#missing", wantErr: `key "missing" tidak ada (tersedia: db_password, port, tls)`},
+		{ref: "vault://kv/deleted#x", wantErr: "secret tidak ditemukan atau sudah dihapus"},
+		{ref: "vault://kv/missing#x", wantErr: "secret tidak ditemukan (/v1/kv/data/missing)"},
+		{ref: "vault://kv/denied#x", wantErr: "akses ditolak Vault (/v1/kv/data/denied); periksa token dan policy"},
+		{ref: "vault://kv#x", wantErr: "format referensi vault://<mount>/<path>#<field>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := resolveRef(t, v, tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, ingin mengandung %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("nilai = %q, ingin %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVaultAppRole(t *testing.T) {
+	f := newFakeVault(t)
+
+	cfg := config.VaultConfig{Address: f.URL}
+	cfg.AppRole.RoleID = "role"
+	cfg.AppRole.SecretID = "secret"
+	v := NewVault(cfg)
+
+	for _, ref := range []string{"vault://kv/app#db_password", "vault://kv/single#token"} {
+		if _, err := resolveRef(t, v, ref); err != nil {
+			t.Fatalf("%s: %v", ref, err)
+		}
+	}
+	if f.logins != 1 {
+		t.Errorf("login AppRole %d kali, ingin 1", f.logins)
+	}
+
+	cfg.AppRole.SecretID = "wrong"
+	_, err := resolveRef(t, NewVault(cfg), "vault://kv/app#db_password")
+	if err == nil || !strings.Contains(err.Error(), "login AppRole gagal: vault 400: invalid role or secret ID") {
+		t.Fatalf("error = %v, ingin login AppRole gagal", err)
+	}
+}
+
+func TestVaultTokenRejected(t *testing.T) {
+	f := newFakeVault(t)
+	v := NewVault(config.VaultConfig{Address: f.URL, Token: "expired"})
+
+	_, err := resolveRef(t, v, "vault://kv/app#db_password")
+	if err == nil || !strings.Contains(err.Error(), "akses ditolak Vault") {
+		t.Fatalf("error = %v, ingin akses ditolak", err)
+	}
+}
+
+func TestVaultNoAddress(t *testing.T) {
+	newFakeVault(t)
+	_, err := resolveRef(t, NewVault(config.VaultConfig{Token: "root"}), "vault://kv/app#db_password")
+	if err == nil || !strings.Contains(err.Error(), "alamat Vault tidak dikonfigurasi") {
+		t.Fatalf("error = %v, ingin alamat Vault tidak dikonfigurasi", err)
+	}
+}
+
+func TestResolverCachesPerRun(t *testing.T) {
+	f := newFakeVault(t)
+
+	cfg := &config.Config{}
+	cfg.Secrets.Vault = config.VaultConfig{Address: f.URL, Token: "root"}
+	r := NewResolver(cfg)
+
+	env := []string{
+		"DB_PASSWORD=vault://kv/app#db_password",
+		"DB_PORT=vault://kv/app#port",
+		"OLD_PASSWORD=vault://kv/app?version=1#db_password",
+	}
+	for i := 0; i < 2; i++ {
+		got, err := r.ResolveEnv(context.Background(), env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"DB_PASSWORD=s3cret", "DB_PORT=5432", "OLD_PASSWORD=old"}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("env = %v, ingin %v", got, want)
+		}
+	}
+
+	if n := f.count("/v1/kv/data/app"); n != 1 {
+		t.Errorf("/v1/kv/data/app dibaca %d kali, ingin 1", n)
+	}
+	if n := f.count("/v1/kv/data/app?version=1"); n != 1 {
+		t.Errorf("/v1/kv/data/app?version=1 dibaca %d kali, ingin 1", n)
+	}
+}